@@ -0,0 +1,124 @@
+// SPDX-License-Identifier: MIT
+// Copyright (c) 2026 WoozyMasta
+// Source: github.com/woozymasta/lzo
+
+package lzo
+
+import (
+	"math"
+	"slices"
+)
+
+// Assembler builds an LZO1X stream from an explicit parse: literal runs and
+// back-references chosen by the caller. It uses the same instruction encoders
+// as the LZO1X-999 compressor and rejects requests that have no legal opcode,
+// so every stream it finishes decodes with any LZO1X decoder.
+// The zero value is ready to use. An Assembler must not be used concurrently.
+type Assembler struct {
+	out []byte // out is the encoded stream without pending literals.
+	lit []byte // lit holds literals not yet written; consecutive runs merge.
+
+	outLen int  // outLen is the decompressed size of everything requested so far.
+	done   bool // done reports whether Finish has written the terminator.
+}
+
+// NewAssembler returns an Assembler that builds the stream in the storage of dst[:0].
+func NewAssembler(dst []byte) *Assembler {
+	return &Assembler{out: dst[:0]}
+}
+
+// Reset discards all state and prepares a to build a new stream in dst[:0].
+func (a *Assembler) Reset(dst []byte) {
+	lit := a.lit[:0]
+	*a = Assembler{out: dst[:0], lit: lit}
+}
+
+// Len returns the decompressed size of the stream assembled so far.
+func (a *Assembler) Len() int {
+	return a.outLen
+}
+
+// Literals appends b as literal bytes. Consecutive calls form one literal run.
+func (a *Assembler) Literals(b []byte) error {
+	if a.done {
+		return ErrWriterClosed
+	}
+	if len(b) > math.MaxInt-a.outLen {
+		return ErrInvalidToken
+	}
+
+	a.lit = append(a.lit, b...)
+	a.outLen += len(b)
+	return nil
+}
+
+// Match appends a back-reference copying length bytes from distance bytes back.
+// It returns ErrLookBehindUnderrun when distance reaches before the start of the
+// output, and ErrInvalidToken when no LZO1X opcode can encode the match here:
+// lengths below 2, distances above 0xbfff, or a 2-byte match that is not
+// preceded by 1–3 literals or reaches further than 0x400.
+func (a *Assembler) Match(length, distance int) error {
+	if a.done {
+		return ErrWriterClosed
+	}
+	if distance > a.outLen {
+		return ErrLookBehindUnderrun
+	}
+	if !encodableMatch(length, distance, len(a.lit)) || length > math.MaxInt-a.outLen {
+		return ErrInvalidToken
+	}
+
+	literalLen := len(a.lit)
+	if err := a.flushLiterals(); err != nil {
+		return err
+	}
+
+	a.grow(length/255 + 5)
+	outPos := len(a.out)
+	if err := encodeLookbackMatch(a.out[:cap(a.out)], &outPos, length, distance, literalLen, 0, formatLZO1X); err != nil {
+		return err
+	}
+	a.out = a.out[:outPos]
+	a.outLen += length
+
+	return nil
+}
+
+// Finish writes pending literals and the stream terminator and returns the
+// complete stream. Further calls other than Reset return ErrWriterClosed.
+func (a *Assembler) Finish() ([]byte, error) {
+	if a.done {
+		return nil, ErrWriterClosed
+	}
+	if err := a.flushLiterals(); err != nil {
+		return nil, err
+	}
+
+	// Standard LZO end marker (M4 with distance class bit and zero payload).
+	a.out = append(a.out, markerM4|1, 0, 0)
+	a.done = true
+
+	return a.out, nil
+}
+
+// flushLiterals encodes the pending literal run.
+func (a *Assembler) flushLiterals() error {
+	if len(a.lit) == 0 {
+		return nil
+	}
+
+	a.grow(len(a.lit) + len(a.lit)/255 + 3)
+	outPos := len(a.out)
+	if err := encodeLiteralRun(a.out[:cap(a.out)], &outPos, a.lit, 0, len(a.lit), formatLZO1X); err != nil {
+		return err
+	}
+	a.out = a.out[:outPos]
+	a.lit = a.lit[:0]
+
+	return nil
+}
+
+// grow makes room for n more bytes of encoded output.
+func (a *Assembler) grow(n int) {
+	a.out = slices.Grow(a.out, n)
+}
@@ -0,0 +1,171 @@
+package lzo
+
+import (
+	"bytes"
+	"errors"
+	"math/rand"
+	"testing"
+)
+
+// reassemble feeds the tokens of src into an Assembler and returns the new stream.
+func reassemble(t *testing.T, src []byte) []byte {
+	t.Helper()
+
+	var a Assembler
+	for tok, err := range Tokens(src) {
+		if err != nil {
+			t.Fatalf("Tokens failed: %v", err)
+		}
+		if tok.Kind == TokenEnd {
+			break
+		}
+		if tok.Kind >= TokenM1 {
+			if err := a.Match(tok.MatchLen, tok.Distance); err != nil {
+				t.Fatalf("Match(%d, %d) at in=%d: %v", tok.MatchLen, tok.Distance, tok.InOffset, err)
+			}
+		}
+		end := tok.InOffset + tok.Size
+		if err := a.Literals(src[end-tok.LiteralLen : end]); err != nil {
+			t.Fatalf("Literals failed: %v", err)
+		}
+	}
+
+	out, err := a.Finish()
+	if err != nil {
+		t.Fatalf("Finish failed: %v", err)
+	}
+	return out
+}
+
+func TestAssemblerReproducesCompressedStreams(t *testing.T) {
+	inputs := testInputSet(t)
+	inputs = append(inputs, struct {
+		name string
+		data []byte
+	}{name: "far-matches", data: ringTestInput()})
+
+	for _, in := range inputs {
+		for _, level := range []int{1, 9} {
+			cmp, err := Compress(in.data, &CompressOptions{Level: level})
+			if err != nil {
+				t.Fatalf("%s level=%d: Compress failed: %v", in.name, level, err)
+			}
+
+			got := reassemble(t, cmp)
+			// LZO1X-999 uses the same instruction encoders, so its streams are reproduced exactly.
+			if level == 9 && !bytes.Equal(got, cmp) {
+				t.Fatalf("%s: reassembled stream differs at byte %d", in.name, firstMismatchBytes(got, cmp))
+			}
+
+			out, err := DecompressInto(got, make([]byte, len(in.data)))
+			if err != nil || !bytes.Equal(out, in.data) {
+				t.Fatalf("%s level=%d: reassembled stream does not decode: %v", in.name, level, err)
+			}
+		}
+	}
+}
+
+func TestAssemblerRandomParseRoundTrip(t *testing.T) {
+	rng := rand.New(rand.NewSource(9))
+	for iter := range 200 {
+		var (
+			a      Assembler
+			want   []byte
+			litRun int
+		)
+		for range 1 + rng.Intn(64) {
+			if len(want) > 0 && rng.Intn(2) == 0 {
+				length := 2 + rng.Intn(300)
+				distance := 1 + rng.Intn(min(len(want), maxOffsetM4))
+				err := a.Match(length, distance)
+				if !encodableMatch(length, distance, litRun) {
+					if !errors.Is(err, ErrInvalidToken) {
+						t.Fatalf("iter %d: Match(%d, %d) after %d literals: expected ErrInvalidToken, got %v", iter, length, distance, litRun, err)
+					}
+					continue
+				}
+				if err != nil {
+					t.Fatalf("iter %d: Match(%d, %d) failed: %v", iter, length, distance, err)
+				}
+				for range length {
+					want = append(want, want[len(want)-distance])
+				}
+				litRun = 0
+				continue
+			}
+
+			lit := make([]byte, 1+rng.Intn(300))
+			rng.Read(lit)
+			if err := a.Literals(lit); err != nil {
+				t.Fatalf("iter %d: Literals failed: %v", iter, err)
+			}
+			want = append(want, lit...)
+			litRun += len(lit)
+		}
+
+		if a.Len() != len(want) {
+			t.Fatalf("iter %d: Len=%d want=%d", iter, a.Len(), len(want))
+		}
+		stream, err := a.Finish()
+		if err != nil {
+			t.Fatalf("iter %d: Finish failed: %v", iter, err)
+		}
+		out, nRead, err := DecompressNInto(stream, make([]byte, len(want)))
+		if err != nil || nRead != len(stream) || !bytes.Equal(out, want) {
+			t.Fatalf("iter %d: round trip failed: %v", iter, err)
+		}
+	}
+}
+
+func TestAssemblerRejectsIllegalRequests(t *testing.T) {
+	a := NewAssembler(nil)
+	if err := a.Match(3, 1); !errors.Is(err, ErrLookBehindUnderrun) {
+		t.Fatalf("match at start: expected ErrLookBehindUnderrun, got %v", err)
+	}
+	if err := a.Literals(bytes.Repeat([]byte{'x'}, 2000)); err != nil {
+		t.Fatalf("Literals failed: %v", err)
+	}
+
+	tests := []struct {
+		name             string
+		length, distance int
+	}{
+		{name: "too-short", length: 1, distance: 1},
+		{name: "zero-distance", length: 3, distance: 0},
+		{name: "m1-after-4-literals", length: 2, distance: 1},
+	}
+	for _, tt := range tests {
+		if err := a.Match(tt.length, tt.distance); !errors.Is(err, ErrInvalidToken) {
+			t.Fatalf("%s: expected ErrInvalidToken, got %v", tt.name, err)
+		}
+	}
+
+	if err := a.Match(4, 1); err != nil {
+		t.Fatalf("Match failed: %v", err)
+	}
+	if err := a.Match(2, 1); !errors.Is(err, ErrInvalidToken) {
+		t.Fatalf("m1 without literals: expected ErrInvalidToken, got %v", err)
+	}
+	if err := a.Literals([]byte("ab")); err != nil {
+		t.Fatalf("Literals failed: %v", err)
+	}
+	if err := a.Match(2, maxOffsetM1+1); !errors.Is(err, ErrInvalidToken) {
+		t.Fatalf("m1 too far: expected ErrInvalidToken, got %v", err)
+	}
+	if err := a.Match(2, maxOffsetM1); err != nil {
+		t.Fatalf("m1: %v", err)
+	}
+
+	if _, err := a.Finish(); err != nil {
+		t.Fatalf("Finish failed: %v", err)
+	}
+	if err := a.Literals([]byte("x")); !errors.Is(err, ErrWriterClosed) {
+		t.Fatalf("after Finish: expected ErrWriterClosed, got %v", err)
+	}
+
+	a.Reset(nil)
+	stream, err := a.Finish()
+	if err != nil || !bytes.Equal(stream, []byte{markerM4 | 1, 0, 0}) {
+		t.Fatalf("empty stream: got %x, %v", stream, err)
+	}
+}
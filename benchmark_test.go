@@ -3,6 +3,7 @@ package lzo
 import (
 	"bytes"
 	"fmt"
+	"io"
 	"runtime"
 	"sync"
 	"testing"
@@ -107,6 +108,174 @@ func BenchmarkCompress(b *testing.B) {
 	}
 }
 
+func BenchmarkCompressExact(b *testing.B) {
+	for _, input := range benchmarkCompressionInputs() {
+		for _, level := range []int{1, 9} {
+			name := fmt.Sprintf("%s/level-%d", input.name, level)
+			b.Run(name, func(b *testing.B) {
+				opts := &CompressOptions{Level: level, Exact: true}
+				compressed, err := Compress(input.data, opts)
+				if err != nil {
+					b.Fatalf("setup Compress failed: %v", err)
+				}
+
+				b.ReportAllocs()
+				b.SetBytes(int64(len(input.data)))
+				b.ResetTimer()
+
+				for i := 0; i < b.N; i++ {
+					_, err := Compress(input.data, opts)
+					if err != nil {
+						b.Fatalf("Compress failed: %v", err)
+					}
+				}
+
+				reportCompressionMetrics(b, compressed, input.data)
+			})
+		}
+	}
+}
+
+func BenchmarkCompressOptimal(b *testing.B) {
+	for _, input := range benchmarkCompressionInputs() {
+		for _, level := range []int{1, 4, 7} {
+			name := fmt.Sprintf("%s/level-%d", input.name, level)
+			b.Run(name, func(b *testing.B) {
+				opts := &CompressOptions{Level: level, Optimal: true}
+				compressed, err := Compress(input.data, opts)
+				if err != nil {
+					b.Fatalf("setup Compress failed: %v", err)
+				}
+
+				b.ReportAllocs()
+				b.SetBytes(int64(len(input.data)))
+				b.ResetTimer()
+
+				for i := 0; i < b.N; i++ {
+					_, err := Compress(input.data, opts)
+					if err != nil {
+						b.Fatalf("Compress failed: %v", err)
+					}
+				}
+
+				reportCompressionMetrics(b, compressed, input.data)
+			})
+		}
+	}
+}
+
+func BenchmarkCompress999MatchFinder(b *testing.B) {
+	finders := []struct {
+		name   string
+		finder hcMatchFinder
+	}{
+		{name: "hash-chain", finder: hcFinderHashChain},
+		{name: "binary-tree", finder: hcFinderBinaryTree},
+	}
+
+	for _, input := range benchmarkCompressionInputs() {
+		for _, level := range []int{5, 9, hcOptimalLevel, hcMaxLevel} {
+			for _, finder := range finders {
+				name := fmt.Sprintf("%s/level-%d/%s", input.name, level, finder.name)
+				b.Run(name, func(b *testing.B) {
+					dict := &hcCompressorDict{}
+					out := make([]byte, MaxCompressedSize(len(input.data)))
+					outLen, err := compress999Finder(input.data, out, dict, level, nil, formatLZO1X, finder.finder)
+					if err != nil {
+						b.Fatalf("setup compress999Finder failed: %v", err)
+					}
+					compressed := append([]byte(nil), out[:outLen]...)
+
+					b.ReportAllocs()
+					b.SetBytes(int64(len(input.data)))
+					b.ResetTimer()
+
+					for i := 0; i < b.N; i++ {
+						if _, err := compress999Finder(input.data, out, dict, level, nil, formatLZO1X, finder.finder); err != nil {
+							b.Fatalf("compress999Finder failed: %v", err)
+						}
+					}
+
+					reportCompressionMetrics(b, compressed, input.data)
+				})
+			}
+		}
+	}
+}
+
+func BenchmarkCompressParallel(b *testing.B) {
+	input := benchmarkMixedBytes(8 << 20)
+	opts := &CompressOptions{Level: 9}
+
+	for _, workers := range []int{1, 2, 4, 8} {
+		b.Run(fmt.Sprintf("workers-%d", workers), func(b *testing.B) {
+			compressed, err := CompressParallel(input, opts, workers)
+			if err != nil {
+				b.Fatalf("setup CompressParallel failed: %v", err)
+			}
+
+			b.ReportAllocs()
+			b.SetBytes(int64(len(input)))
+			b.ResetTimer()
+
+			for i := 0; i < b.N; i++ {
+				if _, err := CompressParallel(input, opts, workers); err != nil {
+					b.Fatalf("CompressParallel failed: %v", err)
+				}
+			}
+
+			reportCompressionMetrics(b, compressed, input)
+		})
+	}
+}
+
+func BenchmarkStreamConcurrency(b *testing.B) {
+	input := benchmarkMixedBytes(8 << 20)
+
+	for _, concurrency := range []int{1, 4} {
+		opts := &CompressOptions{Level: 5, Concurrency: concurrency}
+		var stream bytes.Buffer
+		w := NewWriter(&stream, opts)
+		if _, err := w.Write(input); err != nil {
+			b.Fatalf("setup Write failed: %v", err)
+		}
+		if err := w.Close(); err != nil {
+			b.Fatalf("setup Close failed: %v", err)
+		}
+		compressed := stream.Bytes()
+
+		b.Run(fmt.Sprintf("write/concurrency-%d", concurrency), func(b *testing.B) {
+			b.ReportAllocs()
+			b.SetBytes(int64(len(input)))
+			b.ResetTimer()
+
+			for i := 0; i < b.N; i++ {
+				w.Reset(io.Discard)
+				if _, err := w.Write(input); err != nil {
+					b.Fatalf("Write failed: %v", err)
+				}
+				if err := w.Close(); err != nil {
+					b.Fatalf("Close failed: %v", err)
+				}
+			}
+		})
+
+		b.Run(fmt.Sprintf("read/concurrency-%d", concurrency), func(b *testing.B) {
+			r := NewReaderOptions(bytes.NewReader(compressed), &DecompressOptions{Concurrency: concurrency})
+			b.ReportAllocs()
+			b.SetBytes(int64(len(input)))
+			b.ResetTimer()
+
+			for i := 0; i < b.N; i++ {
+				r.Reset(bytes.NewReader(compressed))
+				if _, err := io.Copy(io.Discard, r); err != nil {
+					b.Fatalf("io.Copy failed: %v", err)
+				}
+			}
+		})
+	}
+}
+
 func BenchmarkCompressInto(b *testing.B) {
 	benchmarkCompressCallerBuffer(b, false)
 }
@@ -156,7 +325,7 @@ func BenchmarkCompress999Core(b *testing.B) {
 	b.ResetTimer()
 
 	for i := 0; i < b.N; i++ {
-		if _, err := compress999NoAlloc(input, out, dict, 9); err != nil {
+		if _, err := compress999NoAlloc(input, out, dict, 9, nil, formatLZO1X); err != nil {
 			b.Fatal(err)
 		}
 	}
@@ -24,23 +24,33 @@ func NewEncoder() *Encoder {
 
 // Compress compresses src with LZO1X. opts may be nil (uses default level 1).
 // Level 0 or 1 = fast LZO1X-1; 2–9 = LZO1X-999 (better ratio, slower).
+// opts.Optimal selects LZO1X-999 with an optimal parse (best ratio, much slower).
+// opts.Method selects another LZO1X-1 hash size or LZO1X-999 explicitly.
+// With opts.Dict set, the stream may reference the dictionary and must be
+// decoded with DecompressWithDict.
 func Compress(src []byte, opts *CompressOptions) ([]byte, error) {
 	if opts == nil {
 		opts = DefaultCompressOptions()
 	}
-	level := max(opts.Level, 0)
+	dictBits, level, err := compressPlan(opts)
+	if err != nil {
+		return nil, err
+	}
 
-	if level <= 1 {
+	if dictBits > 0 {
 		required := MaxCompressedSize(len(src))
 		buf := acquireCompressBuffer(required)
-		tmp := compress1xFast(buf.data[:0], src)
+		tmp := compress1x1(buf.data[:0], src, dictBits, opts.Exact, formatLZO1X)
 		result := make([]byte, len(tmp))
 		copy(result, tmp)
 		releaseCompressBuffer(buf)
 		return result, nil
 	}
+	if opts.Exact {
+		return compress999ExactLevel(src, level, opts.Dict, formatLZO1X)
+	}
 
-	return compress999Level(src, min(level, 9))
+	return compress999Level(src, level, opts.Dict, formatLZO1X)
 }
 
 // CompressInto compresses src into caller-provided dst and returns dst[:n].
@@ -123,18 +133,26 @@ func appendCompress(dst, src []byte, opts *CompressOptions) ([]byte, error) {
 	if opts == nil {
 		opts = DefaultCompressOptions()
 	}
-	level := opts.Level
-	level = max(level, 0)
+	dictBits, level, err := compressPlan(opts)
+	if err != nil {
+		return nil, err
+	}
 
-	if level <= 1 {
-		return compress1xFast(dst, src), nil
+	if dictBits > 0 {
+		return compress1x1(dst, src, dictBits, opts.Exact, formatLZO1X), nil
+	}
+	if opts.Exact {
+		outLen, err := compress999Exact(src, dst[:cap(dst)], level, opts.Dict, formatLZO1X)
+		if err != nil {
+			return nil, err
+		}
+		return dst[:outLen], nil
 	}
 
-	level = min(level, 9)
 	dict := acquireCompressorDict()
 	defer releaseCompressorDict(dict)
 
-	outLen, err := compress999NoAlloc(src, dst[:cap(dst)], dict, level)
+	outLen, err := compress999NoAlloc(src, dst[:cap(dst)], dict, level, opts.Dict, formatLZO1X)
 	if err != nil {
 		return nil, err
 	}
@@ -145,22 +163,77 @@ func (e *Encoder) appendCompress(dst, src []byte, opts *CompressOptions) ([]byte
 	if opts == nil {
 		opts = DefaultCompressOptions()
 	}
-	level := max(opts.Level, 0)
-	if level <= 1 {
-		return compress1xFast(dst, src), nil
+	dictBits, level, err := compressPlan(opts)
+	if err != nil {
+		return nil, err
+	}
+	if dictBits > 0 {
+		return compress1x1(dst, src, dictBits, opts.Exact, formatLZO1X), nil
+	}
+	if opts.Exact {
+		outLen, err := compress999Exact(src, dst[:cap(dst)], level, opts.Dict, formatLZO1X)
+		if err != nil {
+			return nil, err
+		}
+		return dst[:outLen], nil
 	}
 
 	if e.dict == nil {
 		e.dict = &hcCompressorDict{}
 	}
 
-	outLen, err := compress999NoAlloc(src, dst[:cap(dst)], e.dict, min(level, 9))
+	outLen, err := compress999NoAlloc(src, dst[:cap(dst)], e.dict, level, opts.Dict, formatLZO1X)
 	if err != nil {
 		return nil, err
 	}
 	return dst[:outLen], nil
 }
 
+// compressPlan resolves opts to a compression engine: the hash table size in
+// bits for LZO1X-1, or 0 and the level for LZO1X-999. Optimal maps levels
+// 1–3, 4–6 and 7–9 to the internal optimal-parse levels 10, 11 and 12.
+func compressPlan(opts *CompressOptions) (dictBits, level int, err error) {
+	level = min(max(opts.Level, 1), 9)
+	if opts.Optimal && !opts.Exact {
+		level = hcOptimalLevel + (level-1)/3
+	}
+
+	switch opts.Method {
+	case MethodAuto:
+		if opts.Level > 1 || opts.Optimal {
+			return 0, level, nil
+		}
+		dictBits = dictBits1X1
+	case MethodLZO1X1:
+		dictBits = dictBits1X1
+	case MethodLZO1X1_11:
+		dictBits = dictBits1X111
+	case MethodLZO1X1_12:
+		dictBits = dictBits1X112
+	case MethodLZO1X1_15:
+		dictBits = dictBits1X115
+	case MethodLZO1X999:
+		return 0, level, nil
+	default:
+		return 0, 0, ErrUnknownMethod
+	}
+
+	// The LZO1X-1 parser has no dictionary support.
+	if len(opts.Dict) > 0 {
+		return 0, 1, nil
+	}
+	return dictBits, 0, nil
+}
+
+// compress1x1 runs the LZO1X-1 engine with a hash table of 1<<dictBits entries:
+// the liblzo2 replica when exact is set, the fast parser otherwise.
+func compress1x1(out, in []byte, dictBits int, exact bool, f format) []byte {
+	if exact {
+		return compress1xExact(out, in, dictBits, f)
+	}
+	return compress1xFast(out, in, dictBits, f)
+}
+
 // opcodeByte packs an opcode fragment to one byte as required by LZO bit layout.
 // Callers pass values whose low 8 bits are the serialized representation.
 func opcodeByte(v int) byte {
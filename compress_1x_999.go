@@ -86,6 +86,10 @@ type hcCompressorDict struct {
 	match3 hcMatch3Table           // match3 is the primary index for long matches.
 	match2 hcMatch2Table           // match2 is the fallback index for very short matches.
 	buffer [hcBufferGuardSize]byte // buffer is the ring window plus guard bytes for wrap-safe compare.
+
+	finder  hcMatchFinder // finder selects match3 or tree as the long-match index.
+	tree    *hcBinaryTree // tree is the binary-tree index, allocated on first use.
+	matches []hcOptMatch  // matches is scratch space for tree search results.
 }
 
 // hcState tracks the sliding input window and current scan positions.
@@ -113,22 +117,19 @@ type hcBestOffsets struct {
 // Compress1X999Level compresses in with LZO1X-999 at the given level (1–9).
 // Higher levels increase search depth and improve ratio at the cost of speed.
 func Compress1X999Level(in []byte, level int) ([]byte, error) {
-	return compress999Level(in, level)
+	return compress999Level(in, min(level, 9), nil, formatLZO1X)
 }
 
 // Compress1X999 compresses in with LZO1X-999 at level 9 (best ratio).
 func Compress1X999(in []byte) ([]byte, error) {
-	return compress999Level(in, 9)
+	return compress999Level(in, 9, nil, formatLZO1X)
 }
 
-// compress999Level is the MIT-based LZO1X-999 compressor used for levels 2..9.
-func compress999Level(in []byte, level int) ([]byte, error) {
-	if level < 1 {
-		level = 1
-	}
-	if level > 9 {
-		level = 9
-	}
+// compress999Level is the MIT-based LZO1X-999 compressor used for levels 2..9
+// and, as levels 10..12, for the optimal parse.
+// preset is an optional dictionary that primes the window; f selects the output format.
+func compress999Level(in []byte, level int, preset []byte, f format) ([]byte, error) {
+	level = min(max(level, 1), hcMaxLevel)
 
 	dict := acquireCompressorDict()
 	defer releaseCompressorDict(dict)
@@ -136,7 +137,7 @@ func compress999Level(in []byte, level int) ([]byte, error) {
 	temp := acquireCompressBuffer(MaxCompressedSize(len(in)))
 	defer releaseCompressBuffer(temp)
 
-	outLen, err := compress999NoAlloc(in, temp.data, dict, level)
+	outLen, err := compress999NoAlloc(in, temp.data, dict, level, preset, f)
 	if err != nil {
 		return nil, err
 	}
@@ -146,22 +147,33 @@ func compress999Level(in []byte, level int) ([]byte, error) {
 	return out, nil
 }
 
-// compress999NoAlloc compresses in into out using the provided dictionary.
-func compress999NoAlloc(in []byte, out []byte, dict *hcCompressorDict, level int) (int, error) {
+// compress999NoAlloc compresses in into out as a stream of format f using the
+// provided dictionary. preset is an optional preset dictionary loaded into the
+// window before parsing.
+func compress999NoAlloc(in []byte, out []byte, dict *hcCompressorDict, level int, preset []byte, f format) (int, error) {
+	return compress999Finder(in, out, dict, level, preset, f, hcMatchFinderByLevel[min(max(level, 1), hcMaxLevel)])
+}
+
+// compress999Finder is compress999NoAlloc with an explicit match finder.
+func compress999Finder(in []byte, out []byte, dict *hcCompressorDict, level int, preset []byte, f format, finder hcMatchFinder) (int, error) {
 	if len(out) < 3 {
 		return 0, ErrCompressInternal
 	}
+	if level >= hcOptimalLevel {
+		return compress999Optimal(in, out, dict, level, preset, f, finder)
+	}
 
+	searchDepth := hcSearchDepthByLevel[level]
 	state := hcState{src: in}
-	dict.init(&state)
+	dict.init(&state, preset, finder, hcTreeNiceLen, searchDepth)
 
 	outPos := 0
 	literalLen := 0
+	lastOff := 0 // lastOff is the previous match distance, repeatable in LZO1Z.
 	// bestOffsetByLen caches alternative offsets discovered during search so
 	// we can later shorten a chosen match if that yields a cheaper opcode.
 	bestOffsets := hcBestOffsets{}
 	literalStart := state.inPos
-	searchDepth := hcSearchDepthByLevel[level]
 
 	// Prime the parser with the first candidate match.
 	matchOff, matchLen := dict.advance(&state, 0, &bestOffsets, false, searchDepth)
@@ -174,12 +186,11 @@ func compress999NoAlloc(in []byte, out []byte, dict *hcCompressorDict, level int
 
 		// Filter out candidates that are valid as "matches" algorithmically but
 		// cannot be emitted with legal LZO opcodes in the current stream context.
-		if matchLen < 2 ||
-			(matchLen == 2 && (matchOff > maxOffsetM1 || literalLen == 0 || literalLen >= 4)) ||
+		if !encodableMatch(matchLen, matchOff, literalLen) ||
 			(matchLen == 2 && outPos == 0) ||
 			(outPos == 0 && literalLen == 0) {
 			matchLen = 0
-		} else if matchLen == minLenM2 && matchOff > maxOffsetMX && literalLen >= 4 {
+		} else if matchLen == minLenM2 && matchOff > f.maxOffsetMX() && literalLen >= 4 && !repeatsOffset(f, matchLen, matchOff, lastOff) {
 			matchLen = 0
 		}
 
@@ -192,22 +203,25 @@ func compress999NoAlloc(in []byte, out []byte, dict *hcCompressorDict, level int
 
 		// Opcode cost is not monotonic in match length: sometimes a slightly
 		// shorter match with smaller offset encodes to fewer bytes overall.
-		findBetterMatch(bestOffsets.offsets[:], &matchLen, &matchOff)
+		if !repeatsOffset(f, matchLen, matchOff, lastOff) {
+			findBetterMatch(bestOffsets.offsets[:], &matchLen, &matchOff, f)
+		}
 
-		if err := encodeLiteralRun(out, &outPos, in, literalStart, literalLen); err != nil {
+		if err := encodeLiteralRun(out, &outPos, in, literalStart, literalLen, f); err != nil {
 			return 0, err
 		}
 
-		if err := encodeLookbackMatch(out, &outPos, matchLen, matchOff, literalLen); err != nil {
+		if err := encodeLookbackMatch(out, &outPos, matchLen, matchOff, literalLen, lastOff, f); err != nil {
 			return 0, err
 		}
 
 		prevLen := matchLen
+		lastOff = matchOff
 		literalLen = 0
 		matchOff, matchLen = dict.advance(&state, prevLen, &bestOffsets, true, searchDepth)
 	}
 
-	if err := encodeLiteralRun(out, &outPos, in, literalStart, literalLen); err != nil {
+	if err := encodeLiteralRun(out, &outPos, in, literalStart, literalLen, f); err != nil {
 		return 0, err
 	}
 
@@ -272,19 +286,39 @@ func canRetainCompressBuffer(buf *hcCompressBuffer) bool {
 }
 
 // init prepares dictionary and state for a new compression run.
-func (d *hcCompressorDict) init(state *hcState) {
-	d.match3.init()
+// The last hcMaxDist bytes of preset, if any, become history ahead of the input.
+// niceLen is the compare limit of the binary-tree finder, and searchDepth the
+// probe depth with which preset positions are inserted into its trees.
+func (d *hcCompressorDict) init(state *hcState, preset []byte, finder hcMatchFinder, niceLen, searchDepth int) {
+	d.finder = finder
 	d.match2.init()
+	if finder == hcFinderBinaryTree {
+		if d.tree == nil {
+			d.tree = new(hcBinaryTree)
+		}
+		d.tree.init(0, niceLen)
+	} else {
+		d.match3.init()
+	}
+
+	// Preset bytes occupy the ring right before the first parse position.
+	preset = preset[len(preset)-min(len(preset), hcMaxDist):]
+	copy(d.buffer[:len(preset)], preset)
 
 	// Initialize the ring window with as much lookahead as available.
-	state.cycleCountdown = hcMaxDist
 	state.inPos = 0
 	state.windSize = min(len(state.src), hcMaxMatchLen)
-	state.windB = 0
-	state.windE = state.windSize
+	state.windB = len(preset)
+	state.windE = state.windB + state.windSize
+
+	// Eviction starts once windE wraps onto the first occupied ring slot.
+	state.cycleCountdown = hcBufferSize - state.windE
+	if state.windE == hcBufferSize {
+		state.windE = 0
+	}
 
 	if state.windSize > 0 {
-		copy(d.buffer[:state.windSize], state.src[:state.windSize])
+		copy(d.buffer[state.windB:state.windB+state.windSize], state.src[:state.windSize])
 	}
 	state.inPos += state.windSize
 
@@ -296,10 +330,25 @@ func (d *hcCompressorDict) init(state *hcState) {
 			d.buffer[i] = 0
 		}
 	}
+
+	// Index preset positions so the parser finds matches into the dictionary.
+	for pos := range len(preset) {
+		if finder == hcFinderBinaryTree {
+			lenLimit := min(state.windB+state.windSize-pos, hcMaxMatchLen)
+			d.matches = d.tree.find(&d.buffer, pos, lenLimit, treeDepth(searchDepth), hcMaxMatchLen, d.matches[:0])
+		} else {
+			d.match3.insert(pos, &d.buffer)
+		}
+		d.match2.add(pos, &d.buffer)
+	}
 }
 
 // advance updates the dictionary window and returns the best current match.
 func (d *hcCompressorDict) advance(state *hcState, prevLen int, bestOffsets *hcBestOffsets, skip bool, searchDepth int) (int, int) {
+	if d.finder == hcFinderBinaryTree {
+		return d.advanceTree(state, prevLen, bestOffsets, skip, searchDepth)
+	}
+
 	// After emitting a match we still need to insert skipped bytes into both hash tables,
 	// but we do not need to search from each of those intermediate positions.
 	if skip && prevLen > 1 {
@@ -525,15 +574,20 @@ func (m *hcMatch3Table) advance(state *hcState, buffer *[hcBufferGuardSize]byte,
 
 // skipAdvance inserts current position without searching for a match.
 func (m *hcMatch3Table) skipAdvance(state *hcState, buffer *[hcBufferGuardSize]byte) {
-	key := match3Key(buffer, state.windB)
+	m.insert(state.windB, buffer)
+}
+
+// insert adds ring position pos to its 3-byte hash chain without searching.
+func (m *hcMatch3Table) insert(pos int, buffer *[hcBufferGuardSize]byte) {
+	key := match3Key(buffer, pos)
 
 	// Same rationale as in advance(): stale head is harmless while chainSz[key]==0.
 	head := m.head[key]
 
-	m.chain[state.windB] = head
-	m.slotKey[state.windB] = uint16(key) //nolint:gosec // G115: key is bounded by hcHashSize (0x4000)
-	m.head[key] = uint16(state.windB)    //nolint:gosec // G115: ring index always fits uint16
-	m.bestLen[state.windB] = hcMaxMatchLen + 1
+	m.chain[pos] = head
+	m.slotKey[pos] = uint16(key) //nolint:gosec // G115: key is bounded by hcHashSize (0x4000)
+	m.head[key] = uint16(pos)    //nolint:gosec // G115: ring index always fits uint16
+	m.bestLen[pos] = hcMaxMatchLen + 1
 	m.chainSz[key]++
 }
 
@@ -576,17 +630,18 @@ func (m *hcMatch2Table) search(state *hcState, matchPos *int, matchLen *int, buf
 }
 
 // findBetterMatch applies LZO opcode-cost heuristics to shorten a chosen match
-// when a nearby alternative yields smaller encoded size.
-func findBetterMatch(bestOffsetByLen []int, matchLen *int, matchOff *int) {
-	if *matchLen <= minLenM2 || *matchOff <= maxOffsetM2 {
+// when a nearby alternative yields smaller encoded size in format f.
+func findBetterMatch(bestOffsetByLen []int, matchLen *int, matchOff *int, f format) {
+	m2Off, m2Len := f.maxOffsetM2(), f.maxLenM2()
+	if *matchLen <= minLenM2 || *matchOff <= m2Off {
 		return
 	}
 
 	// Try L2 -> L1 reduction to fall into cheaper M2 distance class.
-	if *matchOff > maxOffsetM2 && *matchLen >= minLenM2+1 && *matchLen <= maxLenM2+1 {
+	if *matchOff > m2Off && *matchLen >= minLenM2+1 && *matchLen <= m2Len+1 {
 		shorterLen := *matchLen - 1
 		shorterOff := bestOffsetAt(bestOffsetByLen, shorterLen)
-		if shorterOff != 0 && shorterOff <= maxOffsetM2 {
+		if shorterOff != 0 && shorterOff <= m2Off {
 			*matchLen = shorterLen
 			*matchOff = shorterOff
 			return
@@ -594,11 +649,11 @@ func findBetterMatch(bestOffsetByLen []int, matchLen *int, matchOff *int) {
 	}
 
 	// Try L2 -> L0 reduction for far matches that can become a compact M2.
-	if *matchOff > maxOffsetM3 && *matchLen >= maxLenM4+1 && *matchLen <= maxLenM2+2 {
+	if *matchOff > maxOffsetM3 && *matchLen >= maxLenM4+1 && *matchLen <= m2Len+2 {
 		shorterLen := *matchLen - 2
 		shorterOff := bestOffsetAt(bestOffsetByLen, shorterLen)
 		currentOff := bestOffsetAt(bestOffsetByLen, *matchLen)
-		if shorterOff != 0 && currentOff <= maxOffsetM2 {
+		if shorterOff != 0 && currentOff <= m2Off {
 			*matchLen = shorterLen
 			*matchOff = shorterOff
 			return
@@ -626,8 +681,29 @@ func bestOffsetAt(bestOffsetByLen []int, idx int) int {
 	return bestOffsetByLen[idx]
 }
 
-// encodeLiteralRun writes one literal run and its length opcode.
-func encodeLiteralRun(out []byte, outPos *int, in []byte, literalStart, literalLen int) error {
+// repeatsOffset reports whether f encodes the match as a one-byte LZO1Z M2
+// repeating the previous distance, the cheapest form a match can take.
+func repeatsOffset(f format, matchLen, matchOff, lastOff int) bool {
+	return f == formatLZO1Z && matchLen > 2 && matchLen <= maxLenM2 && matchOff == lastOff
+}
+
+// encodableMatch reports whether a match of matchLen bytes at distance matchOff,
+// preceded by literalLen literals since the previous match, has a legal LZO1X opcode.
+// A 2-byte match exists only as M1, which needs 1–3 preceding literals
+// (literal state 1–3) and a distance within maxOffsetM1.
+func encodableMatch(matchLen, matchOff, literalLen int) bool {
+	if matchLen < 2 || matchOff < 1 || matchOff > maxOffsetM4 {
+		return false
+	}
+	if matchLen == 2 && (matchOff > maxOffsetM1 || literalLen == 0 || literalLen >= 4) {
+		return false
+	}
+
+	return true
+}
+
+// encodeLiteralRun writes one literal run of format f and its length opcode.
+func encodeLiteralRun(out []byte, outPos *int, in []byte, literalStart, literalLen int, f format) error {
 	if literalLen == 0 {
 		return nil
 	}
@@ -639,11 +715,16 @@ func encodeLiteralRun(out []byte, outPos *int, in []byte, literalStart, literalL
 			return err
 		}
 
-	// Very short literal runs are packed into low bits of the previous opcode.
+	// Very short literal runs are packed into low bits of the previous opcode;
+	// LZO1Z keeps them in the last byte of the previous match instead.
 	case literalLen <= 3:
 		if *outPos < 2 {
 			return ErrCompressInternal
 		}
+		if f == formatLZO1Z {
+			out[*outPos-1] |= opcodeByte(literalLen)
+			break
+		}
 		out[*outPos-2] |= opcodeByte(literalLen)
 
 	// Medium literal runs use one explicit length byte.
@@ -665,32 +746,41 @@ func encodeLiteralRun(out []byte, outPos *int, in []byte, literalStart, literalL
 	return writeSlice(out, outPos, in[literalStart:literalStart+literalLen])
 }
 
-// encodeLookbackMatch writes one back-reference token.
-func encodeLookbackMatch(out []byte, outPos *int, matchLen, matchOff, lastLiteralLen int) error {
+// encodeLookbackMatch writes one back-reference token of format f. lastOff is
+// the distance of the previous match, which LZO1Z encodes as a one-byte M2.
+func encodeLookbackMatch(out []byte, outPos *int, matchLen, matchOff, lastLiteralLen, lastOff int, f format) error {
 	switch {
 	// M1, 2-byte match, nearest distance class.
 	case matchLen == 2:
-		matchOff--
-		if err := writeByte(out, outPos, opcodeByte(markerM1|((matchOff&0x3)<<2))); err != nil {
-			return err
-		}
-		return writeByte(out, outPos, opcodeByte(matchOff>>2))
+		return writeShortDistance(out, outPos, markerM1, matchOff-1, f)
+
+	// LZO1Z M2 repeating the previous distance.
+	case repeatsOffset(f, matchLen, matchOff, lastOff):
+		return writeByte(out, outPos, opcodeByte((matchLen-1)<<5|repeatFieldM2))
 
 	// M2, short/medium distance class.
-	case matchLen <= maxLenM2 && matchOff <= maxOffsetM2:
+	case matchLen <= f.maxLenM2() && matchOff <= f.maxOffsetM2():
 		matchOff--
+		switch f {
+		case formatLZO1Y:
+			if err := writeByte(out, outPos, opcodeByte((matchLen+1)<<4|((matchOff&0x3)<<2))); err != nil {
+				return err
+			}
+			return writeByte(out, outPos, opcodeByte(matchOff>>2))
+		case formatLZO1Z:
+			if err := writeByte(out, outPos, opcodeByte((matchLen-1)<<5|(matchOff>>6))); err != nil {
+				return err
+			}
+			return writeByte(out, outPos, opcodeByte(matchOff<<2))
+		}
 		if err := writeByte(out, outPos, opcodeByte((matchLen-1)<<5|((matchOff&0x7)<<2))); err != nil {
 			return err
 		}
 		return writeByte(out, outPos, opcodeByte(matchOff>>3))
 
 	// M1 special case after >=4 literals (LZO opcode quirk).
-	case matchLen == minLenM2 && matchOff <= maxOffsetMX && lastLiteralLen >= 4:
-		matchOff -= 1 + maxOffsetM2
-		if err := writeByte(out, outPos, opcodeByte(markerM1|((matchOff&0x3)<<2))); err != nil {
-			return err
-		}
-		return writeByte(out, outPos, opcodeByte(matchOff>>2))
+	case matchLen == minLenM2 && matchOff <= f.maxOffsetMX() && lastLiteralLen >= 4:
+		return writeShortDistance(out, outPos, markerM1, matchOff-1-f.maxOffsetM2(), f)
 
 	// M3, longer match with medium distance.
 	case matchOff <= maxOffsetM3:
@@ -708,10 +798,7 @@ func encodeLookbackMatch(out []byte, outPos *int, matchLen, matchOff, lastLitera
 			}
 		}
 
-		if err := writeByte(out, outPos, opcodeByte((matchOff&0x3f)<<2)); err != nil {
-			return err
-		}
-		return writeByte(out, outPos, opcodeByte(matchOff>>6))
+		return writeLongDistance(out, outPos, matchOff, f)
 
 	// M4, farthest distance class.
 	case matchOff <= maxOffsetM4:
@@ -730,13 +817,43 @@ func encodeLookbackMatch(out []byte, outPos *int, matchLen, matchOff, lastLitera
 			}
 		}
 
-		if err := writeByte(out, outPos, opcodeByte((matchOff&0x3f)<<2)); err != nil {
+		return writeLongDistance(out, outPos, matchOff, f)
+	}
+
+	return ErrCompressInternal
+}
+
+// writeShortDistance writes an M1 opcode and its distance byte for a biased
+// distance below 0x400. LZO1X and LZO1Y put the low 2 bits in the opcode,
+// LZO1Z the high 4 bits.
+func writeShortDistance(out []byte, outPos *int, marker, dist int, f format) error {
+	if f == formatLZO1Z {
+		if err := writeByte(out, outPos, opcodeByte(marker|(dist>>6))); err != nil {
 			return err
 		}
-		return writeByte(out, outPos, opcodeByte(matchOff>>6))
+		return writeByte(out, outPos, opcodeByte(dist<<2))
 	}
 
-	return ErrCompressInternal
+	if err := writeByte(out, outPos, opcodeByte(marker|((dist&0x3)<<2))); err != nil {
+		return err
+	}
+	return writeByte(out, outPos, opcodeByte(dist>>2))
+}
+
+// writeLongDistance writes the two distance bytes of an M3 or M4 match,
+// low bits first except in LZO1Z.
+func writeLongDistance(out []byte, outPos *int, dist int, f format) error {
+	if f == formatLZO1Z {
+		if err := writeByte(out, outPos, opcodeByte(dist>>6)); err != nil {
+			return err
+		}
+		return writeByte(out, outPos, opcodeByte(dist<<2))
+	}
+
+	if err := writeByte(out, outPos, opcodeByte((dist&0x3f)<<2)); err != nil {
+		return err
+	}
+	return writeByte(out, outPos, opcodeByte(dist>>6))
 }
 
 // writeZeroByteLength writes long-length encoding as zero chunks plus tail.
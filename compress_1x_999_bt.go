@@ -0,0 +1,281 @@
+// SPDX-License-Identifier: MIT
+// Copyright (c) 2026 WoozyMasta
+// Source: github.com/woozymasta/lzo
+
+package lzo
+
+import (
+	"math/bits"
+	"unsafe"
+)
+
+// hcMatchFinder selects how the LZO1X-999 engine finds match candidates.
+type hcMatchFinder uint8
+
+const (
+	// hcFinderHashChain walks the hcMatch3Table 3-byte hash chains.
+	hcFinderHashChain hcMatchFinder = iota
+
+	// hcFinderBinaryTree searches hcBinaryTree, a BT4 binary-tree match finder.
+	hcFinderBinaryTree
+)
+
+const (
+	// hcTreeHashBits is the size in bits of the 4-byte hash table of tree roots.
+	hcTreeHashBits = 16
+
+	// hcTreeBase is the tree position of ring slot 0 for a fresh run. Positions
+	// below it never fall inside the window, so zeroed tables read as empty.
+	hcTreeBase = 1 << 16
+
+	// hcTreeNiceLen is the tree compare limit of the lazy parser levels.
+	hcTreeNiceLen = 256
+
+	// hcTreeNormalizeAt is the position at which stored positions are rebased
+	// so they never overflow uint32.
+	hcTreeNormalizeAt = 1 << 31
+
+	// hcTreeDepthScale divides a level's chain probe depth into its tree depth.
+	// A tree walk visits about log2 of the nodes a chain walk would, so the
+	// chain depths of levels 1-9 all exceed what the tree ever visits.
+	hcTreeDepthScale = 8
+)
+
+// hcMatchFinderByLevel maps compression level (1..12) to its match finder.
+//
+// Levels 11-12 search the binary tree. On source text it is about 1.5x faster
+// than the chains there, within 0.1% of their ratio. On periodic input it is
+// several times slower (BenchmarkCompress999MatchFinder): the chain walk stops
+// early on its bestLen hints, while the tree still compares up to niceLen
+// bytes per inserted position. The lazy parser levels keep the chains, since
+// the tree reports the longest match along its search path rather than the
+// nearest match of each length, which costs ratio where short LZO distances
+// encode cheaper.
+var hcMatchFinderByLevel = [hcMaxLevel + 1]hcMatchFinder{
+	11: hcFinderBinaryTree,
+	12: hcFinderBinaryTree,
+}
+
+// hcBinaryTree is a BT4 match finder over the hcCompressorDict ring. Every
+// 4-byte hash bucket holds a binary search tree of the window positions with
+// that hash, ordered by the bytes that follow them; inserting the current
+// position walks the tree once, collecting ever longer matches on the way and
+// re-rooting the tree at the new position. A 3-byte hash table adds the
+// nearest 3-byte match, which the tree cannot see.
+//
+// Positions are counted from hcTreeBase and stored as uint32; a stored
+// position more than hcMaxDist behind the current one is outside the window,
+// which also retires stale nodes without explicit removal.
+type hcBinaryTree struct {
+	head4 [1 << hcTreeHashBits]uint32 // head4 is the tree root per 4-byte hash.
+	head3 [hcHashSize]uint32          // head3 is the newest position per 3-byte hash.
+	son   [2 * hcBufferSize]uint32    // son holds the smaller and larger child per ring slot.
+	pos   uint32                      // pos is the tree position of the current ring slot.
+
+	// niceLen caps how far tree nodes are compared. A match that reaches it
+	// ends the walk and is extended to its full length afterwards, which keeps
+	// runs of repetitive input from costing a full compare per node, but the
+	// extended match is the nearest one of niceLen bytes, not the longest.
+	niceLen int
+}
+
+// init clears the tree for a run that starts at ring slot windB.
+func (t *hcBinaryTree) init(windB, niceLen int) {
+	clear(t.head4[:])
+	clear(t.head3[:])
+	t.niceLen = niceLen
+	t.pos = hcTreeBase + uint32(windB) //nolint:gosec // G115: ring index fits uint32
+}
+
+// find inserts the position at windB into the tree and appends its matches to
+// matches, longest last; only matches longer than minLen are reported.
+// lenLimit is the lookahead at windB and depth caps the tree nodes visited.
+func (t *hcBinaryTree) find(buffer *[hcBufferGuardSize]byte, windB, lenLimit, depth, minLen int, matches []hcOptMatch) []hcOptMatch {
+	niceLen := min(lenLimit, t.niceLen)
+	found := len(matches)
+	matches = t.search(buffer, windB, niceLen, depth, minLen, matches)
+	if last := len(matches) - 1; last >= found && matches[last].length == niceLen && niceLen < lenLimit {
+		matchPos := t.ringPos(windB, matches[last].offset)
+		matches[last].length = countEqualBytes(buffer, windB, matchPos, niceLen, windB+lenLimit)
+	}
+	return matches
+}
+
+// search is find with every compare capped at lenLimit.
+func (t *hcBinaryTree) search(buffer *[hcBufferGuardSize]byte, windB, lenLimit, depth, minLen int, matches []hcOptMatch) []hcOptMatch {
+	if t.pos >= hcTreeNormalizeAt {
+		t.normalize()
+	}
+	cur := t.pos
+	t.pos++
+
+	if lenLimit < 3 {
+		return matches
+	}
+
+	// The nearest 3-byte match, possibly longer.
+	key3 := match3Key(buffer, windB)
+	if delta := int(cur - t.head3[key3]); delta <= hcMaxDist {
+		matchPos := t.ringPos(windB, delta)
+		if buffer[matchPos] == buffer[windB] && buffer[matchPos+1] == buffer[windB+1] && buffer[matchPos+2] == buffer[windB+2] {
+			if matched := countEqualBytes(buffer, windB, matchPos, 3, windB+lenLimit); matched > minLen {
+				minLen = matched
+				matches = append(matches, hcOptMatch{length: matched, offset: delta})
+			}
+		}
+	}
+	t.head3[key3] = cur
+
+	if lenLimit < 4 {
+		return matches
+	}
+
+	key4 := match4Key(buffer, windB)
+	curMatch := t.head4[key4]
+	t.head4[key4] = cur
+
+	// smaller and larger are the son slots still waiting for a child: the
+	// largest node below the new position and the smallest node above it.
+	smaller, larger := 2*windB, 2*windB+1
+	smallerLen, largerLen := 0, 0
+	for {
+		delta := int(cur - curMatch)
+		if depth == 0 || delta > hcMaxDist {
+			t.son[smaller], t.son[larger] = 0, 0
+			return matches
+		}
+		depth--
+
+		matchPos := t.ringPos(windB, delta)
+		pair := 2 * matchPos
+		matched := min(smallerLen, largerLen)
+		if buffer[matchPos+matched] == buffer[windB+matched] {
+			matched = countEqualBytes(buffer, windB, matchPos, matched+1, windB+lenLimit)
+			if matched > minLen {
+				minLen = matched
+				matches = append(matches, hcOptMatch{length: matched, offset: delta})
+			}
+			if matched == lenLimit {
+				// The node equals the new position as far as can be compared:
+				// the new position takes its place and its subtrees.
+				t.son[smaller], t.son[larger] = t.son[pair], t.son[pair+1]
+				return matches
+			}
+		}
+
+		if buffer[matchPos+matched] < buffer[windB+matched] {
+			t.son[smaller] = curMatch
+			smaller = pair + 1
+			curMatch = t.son[smaller]
+			smallerLen = matched
+		} else {
+			t.son[larger] = curMatch
+			larger = pair
+			curMatch = t.son[larger]
+			largerLen = matched
+		}
+	}
+}
+
+// ringPos returns the ring slot delta positions behind windB.
+func (t *hcBinaryTree) ringPos(windB, delta int) int {
+	pos := windB - delta
+	if pos < 0 {
+		pos += hcBufferSize
+	}
+	return pos
+}
+
+// normalize rebases all stored positions by a multiple of the ring size, so
+// ring slots keep their positions and expired entries stay out of the window.
+func (t *hcBinaryTree) normalize() {
+	sub := (t.pos - hcTreeBase) / hcBufferSize * hcBufferSize
+	rebase := func(table []uint32) {
+		for i, v := range table {
+			if v < sub {
+				table[i] = 0
+			} else {
+				table[i] = v - sub
+			}
+		}
+	}
+
+	rebase(t.head4[:])
+	rebase(t.head3[:])
+	rebase(t.son[:])
+	t.pos -= sub
+}
+
+// advanceTree is advance for the binary-tree match finder.
+func (d *hcCompressorDict) advanceTree(state *hcState, prevLen int, bestOffsets *hcBestOffsets, skip bool, searchDepth int) (int, int) {
+	// Skipped positions still go into the tree, which costs one walk each.
+	if skip && prevLen > 1 {
+		for i := 0; i < prevLen-1; i++ {
+			d.matches = d.tree.find(&d.buffer, state.windB, state.windSize, treeDepth(searchDepth), hcMaxMatchLen, d.matches[:0])
+			state.getByte(&d.buffer)
+		}
+	}
+
+	matchLen := 1
+	matchOff := 0
+	stop := state.windSize == 0
+
+	d.matches = d.treeMatches(state, searchDepth, d.matches[:0])
+	if len(d.matches) > 0 {
+		best := d.matches[len(d.matches)-1]
+		matchLen, matchOff = best.length, best.offset
+	}
+
+	// A match of at least length bytes is also the nearest match of length
+	// bytes, which findBetterMatch may prefer.
+	var touched uint64
+	length := 2
+	for _, m := range d.matches {
+		for ; length <= m.length && length < hcBestTableSize; length++ {
+			bestOffsets.offsets[length] = m.offset
+			touched |= 1 << length
+		}
+	}
+	for stale := bestOffsets.touched &^ touched; stale != 0; stale &= stale - 1 {
+		bestOffsets.offsets[bits.TrailingZeros64(stale)] = 0
+	}
+	bestOffsets.touched = touched
+
+	d.match2.add(state.windB, &d.buffer)
+	state.getByte(&d.buffer)
+
+	if stop {
+		state.bufSize = 0
+		matchLen = 0
+	} else {
+		state.bufSize = state.windSize + 1
+	}
+	state.bufPos = state.inPos - state.bufSize
+
+	return matchOff, matchLen
+}
+
+// treeMatches inserts the current position into the binary tree and returns
+// its matches by increasing length, starting with a 2-byte match if any.
+func (d *hcCompressorDict) treeMatches(state *hcState, searchDepth int, matches []hcOptMatch) []hcOptMatch {
+	matchLen := 1
+	if state.windSize >= 3 {
+		matchPos := 0
+		if d.match2.search(state, &matchPos, &matchLen, &d.buffer) {
+			matches = append(matches, hcOptMatch{length: 2, offset: state.posToOffset(matchPos)})
+		}
+	}
+
+	return d.tree.find(&d.buffer, state.windB, state.windSize, treeDepth(searchDepth), matchLen, matches)
+}
+
+// treeDepth converts a chain probe depth into the number of tree nodes to visit.
+func treeDepth(searchDepth int) int {
+	return max(searchDepth/hcTreeDepthScale, 1)
+}
+
+// match4Key computes the 4-byte hash key of the binary-tree roots.
+func match4Key(buffer *[hcBufferGuardSize]byte, pos int) int {
+	v := *(*uint32)(unsafe.Pointer(&buffer[pos]))
+	return int((v * 0x1e35a7bd) >> (32 - hcTreeHashBits))
+}
@@ -0,0 +1,159 @@
+package lzo
+
+import (
+	"bytes"
+	"slices"
+	"testing"
+)
+
+func TestBinaryTreeRoundTrip(t *testing.T) {
+	inputs := testInputSet(t)
+	inputs = append(inputs, struct {
+		name string
+		data []byte
+	}{name: "far-matches", data: ringTestInput()})
+	preset := bytes.Repeat([]byte("preset dictionary data "), 64)
+	dict := &hcCompressorDict{}
+
+	for _, in := range inputs {
+		out := make([]byte, MaxCompressedSize(len(in.data)))
+		for _, level := range []int{1, 5, 9, hcOptimalLevel, hcMaxLevel} {
+			n, err := compress999Finder(in.data, out, dict, level, nil, formatLZO1X, hcFinderBinaryTree)
+			if err != nil {
+				t.Fatalf("%s level=%d: compress failed: %v", in.name, level, err)
+			}
+			got, err := DecompressInto(out[:n], make([]byte, len(in.data)))
+			if err != nil || !bytes.Equal(got, in.data) {
+				t.Fatalf("%s level=%d: DecompressInto failed: %v", in.name, level, err)
+			}
+
+			n, err = compress999Finder(in.data, out, dict, level, preset, formatLZO1X, hcFinderBinaryTree)
+			if err != nil {
+				t.Fatalf("%s level=%d: compress with dict failed: %v", in.name, level, err)
+			}
+			got, err = DecompressWithDict(out[:n], make([]byte, len(in.data)), preset)
+			if err != nil || !bytes.Equal(got, in.data) {
+				t.Fatalf("%s level=%d: DecompressWithDict failed: %v", in.name, level, err)
+			}
+
+			n, err = compress999Finder(in.data, out, dict, level, nil, formatLZO1Y, hcFinderBinaryTree)
+			if err != nil {
+				t.Fatalf("%s level=%d: LZO1Y compress failed: %v", in.name, level, err)
+			}
+			got, err = DecompressLZO1Y(out[:n], make([]byte, len(in.data)))
+			if err != nil || !bytes.Equal(got, in.data) {
+				t.Fatalf("%s level=%d: DecompressLZO1Y failed: %v", in.name, level, err)
+			}
+		}
+	}
+}
+
+func TestBinaryTreeNormalize(t *testing.T) {
+	// Rebasing positions must not change what the tree finds.
+	data := ringTestInput()[:hcBufferSize]
+	var buffer [hcBufferGuardSize]byte
+	copy(buffer[:], data)
+
+	fresh, shifted := &hcBinaryTree{}, &hcBinaryTree{}
+	fresh.init(0, hcTreeNiceLen)
+	shifted.init(0, hcTreeNiceLen)
+
+	var want, got []hcOptMatch
+	half := len(data) / 2
+	for pos := range half {
+		want = fresh.find(&buffer, pos, min(len(data)-pos, hcMaxMatchLen), 64, 1, want[:0])
+		got = shifted.find(&buffer, pos, min(len(data)-pos, hcMaxMatchLen), 64, 1, got[:0])
+	}
+
+	// Move shifted right below the normalization threshold.
+	shift := hcTreeNormalizeAt - shifted.pos - 100
+	for _, table := range [][]uint32{shifted.head4[:], shifted.head3[:], shifted.son[:]} {
+		for i, v := range table {
+			if v != 0 {
+				table[i] = v + shift
+			}
+		}
+	}
+	shifted.pos += shift
+
+	normalized := false
+	for pos := half; pos < len(data)-hcMaxMatchLen; pos++ {
+		want = fresh.find(&buffer, pos, hcMaxMatchLen, 64, 1, want[:0])
+		got = shifted.find(&buffer, pos, hcMaxMatchLen, 64, 1, got[:0])
+		if !slices.Equal(got, want) {
+			t.Fatalf("pos %d: matches %v, want %v", pos, got, want)
+		}
+		normalized = normalized || shifted.pos < shift
+	}
+	if !normalized {
+		t.Fatal("tree positions were not normalized")
+	}
+}
+
+func TestCompressTopLevelsUseBinaryTree(t *testing.T) {
+	data := logTestInput(64 << 10)
+	out := make([]byte, MaxCompressedSize(len(data)))
+	for _, level := range []int{11, hcMaxLevel} {
+		n, err := compress999Finder(data, out, &hcCompressorDict{}, level, nil, formatLZO1X, hcFinderBinaryTree)
+		if err != nil {
+			t.Fatalf("level=%d: compress999Finder failed: %v", level, err)
+		}
+		got, err := Compress(data, &CompressOptions{Level: 1 + 3*(level-hcOptimalLevel), Optimal: true})
+		if err != nil || !bytes.Equal(got, out[:n]) {
+			t.Fatalf("level=%d: Compress does not use the binary tree: %v", level, err)
+		}
+	}
+}
+
+func TestBinaryTreeDepthFollowsLevel(t *testing.T) {
+	data := logTestInput(64 << 10)
+	out := make([]byte, MaxCompressedSize(len(data)))
+	prev := len(out)
+	for _, level := range []int{1, 5, 9} {
+		n, err := compress999Finder(data, out, &hcCompressorDict{}, level, nil, formatLZO1X, hcFinderBinaryTree)
+		if err != nil {
+			t.Fatalf("level=%d: compress failed: %v", level, err)
+		}
+		if n >= prev {
+			t.Fatalf("level=%d: %d bytes, not smaller than %d at the level below", level, n, prev)
+		}
+		prev = n
+	}
+}
+
+func TestBinaryTreeSkipKeepsOlderPositions(t *testing.T) {
+	// Positions covered by a long match are inserted without a search. The
+	// tree must still find the 3 KiB runs repeating 4 KiB back, like the chains.
+	data := benchmarkMixedBytes(64 << 10)
+	out := make([]byte, MaxCompressedSize(len(data)))
+	chain, err := compress999Finder(data, out, &hcCompressorDict{}, hcMaxLevel, nil, formatLZO1X, hcFinderHashChain)
+	if err != nil {
+		t.Fatalf("hash chains failed: %v", err)
+	}
+	tree, err := compress999Finder(data, out, &hcCompressorDict{}, hcMaxLevel, nil, formatLZO1X, hcFinderBinaryTree)
+	if err != nil {
+		t.Fatalf("binary tree failed: %v", err)
+	}
+	if tree > chain+chain/1000 {
+		t.Fatalf("binary tree: %d bytes, hash chains: %d", tree, chain)
+	}
+}
+
+func FuzzBinaryTreeRoundTrip(f *testing.F) {
+	f.Add([]byte(""), uint8(9))
+	f.Add(bytes.Repeat([]byte("abcdefgh"), 4), uint8(5))
+	f.Add(bytes.Repeat([]byte{0}, hcBufferSize+100), uint8(12))
+
+	f.Fuzz(func(t *testing.T, data []byte, level uint8) {
+		out := make([]byte, MaxCompressedSize(len(data)))
+		n, err := compress999Finder(data, out, &hcCompressorDict{}, 1+int(level)%hcMaxLevel, nil, formatLZO1X, hcFinderBinaryTree)
+		if err != nil {
+			t.Fatalf("compress failed: %v", err)
+		}
+
+		got, err := DecompressInto(out[:n], make([]byte, len(data)))
+		if err != nil || !bytes.Equal(got, data) {
+			t.Fatalf("round trip failed: %v", err)
+		}
+	})
+}
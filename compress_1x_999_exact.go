@@ -0,0 +1,593 @@
+// SPDX-License-Identifier: MIT
+// Copyright (c) 2026 WoozyMasta
+// Source: github.com/woozymasta/lzo
+
+package lzo
+
+import (
+	"math/bits"
+	"sync"
+	"unsafe"
+)
+
+// Sliding window dictionary parameters of liblzo2's LZO1X-999 (lzo_swd.ch).
+const (
+	swdN        = maxOffsetM4 // swdN is the window size.
+	swdF        = 2048        // swdF is the lookahead size and longest match.
+	swdBSize    = swdN + swdF // swdBSize is the ring size; swdF more bytes mirror its start.
+	swdBestOff  = maxLenM3 + 1
+	swdHashSize = 16384
+	swdMaxChain = 2048
+	swdNil2     = 0xffff
+)
+
+// swdLevel holds the lzo1x_999_compress_level parameters for one level.
+// liblzo2 also lists nice_length per level but never passes it on, so every
+// level searches up to swdF bytes.
+type swdLevel struct {
+	tryLazy    int  // tryLazy is how many positions ahead to look for a better match.
+	goodLength int  // goodLength is the match length from which lazy searches use a quarter of maxChain.
+	maxLazy    int  // maxLazy is the match length from which no lazy search is done.
+	maxChain   int  // maxChain caps the hash chain walk.
+	useBestOff bool // useBestOff enables betterMatch.
+}
+
+// swdLevels maps compression level (1..9) to its liblzo2 parameters, with
+// zero good_length and max_lazy already replaced by liblzo2's default of 32.
+var swdLevels = [10]swdLevel{
+	{},
+	{tryLazy: 0, goodLength: 32, maxLazy: 32, maxChain: 4},
+	{tryLazy: 0, goodLength: 32, maxLazy: 32, maxChain: 8},
+	{tryLazy: 0, goodLength: 32, maxLazy: 32, maxChain: 16},
+	{tryLazy: 1, goodLength: 4, maxLazy: 4, maxChain: 16},
+	{tryLazy: 1, goodLength: 8, maxLazy: 16, maxChain: 32},
+	{tryLazy: 1, goodLength: 8, maxLazy: 16, maxChain: 128},
+	{tryLazy: 2, goodLength: 8, maxLazy: 32, maxChain: 256},
+	{tryLazy: 2, goodLength: 32, maxLazy: 128, maxChain: 2048, useBestOff: true},
+	{tryLazy: 2, goodLength: swdF, maxLazy: swdF, maxChain: 4096, useBestOff: true},
+}
+
+// swdPool stores reusable liblzo2 window dictionaries.
+var swdPool = sync.Pool{
+	New: func() any {
+		return &swd{}
+	},
+}
+
+// swd is liblzo2's sliding window dictionary: a ring of swdBSize bytes with
+// 3-byte hash chains and a 2-byte head table. Field names follow lzo_swd_t.
+type swd struct {
+	src   []byte // src is the input being compressed.
+	inPos int    // inPos is the next unread src byte.
+
+	maxChain   int
+	niceLength int
+	useBestOff bool
+
+	mLen    int // mLen is the best match length of the last search.
+	mOff    int // mOff is the distance of that match.
+	mPos    int // mPos is the ring position of that match.
+	look    int // look is the number of lookahead bytes at bp.
+	bChar   int // bChar is the byte at bp, or -1 at the end of input.
+	bestOff [swdBestOff]int
+	bestPos [swdBestOff]int
+
+	ip        int // ip is where the next input byte goes into the ring.
+	bp        int // bp is the current position.
+	rp        int // rp is the oldest position, removed next.
+	nodeCount int // nodeCount counts free nodes before removal starts.
+	firstRP   int
+
+	best3 [swdBSize]uint16
+	succ3 [swdBSize]uint16
+	llen3 [swdHashSize]uint16
+	head3 [swdHashSize]uint16
+	head2 [1 << 16]uint16
+	b     [swdBSize + swdF]byte
+}
+
+// compress999ExactLevel compresses in as a stream of format f with the
+// liblzo2 replica of lzo1x_999_compress_level (or lzo1y_999_compress_level).
+func compress999ExactLevel(in []byte, level int, preset []byte, f format) ([]byte, error) {
+	temp := acquireCompressBuffer(MaxCompressedSize(len(in)))
+	defer releaseCompressBuffer(temp)
+
+	outLen, err := compress999Exact(in, temp.data, level, preset, f)
+	if err != nil {
+		return nil, err
+	}
+
+	out := make([]byte, outLen)
+	copy(out, temp.data[:outLen])
+	return out, nil
+}
+
+// compress999Exact is lzo1x_999_compress_internal with the parameters of
+// level (clamped to 1–9): the same window, lazy matching and better_match
+// rules, so out receives the bytes liblzo2 produces. preset is an optional
+// dictionary as in lzo1x_999_compress_dict.
+func compress999Exact(in, out []byte, level int, preset []byte, f format) (int, error) {
+	params := swdLevels[min(max(level, 1), 9)]
+
+	s := swdPool.Get().(*swd)
+	defer func() {
+		s.src = nil
+		swdPool.Put(s)
+	}()
+
+	s.init(in, preset, params.useBestOff)
+	s.maxChain = params.maxChain
+
+	outPos := 0
+	literalStart := 0
+	lit := 0
+
+	matchLen, matchOff, look := s.findMatch(0, 0)
+	for look > 0 {
+		if lit == 0 {
+			literalStart = s.inPos - look
+		}
+
+		switch {
+		case matchLen < 2 ||
+			(matchLen == 2 && (matchOff > maxOffsetM1 || lit == 0 || lit >= 4)) ||
+			(matchLen == 2 && outPos == 0) ||
+			(outPos == 0 && lit == 0):
+			matchLen = 0
+		case matchLen == minLenM2 && matchOff > f.maxOffsetMX() && lit >= 4:
+			// A literal is cheaper than a far 3-byte match after a long run.
+			matchLen = 0
+		}
+
+		if matchLen == 0 {
+			lit++
+			s.maxChain = params.maxChain
+			matchLen, matchOff, look = s.findMatch(1, 0)
+			continue
+		}
+
+		if s.useBestOff {
+			s.betterMatch(&matchLen, &matchOff, f)
+		}
+
+		// Look up to maxAhead positions ahead for a match that pays for
+		// the literals or shortened match it leaves behind.
+		ahead, maxAhead, codedLen := 0, 0, 0
+		if params.tryLazy > 0 && matchLen < params.maxLazy {
+			codedLen = codedMatchLen(matchLen, matchOff, lit, f)
+			maxAhead = min(params.tryLazy, codedLen-1)
+		}
+
+		lazy := false
+		for ahead < maxAhead && look > matchLen {
+			if matchLen >= params.goodLength {
+				s.maxChain = params.maxChain >> 2
+			} else {
+				s.maxChain = params.maxChain
+			}
+
+			var nextLen, nextOff int
+			nextLen, nextOff, look = s.findMatch(1, 0)
+			ahead++
+
+			if nextLen < matchLen || nextLen == matchLen && nextOff >= matchOff {
+				continue
+			}
+			if s.useBestOff {
+				s.betterMatch(&nextLen, &nextOff, f)
+			}
+			nextCodedLen := codedMatchLen(nextLen, nextOff, lit+ahead, f)
+			if nextCodedLen == 0 {
+				continue
+			}
+
+			// liblzo2 never shortens the first match, to stay compatible with LZO 1.01.
+			shortCodedLen := 0
+			if outPos != 0 {
+				shortCodedLen = codedMatchLen(ahead, matchOff, lit, f)
+			}
+
+			if nextLen >= matchLen+lazyMinGain(ahead, lit, lit+ahead, codedLen, nextCodedLen, shortCodedLen) {
+				if shortCodedLen != 0 {
+					if err := encodeLiteralRun(out, &outPos, in, literalStart, lit, f); err != nil {
+						return 0, err
+					}
+					if err := encodeLookbackMatch(out, &outPos, ahead, matchOff, lit, 0, f); err != nil {
+						return 0, err
+					}
+					lit = 0
+				} else {
+					lit += ahead
+				}
+
+				matchLen, matchOff = nextLen, nextOff
+				lazy = true
+				break
+			}
+		}
+		if lazy {
+			continue
+		}
+
+		if err := encodeLiteralRun(out, &outPos, in, literalStart, lit, f); err != nil {
+			return 0, err
+		}
+		if err := encodeLookbackMatch(out, &outPos, matchLen, matchOff, lit, 0, f); err != nil {
+			return 0, err
+		}
+
+		lit = 0
+		s.maxChain = params.maxChain
+		matchLen, matchOff, look = s.findMatch(matchLen, 1+ahead)
+	}
+
+	if err := encodeLiteralRun(out, &outPos, in, literalStart, lit, f); err != nil {
+		return 0, err
+	}
+	if err := writeSlice(out, &outPos, []byte{markerM4 | 1, 0, 0}); err != nil {
+		return 0, err
+	}
+
+	return outPos, nil
+}
+
+// codedMatchLen returns the encoded size of a match of format f after lit
+// literals, or 0 if it cannot be encoded (len_of_coded_match).
+func codedMatchLen(matchLen, matchOff, lit int, f format) int {
+	switch {
+	case matchLen < 2:
+		return 0
+	case matchLen == 2:
+		if matchOff <= maxOffsetM1 && lit > 0 && lit < 4 {
+			return 2
+		}
+		return 0
+	case matchLen <= f.maxLenM2() && matchOff <= f.maxOffsetM2():
+		return 2
+	case matchLen == minLenM2 && matchOff <= f.maxOffsetMX() && lit >= 4:
+		return 2
+	case matchOff <= maxOffsetM3:
+		if matchLen <= maxLenM3 {
+			return 3
+		}
+		return 4 + (matchLen-maxLenM3-1)/255
+	case matchOff <= maxOffsetM4:
+		if matchLen <= maxLenM4 {
+			return 3
+		}
+		return 4 + (matchLen-maxLenM4-1)/255
+	}
+
+	return 0
+}
+
+// lazyMinGain returns how much longer a match found ahead positions later must
+// be to replace the current one (min_gain). lit1 and lit2 are the literal runs
+// before either match, l1 and l2 their encoded sizes and l3 the encoded size of
+// the current match shortened to ahead bytes.
+func lazyMinGain(ahead, lit1, lit2, l1, l2, l3 int) int {
+	gain := ahead
+	if lit1 <= 3 {
+		if lit2 > 3 {
+			gain += 2
+		}
+	} else if lit1 <= 18 {
+		if lit2 > 18 {
+			gain++
+		}
+	}
+
+	gain += (l2 - l1) * 2
+	if l3 != 0 {
+		gain -= (ahead - l3) * 2
+	}
+
+	return max(gain, 0)
+}
+
+// betterMatch shortens a match of format f by one or two bytes when the
+// window saw a match of that length at a distance with a cheaper opcode.
+func (s *swd) betterMatch(matchLen, matchOff *int, f format) {
+	m2Off, m2Len := f.maxOffsetM2(), f.maxLenM2()
+	if *matchLen <= minLenM2 || *matchOff <= m2Off {
+		return
+	}
+
+	// M3/M4 -> M2
+	if *matchLen <= m2Len+1 {
+		if off := s.bestOff[*matchLen-1]; off != 0 && off <= m2Off {
+			*matchLen--
+			*matchOff = off
+			return
+		}
+	}
+
+	if *matchOff <= maxOffsetM3 || *matchLen < maxLenM4+1 {
+		return
+	}
+
+	// M4 -> M2
+	if *matchLen <= m2Len+2 {
+		if off := s.bestOff[*matchLen-2]; off != 0 && off <= m2Off {
+			*matchLen -= 2
+			*matchOff = off
+			return
+		}
+	}
+
+	// M4 -> M3
+	if *matchLen <= maxLenM3+1 {
+		if off := s.bestOff[*matchLen-1]; off != 0 && off <= maxOffsetM3 {
+			*matchLen--
+			*matchOff = off
+		}
+	}
+}
+
+// init loads preset and the first lookahead bytes of src (swd_init).
+func (s *swd) init(src, preset []byte, useBestOff bool) {
+	s.src = src
+	s.inPos = 0
+	s.mLen, s.mOff = 0, 0
+	clear(s.bestOff[:])
+	clear(s.bestPos[:])
+
+	s.maxChain = swdMaxChain
+	s.niceLength = swdF
+	s.useBestOff = useBestOff
+	s.nodeCount = swdN
+
+	clear(s.llen3[:])
+	for i := range s.head2 {
+		s.head2[i] = swdNil2
+	}
+
+	preset = preset[len(preset)-min(len(preset), swdN):]
+	copy(s.b[:], preset)
+	s.ip = len(preset)
+	s.bp = s.ip
+	s.firstRP = s.ip
+
+	s.look = min(len(src), swdF)
+	copy(s.b[s.ip:], src[:s.look])
+	s.inPos += s.look
+	s.ip += s.look
+	if s.ip == swdBSize {
+		s.ip = 0
+	}
+
+	if s.look >= 2 && len(preset) > 0 {
+		s.insertDict(0, len(preset))
+	}
+
+	s.rp = s.firstRP
+	if s.rp >= s.nodeCount {
+		s.rp -= s.nodeCount
+	} else {
+		s.rp += swdBSize - s.nodeCount
+	}
+
+	// The first hash keys read up to three bytes even for tiny inputs.
+	if s.look < 3 {
+		clear(s.b[s.bp+s.look : s.bp+s.look+3])
+	}
+}
+
+// insertDict indexes n preset bytes starting at ring position node.
+func (s *swd) insertDict(node, n int) {
+	s.nodeCount = swdN - n
+	s.firstRP = node
+
+	for ; n > 0; n-- {
+		key := s.hash3(node)
+		s.succ3[node] = s.head3[key]
+		s.head3[key] = uint16(node) //nolint:gosec // G115: ring positions fit uint16
+		s.best3[node] = swdF + 1
+		s.llen3[key]++
+		s.head2[s.hash2(node)] = uint16(node) //nolint:gosec // G115: ring positions fit uint16
+		node++
+	}
+}
+
+// findMatch accepts thisLen-skip positions without searching, searches the
+// next one and advances past it (find_match in lzo1x_9x.c). It returns the
+// match found there and the lookahead counted from that position; a
+// lookahead of 0 means the input is exhausted.
+func (s *swd) findMatch(thisLen, skip int) (matchLen, matchOff, look int) {
+	if skip > 0 {
+		s.accept(thisLen - skip)
+	}
+
+	s.mLen = 1
+	s.mOff = 0
+	if s.useBestOff {
+		clear(s.bestPos[:])
+	}
+	s.findBest()
+	matchLen, matchOff = s.mLen, s.mOff
+
+	s.getByte()
+	if s.bChar < 0 {
+		return 0, 0, 0
+	}
+
+	return matchLen, matchOff, s.look + 1
+}
+
+// getByte moves the next input byte into the ring and advances all positions.
+func (s *swd) getByte() {
+	var c byte
+	if s.inPos < len(s.src) {
+		c = s.src[s.inPos]
+		s.inPos++
+	} else if s.look > 0 {
+		s.look--
+	}
+
+	s.b[s.ip] = c
+	if s.ip < swdF {
+		s.b[swdBSize+s.ip] = c
+	}
+
+	s.ip = (s.ip + 1) % swdBSize
+	s.bp = (s.bp + 1) % swdBSize
+	s.rp = (s.rp + 1) % swdBSize
+}
+
+// removeNode drops the oldest position from the hash tables once the window is full.
+func (s *swd) removeNode(node int) {
+	if s.nodeCount > 0 {
+		s.nodeCount--
+		return
+	}
+
+	s.llen3[s.hash3(node)]--
+	if key := s.hash2(node); int(s.head2[key]) == node {
+		s.head2[key] = swdNil2
+	}
+}
+
+// accept inserts the next n positions without searching them.
+func (s *swd) accept(n int) {
+	for ; n > 0; n-- {
+		s.removeNode(s.rp)
+
+		key := s.hash3(s.bp)
+		s.succ3[s.bp] = s.head3[key]
+		s.head3[key] = uint16(s.bp) //nolint:gosec // G115: ring positions fit uint16
+		s.best3[s.bp] = swdF + 1
+		s.llen3[key]++
+		s.head2[s.hash2(s.bp)] = uint16(s.bp) //nolint:gosec // G115: ring positions fit uint16
+
+		s.getByte()
+	}
+}
+
+// findBest inserts bp into the hash tables and searches it for the longest match.
+func (s *swd) findBest() {
+	key := s.hash3(s.bp)
+	node := int(s.head3[key])
+	s.succ3[s.bp] = s.head3[key]
+	count := int(s.llen3[key])
+	s.llen3[key]++
+	if count > s.maxChain && s.maxChain > 0 {
+		count = s.maxChain
+	}
+	s.head3[key] = uint16(s.bp) //nolint:gosec // G115: ring positions fit uint16
+
+	s.bChar = int(s.b[s.bp])
+	prevLen := s.mLen
+	if s.mLen >= s.look {
+		if s.look == 0 {
+			s.bChar = -1
+		}
+		s.mOff = 0
+		s.best3[s.bp] = swdF + 1
+	} else {
+		if s.search2() && s.look >= 3 {
+			s.search(node, count)
+		}
+		if s.mLen > prevLen {
+			s.mOff = s.posToOffset(s.mPos)
+		}
+		s.best3[s.bp] = uint16(s.mLen) //nolint:gosec // G115: bounded by swdF
+
+		if s.useBestOff {
+			for i := 2; i < swdBestOff; i++ {
+				s.bestOff[i] = 0
+				if s.bestPos[i] > 0 {
+					s.bestOff[i] = s.posToOffset(s.bestPos[i] - 1)
+				}
+			}
+		}
+	}
+
+	s.removeNode(s.rp)
+	s.head2[s.hash2(s.bp)] = uint16(s.bp) //nolint:gosec // G115: ring positions fit uint16
+}
+
+// search2 takes the newest position with the same two bytes as a 2-byte match.
+func (s *swd) search2() bool {
+	node := s.head2[s.hash2(s.bp)]
+	if node == swdNil2 {
+		return false
+	}
+
+	if s.bestPos[2] == 0 {
+		s.bestPos[2] = int(node) + 1
+	}
+	if s.mLen < 2 {
+		s.mLen = 2
+		s.mPos = int(node)
+	}
+
+	return true
+}
+
+// search walks up to count nodes of the hash chain starting at node.
+func (s *swd) search(node, count int) {
+	b := &s.b
+	bp := s.bp
+	limit := bp + s.look
+	matchLen := s.mLen
+	scanEnd := b[bp+matchLen-1]
+
+	for ; count > 0; count, node = count-1, int(s.succ3[node]) {
+		if b[node+matchLen-1] != scanEnd || b[node+matchLen] != b[bp+matchLen] ||
+			b[node] != b[bp] || b[node+1] != b[bp+1] {
+			continue
+		}
+
+		// Like liblzo2, rely on the hash for the third byte.
+		n := 3 + swdMatchLen(b, bp+3, node+3, limit)
+		if n < swdBestOff && s.bestPos[n] == 0 {
+			s.bestPos[n] = node + 1
+		}
+		if n > matchLen {
+			s.mLen, matchLen = n, n
+			s.mPos = node
+			if n == s.look || n >= s.niceLength || n > int(s.best3[node]) {
+				return
+			}
+			scanEnd = b[bp+matchLen-1]
+		}
+	}
+}
+
+// swdMatchLen returns the number of equal bytes at left and right, stopping at leftLimit.
+func swdMatchLen(b *[swdBSize + swdF]byte, left, right, leftLimit int) int {
+	start := left
+	for left+8 <= leftLimit {
+		diff := *(*uint64)(unsafe.Pointer(&b[left])) ^ *(*uint64)(unsafe.Pointer(&b[right]))
+		if diff != 0 {
+			return left - start + bits.TrailingZeros64(diff)>>3
+		}
+		left += 8
+		right += 8
+	}
+	for left < leftLimit && b[left] == b[right] {
+		left++
+		right++
+	}
+
+	return left - start
+}
+
+// posToOffset converts a ring position to its distance behind bp.
+func (s *swd) posToOffset(pos int) int {
+	if s.bp > pos {
+		return s.bp - pos
+	}
+	return swdBSize - (pos - s.bp)
+}
+
+// hash3 is liblzo2's HEAD3 key of the three bytes at pos.
+func (s *swd) hash3(pos int) int {
+	v := (int(s.b[pos])<<5^int(s.b[pos+1]))<<5 ^ int(s.b[pos+2])
+	return (0x9f5f * v >> 5) & (swdHashSize - 1)
+}
+
+// hash2 is liblzo2's HEAD2 key of the two bytes at pos.
+func (s *swd) hash2(pos int) int {
+	return int(s.b[pos]) | int(s.b[pos+1])<<8
+}
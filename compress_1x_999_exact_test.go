@@ -0,0 +1,90 @@
+package lzo
+
+import (
+	"bytes"
+	"testing"
+)
+
+func TestCompress999ExactRoundTrip(t *testing.T) {
+	inputs := testInputSet(t)
+	inputs = append(inputs, struct {
+		name string
+		data []byte
+	}{name: "far-matches", data: ringTestInput()})
+	dict := bytes.Repeat([]byte("preset dictionary data "), 64)
+
+	for _, in := range inputs {
+		for level := 2; level <= 9; level++ {
+			opts := &CompressOptions{Level: level, Exact: true}
+			cmp, err := Compress(in.data, opts)
+			if err != nil {
+				t.Fatalf("%s level=%d: Compress failed: %v", in.name, level, err)
+			}
+			out, err := DecompressInto(cmp, make([]byte, len(in.data)))
+			if err != nil || !bytes.Equal(out, in.data) {
+				t.Fatalf("%s level=%d: DecompressInto failed: %v", in.name, level, err)
+			}
+
+			appended, err := AppendCompress([]byte("prefix"), in.data, opts)
+			if err != nil || !bytes.Equal(appended[len("prefix"):], cmp) {
+				t.Fatalf("%s level=%d: AppendCompress differs from Compress: %v", in.name, level, err)
+			}
+
+			opts.Dict = dict
+			cmp, err = Compress(in.data, opts)
+			if err != nil {
+				t.Fatalf("%s level=%d: Compress with dict failed: %v", in.name, level, err)
+			}
+			out, err = DecompressWithDict(cmp, make([]byte, len(in.data)), dict)
+			if err != nil || !bytes.Equal(out, in.data) {
+				t.Fatalf("%s level=%d: DecompressWithDict failed: %v", in.name, level, err)
+			}
+
+			cmp, err = CompressLZO1Y(in.data, &CompressOptions{Level: level, Exact: true})
+			if err != nil {
+				t.Fatalf("%s level=%d: CompressLZO1Y failed: %v", in.name, level, err)
+			}
+			out, err = DecompressLZO1Y(cmp, make([]byte, len(in.data)))
+			if err != nil || !bytes.Equal(out, in.data) {
+				t.Fatalf("%s level=%d: DecompressLZO1Y failed: %v", in.name, level, err)
+			}
+		}
+	}
+}
+
+func TestCompress999ExactShortInputs(t *testing.T) {
+	// liblzo2 never starts the stream with a match, so the first byte is
+	// always a literal; "aaaa" then continues with an M2 of 3 at distance 1.
+	for _, tc := range []struct {
+		in   string
+		want []byte
+	}{
+		{in: "", want: []byte{markerM4 | 1, 0, 0}},
+		{in: "a", want: []byte{17 + 1, 'a', markerM4 | 1, 0, 0}},
+		{in: "aa", want: []byte{17 + 2, 'a', 'a', markerM4 | 1, 0, 0}},
+		{in: "aaaa", want: []byte{17 + 1, 'a', (3 - 1) << 5, 0, markerM4 | 1, 0, 0}},
+	} {
+		got, err := Compress([]byte(tc.in), &CompressOptions{Level: 9, Exact: true})
+		if err != nil || !bytes.Equal(got, tc.want) {
+			t.Fatalf("%q: got % x, %v, want % x", tc.in, got, err, tc.want)
+		}
+	}
+}
+
+func FuzzCompress999ExactRoundTrip(f *testing.F) {
+	f.Add([]byte(""), uint8(9))
+	f.Add(bytes.Repeat([]byte("abcdefgh"), 4), uint8(2))
+	f.Add(bytes.Repeat([]byte{0}, maxOffsetM4+100), uint8(8))
+
+	f.Fuzz(func(t *testing.T, data []byte, level uint8) {
+		cmp, err := Compress(data, &CompressOptions{Level: 2 + int(level%8), Exact: true})
+		if err != nil {
+			t.Fatalf("Compress failed: %v", err)
+		}
+
+		out, err := DecompressInto(cmp, make([]byte, len(data)))
+		if err != nil || !bytes.Equal(out, data) {
+			t.Fatalf("round trip failed: %v", err)
+		}
+	})
+}
@@ -0,0 +1,402 @@
+// SPDX-License-Identifier: MIT
+// Copyright (c) 2026 WoozyMasta
+// Source: github.com/woozymasta/lzo
+
+package lzo
+
+import (
+	"math"
+	"sync"
+)
+
+const (
+	// hcOptimalLevel is the first level that uses the optimal parser.
+	hcOptimalLevel = 10
+
+	// hcMaxLevel is the highest LZO1X-999 level.
+	hcMaxLevel = 12
+
+	// hcOptBlockSize is how many input positions one shortest-path pass covers.
+	// Matches never cross a block end; pending literals carry over.
+	hcOptBlockSize = 1 << 15
+
+	// hcOptStates is the number of literal states tracked per position:
+	// 0–3 literals since the last match, 4 or more, and the literal-only
+	// prefix before the first match of the stream.
+	hcOptStates = 6
+
+	// hcOptRunState is the state for runs of 4 or more literals.
+	hcOptRunState = 4
+
+	// hcOptPrefixState is the state for the literal run that opens the stream.
+	hcOptPrefixState = 5
+
+	// hcOptInfinity marks an unreachable state.
+	hcOptInfinity = math.MaxInt32
+)
+
+// hcOptimalParams maps levels 10..12 to chain probe depth and nice length.
+// A match of at least niceLen bytes is taken without parsing the positions it
+// covers, which bounds the work on highly repetitive input.
+var hcOptimalParams = [hcMaxLevel - hcOptimalLevel + 1]struct {
+	searchDepth int
+	niceLen     int
+}{
+	{searchDepth: 128, niceLen: 64},    // level 10
+	{searchDepth: 512, niceLen: 256},   // level 11
+	{searchDepth: 2048, niceLen: 1024}, // level 12
+}
+
+// hcOptParserPool stores reusable shortest-path buffers.
+var hcOptParserPool = sync.Pool{
+	New: func() any {
+		return &hcOptParser{}
+	},
+}
+
+// hcOptMatch is one match candidate: the nearest distance reaching length.
+type hcOptMatch struct {
+	length int
+	offset int
+}
+
+// hcOptStep is the last step of the cheapest path into one node state.
+type hcOptStep struct {
+	length uint16 // length is the match length, or 0 for a literal.
+	offset uint16 // offset is the match distance.
+	state  uint8  // state is the literal state the step starts from.
+}
+
+// hcOptNode holds the cheapest known path into each literal state of one position.
+type hcOptNode struct {
+	cost [hcOptStates]int32
+	from [hcOptStates]hcOptStep
+	run  int32 // run is the literal run length of the hcOptRunState path.
+}
+
+// hcOptParser holds the per-block shortest-path graph and match candidates.
+type hcOptParser struct {
+	nodes   [hcOptBlockSize + 1]hcOptNode
+	matches []hcOptMatch
+	steps   []hcOptStep
+}
+
+// compress999Optimal compresses in into out as a stream of format f with a
+// price-based shortest-path parse over the match finder candidates, for
+// levels 10..12. Prices are exact encoded sizes: the parser tracks how many
+// literals precede each position, so it can price the 1–3 literals packed into
+// the previous opcode, the literal-state dependent M1 forms and the opening
+// literal run.
+func compress999Optimal(in, out []byte, dict *hcCompressorDict, level int, preset []byte, f format, finder hcMatchFinder) (int, error) {
+	if len(out) < 3 {
+		return 0, ErrCompressInternal
+	}
+
+	params := hcOptimalParams[min(max(level, hcOptimalLevel), hcMaxLevel)-hcOptimalLevel]
+
+	p := hcOptParserPool.Get().(*hcOptParser)
+	defer hcOptParserPool.Put(p)
+
+	state := hcState{src: in}
+	dict.init(&state, preset, finder, max(params.niceLen, hcTreeNiceLen), params.searchDepth)
+
+	outPos := 0
+	literalStart := 0
+	literalLen := 0
+	lastOff := 0
+	first := true
+
+	for blockStart := 0; blockStart < len(in); {
+		blockEnd := min(blockStart+hcOptBlockSize, len(in))
+		p.parseBlock(dict, &state, blockStart, blockEnd, literalLen, first, params.searchDepth, params.niceLen, f)
+
+		// Replay the cheapest path through the block.
+		pos := blockStart
+		for i := len(p.steps) - 1; i >= 0; i-- {
+			step := p.steps[i]
+			if step.length == 0 {
+				if literalLen == 0 {
+					literalStart = pos
+				}
+				literalLen++
+				pos++
+				continue
+			}
+
+			matchLen, matchOff := int(step.length), int(step.offset)
+			if err := encodeLiteralRun(out, &outPos, in, literalStart, literalLen, f); err != nil {
+				return 0, err
+			}
+			if err := encodeLookbackMatch(out, &outPos, matchLen, matchOff, literalLen, lastOff, f); err != nil {
+				return 0, err
+			}
+			lastOff = matchOff
+			literalLen = 0
+			first = false
+			pos += matchLen
+		}
+
+		blockStart = blockEnd
+	}
+
+	if err := encodeLiteralRun(out, &outPos, in, literalStart, literalLen, f); err != nil {
+		return 0, err
+	}
+	if err := writeSlice(out, &outPos, []byte{markerM4 | 1, 0, 0}); err != nil {
+		return 0, err
+	}
+
+	return outPos, nil
+}
+
+// parseBlock finds the cheapest encoding of in[blockStart:blockEnd] and
+// stores its steps in p.steps, last step first. carried literals precede
+// blockStart; first means no match has been emitted yet.
+func (p *hcOptParser) parseBlock(dict *hcCompressorDict, state *hcState, blockStart, blockEnd, carried int, first bool, searchDepth, niceLen int, f format) {
+	size := blockEnd - blockStart
+	nodes := p.nodes[:size+1]
+	for i := range nodes {
+		nodes[i].cost = [hcOptStates]int32{hcOptInfinity, hcOptInfinity, hcOptInfinity, hcOptInfinity, hcOptInfinity, hcOptInfinity}
+	}
+
+	switch {
+	case first:
+		nodes[0].cost[hcOptPrefixState] = 0
+	case carried >= hcOptRunState:
+		nodes[0].cost[hcOptRunState] = 0
+		nodes[0].run = int32(carried) //nolint:gosec // G115: bounded by input size
+	default:
+		nodes[0].cost[carried] = 0
+	}
+
+	skipUntil := 0
+	for i := 0; i < size; i++ {
+		node := &nodes[i]
+		pos := blockStart + i
+
+		// Literal edges.
+		for s := range hcOptStates {
+			if node.cost[s] == hcOptInfinity {
+				continue
+			}
+
+			run := s
+			switch s {
+			case hcOptRunState:
+				run = int(node.run)
+			case hcOptPrefixState:
+				run = carried + i
+			}
+			next, nextState := &nodes[i+1], s
+			if s < hcOptRunState {
+				nextState = s + 1
+			}
+
+			cost := node.cost[s] + int32(1+literalHeaderLen(run+1, s == hcOptPrefixState)-literalHeaderLen(run, s == hcOptPrefixState)) //nolint:gosec // G115: small
+			if cost < next.cost[nextState] {
+				next.cost[nextState] = cost
+				next.from[nextState] = hcOptStep{state: uint8(s)} //nolint:gosec // G115: s < hcOptStates
+				if nextState == hcOptRunState {
+					next.run = int32(run + 1) //nolint:gosec // G115: bounded by input size
+				}
+			}
+		}
+
+		// Positions inside a taken nice-length match are only indexed.
+		if pos < skipUntil {
+			continue
+		}
+
+		p.matches = dict.collectMatches(state, searchDepth, p.matches[:0])
+		if len(p.matches) == 0 {
+			continue
+		}
+
+		if !p.relaxMatches(nodes, i, blockEnd-pos, carried, f) {
+			continue
+		}
+		if longest := p.matches[len(p.matches)-1].length; longest >= niceLen {
+			skipUntil = pos + min(longest, blockEnd-pos)
+			dict.skipPositions(state, skipUntil-pos-1, searchDepth)
+		}
+	}
+
+	// Walk back from the cheapest final state.
+	best := 0
+	for s := 1; s < hcOptStates; s++ {
+		if nodes[size].cost[s] < nodes[size].cost[best] {
+			best = s
+		}
+	}
+
+	p.steps = p.steps[:0]
+	for i, s := size, best; i > 0; {
+		step := nodes[i].from[s]
+		p.steps = append(p.steps, step)
+		if step.length == 0 {
+			i--
+		} else {
+			i -= int(step.length)
+		}
+		s = int(step.state)
+	}
+}
+
+// relaxMatches adds the match edges of node i for every length up to limit.
+// It reports false if no path may place a match at i.
+func (p *hcOptParser) relaxMatches(nodes []hcOptNode, i, limit, carried int, f format) bool {
+	node := &nodes[i]
+
+	// From 4 bytes on, a match costs the same after any literal state, so
+	// only the cheapest state matters.
+	bestState := -1
+	for s := range hcOptStates {
+		if node.cost[s] == hcOptInfinity || s == hcOptPrefixState && carried+i == 0 {
+			continue
+		}
+		if bestState < 0 || node.cost[s] < node.cost[bestState] {
+			bestState = s
+		}
+	}
+	if bestState < 0 {
+		return false
+	}
+
+	length := 2
+	for _, m := range p.matches {
+		for ; length <= min(m.length, limit); length++ {
+			if length >= 4 {
+				cost := codedMatchLen(length, m.offset, 0, f)
+				relaxMatch(&nodes[i+length], node.cost[bestState]+int32(cost), length, m.offset, bestState) //nolint:gosec // G115: small
+				continue
+			}
+
+			for s := range hcOptStates {
+				if node.cost[s] == hcOptInfinity {
+					continue
+				}
+
+				lit := s
+				switch s {
+				case hcOptRunState:
+					lit = int(node.run)
+				case hcOptPrefixState:
+					// The stream opens with literals, and no M1 follows them
+					// (compatibility with LZO 1.01 decoders).
+					lit = carried + i
+					if lit == 0 || length == 2 {
+						continue
+					}
+				}
+
+				cost := codedMatchLen(length, m.offset, lit, f)
+				if cost == 0 {
+					continue
+				}
+				relaxMatch(&nodes[i+length], node.cost[s]+int32(cost), length, m.offset, s) //nolint:gosec // G115: small
+			}
+		}
+	}
+
+	return true
+}
+
+// relaxMatch records a match edge into state 0 of next if it is cheaper.
+func relaxMatch(next *hcOptNode, cost int32, length, offset, fromState int) {
+	if cost >= next.cost[0] {
+		return
+	}
+
+	next.cost[0] = cost
+	next.from[0] = hcOptStep{
+		length: uint16(length),   //nolint:gosec // G115: bounded by hcMaxMatchLen
+		offset: uint16(offset),   //nolint:gosec // G115: bounded by hcMaxDist
+		state:  uint8(fromState), //nolint:gosec // G115: fromState < hcOptStates
+	}
+}
+
+// literalHeaderLen returns the opcode bytes of a run of n literals, excluding
+// the literals themselves. Runs of 1–3 ride in the previous opcode, except
+// for the run that opens the stream.
+func literalHeaderLen(n int, opening bool) int {
+	switch {
+	case n == 0:
+		return 0
+	case opening && n <= 238:
+		return 1
+	case n <= 3:
+		return 0
+	case n <= 18:
+		return 1
+	}
+
+	return 2 + (n-19)/255
+}
+
+// collectMatches inserts the current position into the hash tables and
+// returns, by increasing length, the nearest match for each length the chain
+// reaches; the first entry may be a 2-byte match.
+func (d *hcCompressorDict) collectMatches(state *hcState, searchDepth int, matches []hcOptMatch) []hcOptMatch {
+	if d.finder == hcFinderBinaryTree {
+		matches = d.treeMatches(state, searchDepth, matches)
+		d.match2.add(state.windB, &d.buffer)
+		state.getByte(&d.buffer)
+		return matches
+	}
+
+	head, count := d.match3.advance(state, &d.buffer, searchDepth)
+	if head == hcNilNode {
+		count = 0
+	}
+
+	matchLen := 1
+	if state.windSize >= 3 {
+		matchPos := 0
+		if d.match2.search(state, &matchPos, &matchLen, &d.buffer) {
+			matches = append(matches, hcOptMatch{length: 2, offset: state.posToOffset(matchPos)})
+		}
+
+		scanPos := state.windB
+		scanLimit := scanPos + state.windSize
+		node := int(head)
+		for ; count > 0 && matchLen < state.windSize; count-- {
+			if d.buffer[node+matchLen-1] == d.buffer[scanPos+matchLen-1] &&
+				d.buffer[node+matchLen] == d.buffer[scanPos+matchLen] &&
+				d.buffer[node] == d.buffer[scanPos] &&
+				d.buffer[node+1] == d.buffer[scanPos+1] {
+				if matched := countEqualBytes(&d.buffer, scanPos, node, 2, scanLimit); matched > matchLen && matched >= 3 {
+					matchLen = matched
+					matches = append(matches, hcOptMatch{length: matched, offset: state.posToOffset(node)})
+				}
+			}
+
+			next := d.match3.chain[node]
+			if next == hcNilNode {
+				break
+			}
+			node = int(next)
+		}
+	}
+
+	d.match3.bestLen[state.windB] = uint16(min(matchLen, hcMaxMatchLen+1)) //nolint:gosec // G115: bounded by window size
+	d.resetNextInputEntry(state)
+	d.match2.add(state.windB, &d.buffer)
+	state.getByte(&d.buffer)
+
+	return matches
+}
+
+// skipPositions indexes the next n positions without searching them. The
+// binary tree still walks searchDepth nodes to insert each one, since a node
+// inserted without a walk cuts off the older positions below it.
+func (d *hcCompressorDict) skipPositions(state *hcState, n, searchDepth int) {
+	for ; n > 0; n-- {
+		if d.finder == hcFinderBinaryTree {
+			d.matches = d.tree.find(&d.buffer, state.windB, state.windSize, treeDepth(searchDepth), hcMaxMatchLen, d.matches[:0])
+		} else {
+			d.resetNextInputEntry(state)
+			d.match3.skipAdvance(state, &d.buffer)
+		}
+		state.getByte(&d.buffer)
+	}
+}
@@ -0,0 +1,149 @@
+package lzo
+
+import (
+	"bytes"
+	"testing"
+)
+
+func TestCompressOptimalRoundTrip(t *testing.T) {
+	inputs := testInputSet(t)
+	inputs = append(inputs, struct {
+		name string
+		data []byte
+	}{name: "far-matches", data: ringTestInput()})
+	dict := bytes.Repeat([]byte("preset dictionary data "), 64)
+
+	for _, in := range inputs {
+		for _, level := range []int{1, 4, 9} {
+			opts := &CompressOptions{Level: level, Optimal: true}
+			cmp, err := Compress(in.data, opts)
+			if err != nil {
+				t.Fatalf("%s level=%d: Compress failed: %v", in.name, level, err)
+			}
+			out, err := DecompressInto(cmp, make([]byte, len(in.data)))
+			if err != nil || !bytes.Equal(out, in.data) {
+				t.Fatalf("%s level=%d: DecompressInto failed: %v", in.name, level, err)
+			}
+
+			greedy, err := Compress(in.data, &CompressOptions{Level: 9})
+			if err != nil {
+				t.Fatalf("%s: level 9 Compress failed: %v", in.name, err)
+			}
+			if len(cmp) > len(greedy)+len(greedy)/200 {
+				t.Errorf("%s level=%d: %d bytes, level 9 has %d", in.name, level, len(cmp), len(greedy))
+			}
+
+			opts.Dict = dict
+			cmp, err = Compress(in.data, opts)
+			if err != nil {
+				t.Fatalf("%s level=%d: Compress with dict failed: %v", in.name, level, err)
+			}
+			out, err = DecompressWithDict(cmp, make([]byte, len(in.data)), dict)
+			if err != nil || !bytes.Equal(out, in.data) {
+				t.Fatalf("%s level=%d: DecompressWithDict failed: %v", in.name, level, err)
+			}
+
+			cmp, err = CompressLZO1Y(in.data, &CompressOptions{Level: level, Optimal: true})
+			if err != nil {
+				t.Fatalf("%s level=%d: CompressLZO1Y failed: %v", in.name, level, err)
+			}
+			out, err = DecompressLZO1Y(cmp, make([]byte, len(in.data)))
+			if err != nil || !bytes.Equal(out, in.data) {
+				t.Fatalf("%s level=%d: DecompressLZO1Y failed: %v", in.name, level, err)
+			}
+		}
+	}
+}
+
+func TestCompressOptimalOption(t *testing.T) {
+	data := logTestInput(64 << 10)
+	out := make([]byte, MaxCompressedSize(len(data)))
+
+	// Levels 1–3, 4–6 and 7–9 select the three optimal-parse levels; level 0
+	// still selects LZO1X-999 rather than LZO1X-1.
+	for level := 0; level <= 9; level++ {
+		internal := hcOptimalLevel + (max(level, 1)-1)/3
+		n, err := compress999NoAlloc(data, out, &hcCompressorDict{}, internal, nil, formatLZO1X)
+		if err != nil {
+			t.Fatalf("level=%d: compress999NoAlloc failed: %v", level, err)
+		}
+		got, err := Compress(data, &CompressOptions{Level: level, Optimal: true})
+		if err != nil || !bytes.Equal(got, out[:n]) {
+			t.Fatalf("level=%d: Compress does not use optimal level %d: %v", level, internal, err)
+		}
+	}
+
+	// Exact and the LZO1X-1 methods ignore Optimal.
+	for _, opts := range []CompressOptions{
+		{Level: 9, Exact: true},
+		{Level: 1, Method: MethodLZO1X1},
+	} {
+		want, err := Compress(data, &opts)
+		if err != nil {
+			t.Fatalf("%+v: Compress failed: %v", opts, err)
+		}
+		opts.Optimal = true
+		if got, err := Compress(data, &opts); err != nil || !bytes.Equal(got, want) {
+			t.Fatalf("%+v: Optimal changed the output: %v", opts, err)
+		}
+	}
+}
+
+func TestOptimalPrices(t *testing.T) {
+	// The parser's prices must equal the bytes the encoders write.
+	in := make([]byte, 600)
+	out := make([]byte, 1024)
+	for n := 0; n <= len(in); n++ {
+		for _, opening := range []bool{false, true} {
+			outPos := 0
+			if !opening {
+				outPos = 2 // a previous opcode to pack 1–3 literals into
+			}
+			start := outPos
+			if err := encodeLiteralRun(out, &outPos, in, 0, n, formatLZO1X); err != nil {
+				t.Fatalf("n=%d: encodeLiteralRun failed: %v", n, err)
+			}
+			if got, want := outPos-start-n, literalHeaderLen(n, opening); got != want {
+				t.Fatalf("n=%d opening=%v: header is %d bytes, priced %d", n, opening, got, want)
+			}
+		}
+	}
+
+	for _, f := range []format{formatLZO1X, formatLZO1Y} {
+		for _, lit := range []int{0, 1, 3, 4, 20} {
+			for _, off := range []int{1, 0x400, 0x401, 0x800, 0xc00, 0xc01, 0x4000, 0x4001, 0xbfff} {
+				for length := 2; length <= 600; length++ {
+					price := codedMatchLen(length, off, lit, f)
+					if price == 0 {
+						continue
+					}
+					outPos := 0
+					if err := encodeLookbackMatch(out, &outPos, length, off, lit, 0, f); err != nil {
+						t.Fatalf("len=%d off=%#x lit=%d: encodeLookbackMatch failed: %v", length, off, lit, err)
+					}
+					if outPos != price {
+						t.Fatalf("format %d len=%d off=%#x lit=%d: wrote %d bytes, priced %d", f, length, off, lit, outPos, price)
+					}
+				}
+			}
+		}
+	}
+}
+
+func FuzzCompressOptimalRoundTrip(f *testing.F) {
+	f.Add([]byte(""), uint8(0))
+	f.Add(bytes.Repeat([]byte("abcdefgh"), 4), uint8(1))
+	f.Add(bytes.Repeat([]byte{0}, hcOptBlockSize+100), uint8(2))
+
+	f.Fuzz(func(t *testing.T, data []byte, level uint8) {
+		cmp, err := Compress(data, &CompressOptions{Level: int(level % 10), Optimal: true})
+		if err != nil {
+			t.Fatalf("Compress failed: %v", err)
+		}
+
+		out, err := DecompressInto(cmp, make([]byte, len(data)))
+		if err != nil || !bytes.Equal(out, data) {
+			t.Fatalf("round trip failed: %v", err)
+		}
+	})
+}
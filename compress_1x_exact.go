@@ -0,0 +1,163 @@
+// SPDX-License-Identifier: MIT
+// Copyright (c) 2026 WoozyMasta
+// Source: github.com/woozymasta/lzo
+
+package lzo
+
+import (
+	"encoding/binary"
+	"math/bits"
+)
+
+// compress1xExact is the LZO1X-1 compressor of liblzo2 2.10 (lzo1x_1_compress
+// and its D_BITS variants, lzo1y_1_compress for LZO1Y) replicated step for
+// step, so its output is byte-identical to a little-endian 64-bit build. Like
+// liblzo2 it compresses in blocks of f.maxOffsetM4()+1 bytes, the largest that
+// keep every match in range, with a fresh hash table each, carrying pending
+// literals across block boundaries.
+//
+// For formatLZORLE it is the Linux kernel's lzorle1x_1_compress (lib/lzo),
+// which shares the liblzo2 loop: it writes the version header, stops blocks one
+// byte short and codes runs of zero bytes as zero-run instructions.
+func compress1xExact(out, in []byte, dictBits int, f format) []byte {
+	if f == formatLZORLE {
+		out = append(out, rleHeader, rleVersion)
+	}
+
+	dict := make([]uint16, 1<<dictBits)
+	start := len(out)
+	pending := 0
+	stateOffset := -2
+	pos := 0
+
+	for len(in)-pos > 20 {
+		end := pos + min(len(in)-pos, f.maxOffsetM4()+1)
+		clear(dict)
+		out, pending, stateOffset = compress1xExactChunk(out, in, pos, end, pending, stateOffset, dict, dictBits, f)
+		pos = end
+	}
+
+	pending += len(in) - pos
+	if pending > 0 {
+		out = appendFastLiteral(out, in[len(in)-pending:], start, len(out)+stateOffset)
+	}
+
+	return append(out, markerM4|1, 0, 0)
+}
+
+// compress1xExactChunk compresses in[base:end] the way liblzo2's do_compress
+// does. carried literals end at base and are emitted with the first match.
+// stateOffset locates, relative to the end of out, the byte of the last
+// instruction that takes the count of a short literal run: -2, or -3 after a
+// zero run. It returns the number of literals left pending at end and the
+// stateOffset of the last instruction.
+func compress1xExactChunk(out, in []byte, base, end, carried, stateOffset int, dict []uint16, dictBits int, f format) ([]byte, int, int) {
+	m2Off, m2Len := f.maxOffsetM2(), f.maxLenM2()
+	inputLimit := end - 20
+	ip := base
+	if carried < 4 {
+		ip += 4 - carried
+	}
+	// skipStart drives the literal skip heuristic and ignores carried literals;
+	// literalStart is where the pending literal run begins.
+	skipStart := base
+	literalStart := base - carried
+
+	// do_compress enters its loop through the literal step, so the first
+	// position is never hashed.
+	for ip += 1 + (ip-skipStart)>>5; ip < inputLimit; {
+		dv := binary.LittleEndian.Uint32(in[ip:])
+		runLen, matchPos := 0, 0
+		if dv == 0 && f == formatLZORLE {
+			// The kernel codes 4 or more zero bytes as a zero run and leaves
+			// the hash table alone.
+			runEnd := ip + minLenZeroRun
+			for limit := min(inputLimit, ip+maxLenZeroRun+1); runEnd < limit && in[runEnd] == 0; runEnd++ {
+			}
+			runLen = min(runEnd-ip, maxLenZeroRun)
+		} else {
+			dictIndex := int((dv * 0x1824429d) >> (32 - dictBits))
+			matchPos = base + int(dict[dictIndex])
+			dict[dictIndex] = uint16(ip - base) //nolint:gosec // G115: chunk positions fit uint16
+			if dv != binary.LittleEndian.Uint32(in[matchPos:]) {
+				ip += 1 + (ip-skipStart)>>5
+				continue
+			}
+		}
+
+		if literalCount := ip - literalStart; literalCount > 0 {
+			switch {
+			case literalCount <= 3:
+				out[len(out)+stateOffset] |= opcodeByte(literalCount)
+			case literalCount <= 18:
+				out = append(out, opcodeByte(literalCount-3))
+			default:
+				out = append(out, 0)
+				out = appendFastMultiple(out, literalCount-18)
+			}
+			out = append(out, in[literalStart:ip]...)
+		}
+
+		if runLen > 0 {
+			out = appendZeroRun(out, runLen)
+			stateOffset = -3
+			ip += runLen
+			skipStart, literalStart = ip, ip
+			continue
+		}
+
+		// liblzo2 compares 8 bytes at a time and, once the match reaches
+		// inputLimit, stops without counting the last word's matching bytes.
+		matchLen, capped := 4, false
+		diff := binary.LittleEndian.Uint64(in[ip+matchLen:]) ^ binary.LittleEndian.Uint64(in[matchPos+matchLen:])
+		for diff == 0 {
+			matchLen += 8
+			diff = binary.LittleEndian.Uint64(in[ip+matchLen:]) ^ binary.LittleEndian.Uint64(in[matchPos+matchLen:])
+			if ip+matchLen >= inputLimit {
+				capped = true
+				break
+			}
+		}
+		if !capped {
+			matchLen += bits.TrailingZeros64(diff) >> 3
+		}
+
+		matchOffset := ip - matchPos
+		ip += matchLen
+		stateOffset = -2
+
+		switch {
+		case matchLen <= m2Len && matchOffset <= m2Off:
+			out = appendFastM2(out, matchLen, matchOffset, f)
+
+		case matchOffset <= maxOffsetM3:
+			matchOffset--
+			if matchLen <= maxLenM3 {
+				out = append(out, opcodeByte(markerM3|(matchLen-2)))
+			} else {
+				out = append(out, markerM3)
+				out = appendFastMultiple(out, matchLen-maxLenM3)
+			}
+			out = append(out, opcodeByte(matchOffset<<2), opcodeByte(matchOffset>>6))
+
+		default:
+			matchOffset -= 0x4000
+			if f == formatLZORLE && matchOffset&0x403f == 0x403f && matchLen >= 261 && matchLen <= 264 {
+				// The length byte and distance bytes of these matches can read as
+				// a zero-run instruction, so the kernel shortens them.
+				ip -= matchLen - 260
+				matchLen = 260
+			}
+			if matchLen <= maxLenM4 {
+				out = append(out, opcodeByte(markerM4|((matchOffset>>11)&8)|(matchLen-2)))
+			} else {
+				out = append(out, opcodeByte(markerM4|((matchOffset>>11)&8)))
+				out = appendFastMultiple(out, matchLen-maxLenM4)
+			}
+			out = append(out, opcodeByte(matchOffset<<2), opcodeByte(matchOffset>>6))
+		}
+		skipStart, literalStart = ip, ip
+	}
+
+	return out, end - literalStart, stateOffset
+}
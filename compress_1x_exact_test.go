@@ -0,0 +1,77 @@
+package lzo
+
+import (
+	"bytes"
+	"testing"
+)
+
+func TestCompressExactVector(t *testing.T) {
+	// liblzo2 starts hashing at the fifth byte, emits the first literal run
+	// with a plain run opcode and stops extending the match at the input limit.
+	in := bytes.Repeat([]byte("abcdefgh"), 4)
+	want := append([]byte{8 - 3}, "abcdefgh"...)
+	want = append(want, markerM3|(12-2), 7<<2, 0) // M3 of 12 at distance 8
+	want = append(want, 12-3)
+	want = append(want, "efghabcdefgh"...)
+	want = append(want, markerM4|1, 0, 0)
+
+	got, err := Compress(in, &CompressOptions{Exact: true})
+	if err != nil || !bytes.Equal(got, want) {
+		t.Fatalf("got % x, %v\nwant % x", got, err, want)
+	}
+}
+
+func TestCompressExactRoundTrip(t *testing.T) {
+	inputs := testInputSet(t)
+	inputs = append(inputs, struct {
+		name string
+		data []byte
+	}{name: "far-matches", data: ringTestInput()})
+
+	methods := []Method{MethodLZO1X1, MethodLZO1X1_11, MethodLZO1X1_12, MethodLZO1X1_15}
+	for _, in := range inputs {
+		for _, method := range methods {
+			opts := &CompressOptions{Method: method, Exact: true}
+			cmp, err := Compress(in.data, opts)
+			if err != nil {
+				t.Fatalf("%s method=%d: Compress failed: %v", in.name, method, err)
+			}
+			out, err := DecompressInto(cmp, make([]byte, len(in.data)))
+			if err != nil || !bytes.Equal(out, in.data) {
+				t.Fatalf("%s method=%d: DecompressInto failed: %v", in.name, method, err)
+			}
+
+			appended, err := AppendCompress([]byte("prefix"), in.data, opts)
+			if err != nil || !bytes.Equal(appended[len("prefix"):], cmp) {
+				t.Fatalf("%s method=%d: AppendCompress differs from Compress: %v", in.name, method, err)
+			}
+		}
+
+		cmp, err := CompressLZO1Y(in.data, &CompressOptions{Level: 1, Exact: true})
+		if err != nil {
+			t.Fatalf("%s: CompressLZO1Y failed: %v", in.name, err)
+		}
+		out, err := DecompressLZO1Y(cmp, make([]byte, len(in.data)))
+		if err != nil || !bytes.Equal(out, in.data) {
+			t.Fatalf("%s: DecompressLZO1Y failed: %v", in.name, err)
+		}
+	}
+}
+
+func FuzzCompressExactRoundTrip(f *testing.F) {
+	f.Add([]byte(""))
+	f.Add(bytes.Repeat([]byte("abcdefgh"), 4))
+	f.Add(bytes.Repeat([]byte{0}, maxOffsetM4+100))
+
+	f.Fuzz(func(t *testing.T, data []byte) {
+		cmp, err := Compress(data, &CompressOptions{Exact: true})
+		if err != nil {
+			t.Fatalf("Compress failed: %v", err)
+		}
+
+		out, err := DecompressInto(cmp, make([]byte, len(data)))
+		if err != nil || !bytes.Equal(out, data) {
+			t.Fatalf("round trip failed: %v", err)
+		}
+	})
+}
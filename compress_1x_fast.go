@@ -9,17 +9,24 @@ import (
 	"unsafe"
 )
 
-// Dictionary hash parameters used by the fast compressor.
+// Dictionary hash sizes (in bits) of the LZO1X-1 compressor family.
+// liblzo2 builds every variant from the same source with a different D_BITS.
 const (
-	dictBits = 14
-	dictMask = (1 << dictBits) - 1
-	dictHigh = (dictMask >> 1) + 1
+	dictBits1X1   = 14 // dictBits1X1 is LZO1X-1 (lzo1x_1_compress).
+	dictBits1X111 = 11 // dictBits1X111 is LZO1X-1(11) (lzo1x_1_11_compress).
+	dictBits1X112 = 12 // dictBits1X112 is LZO1X-1(12) (lzo1x_1_12_compress).
+	dictBits1X115 = 15 // dictBits1X115 is LZO1X-1(15) (lzo1x_1_15_compress).
+	dictBitsLinux = 13 // dictBitsLinux is the Linux kernel's LZO1X-1 (lib/lzo, D_BITS).
 )
 
-// compress1xFastCore performs the fast LZO1X-1 parse and returns pending literal tail.
-func compress1xFastCore(out, in []byte) ([]byte, int) {
+// compress1xFastCore performs the fast LZO1X-1 parse with a hash table of
+// 1<<dictBits entries, emits instructions of format f and returns pending literal tail.
+func compress1xFastCore(out, in []byte, dictBits int, f format) ([]byte, int) {
+	m2Off, m2Len := f.maxOffsetM2(), f.maxLenM2()
 	inputLen := len(in)
 	inputLimit := inputLen - maxLenM2 - 5
+	dictMask := (1 << dictBits) - 1
+	dictHigh := (dictMask >> 1) + 1
 	dict := make([]int32, 1<<dictBits)
 	literalStart := 0
 	inputPos := 4
@@ -36,8 +43,8 @@ func compress1xFastCore(out, in []byte) ([]byte, int) {
 
 		// Probe two related hash slots to improve hit rate without extra structures.
 		for attempt := range 2 {
-			matchPos, matchOffset := findFastCandidate(dict, in, inputPos, dictIndex)
-			tryMatch := matchPos >= 0 && (matchOffset <= maxOffsetM2 || in[matchPos+3] == in[inputPos+3])
+			matchPos, matchOffset := findFastCandidate(dict, in, inputPos, dictIndex, m2Off)
+			tryMatch := matchPos >= 0 && (matchOffset <= m2Off || in[matchPos+3] == in[inputPos+3])
 
 			if tryMatch &&
 				in[matchPos] == in[inputPos] &&
@@ -46,7 +53,7 @@ func compress1xFastCore(out, in []byte) ([]byte, int) {
 				dict[dictIndex] = int32(inputPos + 1) //nolint:gosec // G115: input position fits int32 for LZO input sizes
 
 				if inputPos != literalStart {
-					out = appendFastLiteral(out, in[literalStart:inputPos])
+					out = appendFastLiteral(out, in[literalStart:inputPos], 0, len(out)-2)
 					literalStart = inputPos
 				}
 
@@ -67,12 +74,8 @@ func compress1xFastCore(out, in []byte) ([]byte, int) {
 					matchLen := inputPos - literalStart
 
 					switch { // Pick the shortest opcode class that can represent this match.
-					case matchOffset <= maxOffsetM2:
-						matchOffset--
-						out = append(out,
-							opcodeByte(((matchLen-1)<<5)|((matchOffset&7)<<2)),
-							opcodeByte(matchOffset>>3),
-						)
+					case matchOffset <= m2Off:
+						out = appendFastM2(out, matchLen, matchOffset, f)
 
 					case matchOffset <= maxOffsetM3:
 						matchOffset--
@@ -96,7 +99,12 @@ func compress1xFastCore(out, in []byte) ([]byte, int) {
 					inputPos += fastMatchLen(in, m, inputPos)
 
 					matchLen := inputPos - literalStart
-					if matchOffset <= maxOffsetM3 {
+					switch {
+					case matchLen <= m2Len && matchOffset <= m2Off:
+						// Only LZO1Y has M2 lengths beyond the short extension window.
+						out = appendFastM2(out, matchLen, matchOffset, f)
+
+					case matchOffset <= maxOffsetM3:
 						matchOffset--
 						if matchLen <= 33 {
 							out = append(out, opcodeByte(markerM3|(matchLen-2)))
@@ -105,7 +113,9 @@ func compress1xFastCore(out, in []byte) ([]byte, int) {
 							out = append(out, opcodeByte(markerM3))
 							out = appendFastMultiple(out, matchLen)
 						}
-					} else {
+						out = append(out, opcodeByte((matchOffset&63)<<2), opcodeByte(matchOffset>>6))
+
+					default:
 						matchOffset -= 0x4000
 						if matchLen <= maxLenM4 {
 							out = append(out, opcodeByte(markerM4|((matchOffset&0x4000)>>11)|(matchLen-2)))
@@ -114,8 +124,8 @@ func compress1xFastCore(out, in []byte) ([]byte, int) {
 							out = append(out, opcodeByte(markerM4|((matchOffset&0x4000)>>11)))
 							out = appendFastMultiple(out, matchLen)
 						}
+						out = append(out, opcodeByte((matchOffset&63)<<2), opcodeByte(matchOffset>>6))
 					}
-					out = append(out, opcodeByte((matchOffset&63)<<2), opcodeByte(matchOffset>>6))
 				}
 
 				// Next literal run, if any, starts after the emitted match.
@@ -125,7 +135,9 @@ func compress1xFastCore(out, in []byte) ([]byte, int) {
 			}
 
 			if attempt == 0 {
-				dictIndex = (dictIndex & (dictMask & 0x7ff)) ^ (dictHigh | 0x1f)
+				// The second slot keeps the low dictBits-3 bits of the first
+				// (0x7ff for the 14-bit table), so every table size is fully used.
+				dictIndex = (dictIndex & (dictMask >> 3)) ^ (dictHigh | 0x1f)
 			}
 		}
 
@@ -175,20 +187,21 @@ func fastMatchLen(in []byte, left, right int) int {
 	return right - start
 }
 
-// compress1xFast is the fast LZO1X-1 compressor (level 0 or 1).
-func compress1xFast(out, in []byte) []byte {
+// compress1xFast is the fast LZO1X-1 compressor (level 0 or 1) with a hash
+// table of 1<<dictBits entries, emitting a stream of format f.
+func compress1xFast(out, in []byte, dictBits int, f format) []byte {
 	var literalTailSize int
 	inLen := len(in)
 
 	if inLen <= maxLenM2+5 {
 		literalTailSize = inLen
 	} else {
-		out, literalTailSize = compress1xFastCore(out, in)
+		out, literalTailSize = compress1xFastCore(out, in, dictBits, f)
 	}
 
 	if literalTailSize > 0 {
 		ii := inLen - literalTailSize
-		out = appendFastLiteral(out, in[ii:ii+literalTailSize])
+		out = appendFastLiteral(out, in[ii:ii+literalTailSize], 0, len(out)-2)
 	}
 
 	out = append(out, markerM4|1, 0, 0)
@@ -196,7 +209,8 @@ func compress1xFast(out, in []byte) []byte {
 }
 
 // findFastCandidate returns (matchPos, matchOffset) for the given dict slot, or (-1, 0) if none.
-func findFastCandidate(dict []int32, in []byte, inputPos, dictIndex int) (matchPos int, matchOffset int) {
+// Candidates farther than m2Off must also match the fourth byte.
+func findFastCandidate(dict []int32, in []byte, inputPos, dictIndex, m2Off int) (matchPos int, matchOffset int) {
 	matchPos = int(dict[dictIndex]) - 1
 	if matchPos < 0 {
 		return -1, 0
@@ -207,26 +221,43 @@ func findFastCandidate(dict []int32, in []byte, inputPos, dictIndex int) (matchP
 	}
 
 	matchOffset = inputPos - matchPos
-	if matchOffset <= maxOffsetM2 || in[matchPos+3] == in[inputPos+3] {
+	if matchOffset <= m2Off || in[matchPos+3] == in[inputPos+3] {
 		return matchPos, matchOffset
 	}
 
 	return -1, 0
 }
 
-// appendFastLiteral appends a literal run and its header encoding.
-// lit must be non-empty.
-func appendFastLiteral(out []byte, lit []byte) []byte {
+// appendFastM2 appends an M2 match of format f; callers check its length and distance bounds.
+func appendFastM2(out []byte, matchLen, matchOffset int, f format) []byte {
+	matchOffset--
+	if f == formatLZO1Y {
+		return append(out,
+			opcodeByte(((matchLen+1)<<4)|((matchOffset&3)<<2)),
+			opcodeByte(matchOffset>>2),
+		)
+	}
+
+	return append(out,
+		opcodeByte(((matchLen-1)<<5)|((matchOffset&7)<<2)),
+		opcodeByte(matchOffset>>3),
+	)
+}
+
+// appendFastLiteral appends a literal run and its header encoding. start is
+// the index of the first instruction in out, and a run of 1–3 literals after
+// an instruction is counted in out[countPos]. lit must be non-empty.
+func appendFastLiteral(out []byte, lit []byte, start, countPos int) []byte {
 	if len(lit) == 0 {
 		return out
 	}
 	literalCount := len(lit)
 
 	switch {
-	case len(out) == 0 && literalCount <= 238:
+	case len(out) == start && literalCount <= 238:
 		out = append(out, opcodeByte(17+literalCount))
 	case literalCount <= 3:
-		out[len(out)-2] |= opcodeByte(literalCount)
+		out[countPos] |= opcodeByte(literalCount)
 	case literalCount <= 18:
 		out = append(out, opcodeByte(literalCount-3))
 	default:
@@ -238,6 +269,13 @@ func appendFastLiteral(out []byte, lit []byte) []byte {
 	return out
 }
 
+// appendZeroRun appends an LZO-RLE instruction for n zero bytes
+// (minLenZeroRun to maxLenZeroRun).
+func appendZeroRun(out []byte, n int) []byte {
+	n -= minLenZeroRun
+	return append(out, opcodeByte(markerM4|8|n&7), 0xfc, 0xff, opcodeByte(n>>3))
+}
+
 // appendFastMultiple appends a multiple of 255 to the output.
 func appendFastMultiple(out []byte, t int) []byte {
 	for t > 255 {
@@ -0,0 +1,220 @@
+// SPDX-License-Identifier: MIT
+// Copyright (c) 2026 WoozyMasta
+// Source: github.com/woozymasta/lzo
+
+package lzo
+
+import (
+	"runtime"
+	"sync"
+)
+
+// parallelMinSegment is the smallest input segment CompressParallel gives a
+// worker; shorter inputs gain too little from splitting.
+const parallelMinSegment = 256 << 10
+
+// CompressParallel compresses src with LZO1X-999 on up to workers goroutines
+// and returns a single standard LZO1X stream, decodable by Decompress (or
+// DecompressWithDict when opts.Dict is set).
+//
+// src is cut into one segment per worker. Each segment is compressed with
+// its own dictionary primed with the 0xbfff bytes before it (opts.Dict for
+// the first), so matches still reach across segment boundaries, and the
+// segment streams are joined by re-encoding the literal run and first match
+// at each boundary. The ratio stays within a fraction of a percent of Compress.
+//
+// workers <= 0 uses runtime.GOMAXPROCS(0). opts may be nil and is interpreted
+// as by Compress; levels 0 and 1, the LZO1X-1 methods, Exact and inputs below
+// 512 KiB are compressed sequentially by Compress.
+func CompressParallel(src []byte, opts *CompressOptions, workers int) ([]byte, error) {
+	return compressParallel(src, opts, workers, parallelMinSegment)
+}
+
+// compressParallel is CompressParallel with a configurable minimum segment size.
+func compressParallel(src []byte, opts *CompressOptions, workers, minSegment int) ([]byte, error) {
+	if opts == nil {
+		opts = DefaultCompressOptions()
+	}
+	dictBits, level, err := compressPlan(opts)
+	if err != nil {
+		return nil, err
+	}
+	if workers <= 0 {
+		workers = runtime.GOMAXPROCS(0)
+	}
+
+	segments := min(workers, len(src)/minSegment)
+	if dictBits > 0 || opts.Exact || segments < 2 {
+		return Compress(src, opts)
+	}
+
+	segSize := (len(src) + segments - 1) / segments
+	outs := make([]*hcCompressBuffer, segments)
+	lens := make([]int, segments)
+	errs := make([]error, segments)
+
+	var wg sync.WaitGroup
+	for i := range segments {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+
+			start := i * segSize
+			end := min(start+segSize, len(src))
+			preset := opts.Dict
+			if i > 0 {
+				preset = src[max(start-hcMaxDist, 0):start]
+			}
+
+			dict := acquireCompressorDict()
+			defer releaseCompressorDict(dict)
+
+			outs[i] = acquireCompressBuffer(MaxCompressedSize(end - start))
+			lens[i], errs[i] = compress999NoAlloc(src[start:end], outs[i].data, dict, level, preset, formatLZO1X)
+		}()
+	}
+	wg.Wait()
+
+	defer func() {
+		for _, buf := range outs {
+			releaseCompressBuffer(buf)
+		}
+	}()
+
+	s := parallelStitcher{src: src, out: make([]byte, 0, MaxCompressedSize(len(src)))}
+	for i := range segments {
+		if errs[i] != nil {
+			return nil, errs[i]
+		}
+		if err := s.appendSegment(outs[i].data[:lens[i]], i*segSize); err != nil {
+			return nil, err
+		}
+	}
+
+	return s.finish()
+}
+
+// parallelStitcher joins the LZO1X streams of consecutive input segments into
+// one stream. Every segment stream opens with a literal run that, in the
+// joined stream, continues the literal run that ended the previous segment;
+// the stitcher merges the two, re-encodes the first match for the merged run
+// length and copies the remaining instructions up to the last match verbatim.
+type parallelStitcher struct {
+	src      []byte // src is the whole input.
+	out      []byte // out is the joined stream; its length is the write position.
+	litStart int    // litStart is the src position of the literal run not yet written.
+}
+
+// appendSegment appends the instructions of seg, the stream of the segment
+// starting at src position segStart, leaving its trailing literals pending.
+func (s *parallelStitcher) appendSegment(seg []byte, segStart int) error {
+	inPos := 0
+	state := 0
+	pos := segStart // pos is the src position the next instruction decodes to.
+
+	firstEnd := -1 // firstEnd is the seg offset after the re-encoded first match.
+	firstLits := 0 // firstLits is the literal count packed into the first match.
+	lastEnd := -1  // lastEnd is the seg offset after the last match.
+	lastPos := 0   // lastPos is the src position after the last match.
+
+	for {
+		ins, err := parseInstruction(seg, &inPos, state, 0, formatLZO1X)
+		if err != nil {
+			return err
+		}
+		if ins.kind == TokenEnd {
+			break
+		}
+
+		if ins.isMatch() {
+			// Until a match is written, every byte joins the pending literal
+			// run; matches that cannot follow the merged run become literals.
+			if firstEnd < 0 && s.canEncode(ins.matchLen, ins.matchDist, pos-s.litStart) {
+				if err := s.writeMatch(ins.matchLen, ins.matchDist, pos); err != nil {
+					return err
+				}
+				firstEnd, firstLits = inPos, ins.litLen
+			}
+
+			pos += ins.matchLen
+			if firstEnd >= 0 {
+				lastEnd, lastPos = inPos, pos
+			}
+		}
+
+		inPos += ins.litLen
+		pos += ins.litLen
+		state = min(ins.litLen, 4)
+	}
+
+	if firstEnd < 0 {
+		return nil
+	}
+	if lastEnd > firstEnd {
+		// The first match keeps its packed literals and the last one loses
+		// them to the next pending run.
+		s.out[len(s.out)-2] |= opcodeByte(firstLits)
+		s.out = append(s.out, seg[firstEnd:lastEnd]...)
+		s.out[len(s.out)-2] &^= 3
+	}
+	s.litStart = lastPos
+
+	return nil
+}
+
+// canEncode reports whether a match may follow a literal run of litLen bytes
+// at the current end of the joined stream.
+func (s *parallelStitcher) canEncode(matchLen, matchOff, litLen int) bool {
+	if !encodableMatch(matchLen, matchOff, litLen) {
+		return false
+	}
+
+	// Same rules as compress999Finder for the opening literal run.
+	return len(s.out) > 0 || (litLen > 0 && matchLen > 2)
+}
+
+// writeMatch writes the pending literal run up to src position pos and a match.
+func (s *parallelStitcher) writeMatch(matchLen, matchOff, pos int) error {
+	litLen := pos - s.litStart
+	s.grow(litLen + litLen/255 + 32)
+
+	outPos := len(s.out)
+	out := s.out[:cap(s.out)]
+	if err := encodeLiteralRun(out, &outPos, s.src, s.litStart, litLen, formatLZO1X); err != nil {
+		return err
+	}
+	if err := encodeLookbackMatch(out, &outPos, matchLen, matchOff, litLen, 0, formatLZO1X); err != nil {
+		return err
+	}
+	s.out = out[:outPos]
+
+	return nil
+}
+
+// finish writes the pending literal run and the stream terminator.
+func (s *parallelStitcher) finish() ([]byte, error) {
+	litLen := len(s.src) - s.litStart
+	s.grow(litLen + litLen/255 + 32)
+
+	outPos := len(s.out)
+	out := s.out[:cap(s.out)]
+	if err := encodeLiteralRun(out, &outPos, s.src, s.litStart, litLen, formatLZO1X); err != nil {
+		return nil, err
+	}
+	for _, b := range []byte{markerM4 | 1, 0, 0} {
+		if err := writeByte(out, &outPos, b); err != nil {
+			return nil, err
+		}
+	}
+
+	return out[:outPos], nil
+}
+
+// grow makes room for n more bytes in s.out.
+func (s *parallelStitcher) grow(n int) {
+	if cap(s.out)-len(s.out) < n {
+		out := make([]byte, len(s.out), 2*cap(s.out)+n)
+		copy(out, s.out)
+		s.out = out
+	}
+}
@@ -0,0 +1,106 @@
+package lzo
+
+import (
+	"bytes"
+	"errors"
+	"testing"
+)
+
+func TestCompressParallelRoundTrip(t *testing.T) {
+	inputs := testInputSet(t)
+	inputs = append(inputs, struct {
+		name string
+		data []byte
+	}{name: "far-matches", data: ringTestInput()}, struct {
+		name string
+		data []byte
+	}{name: "random", data: benchmarkRandomBytes(64 << 10)})
+	dict := bytes.Repeat([]byte("preset dictionary data "), 64)
+
+	for _, in := range inputs {
+		for _, plan := range []CompressOptions{{Level: 2}, {Level: 9}, {Level: 1, Optimal: true}} {
+			for _, workers := range []int{2, 3, 16} {
+				opts := plan
+				cmp, err := compressParallel(in.data, &opts, workers, 1<<10)
+				if err != nil {
+					t.Fatalf("%s level=%d optimal=%t workers=%d: compressParallel failed: %v", in.name, plan.Level, plan.Optimal, workers, err)
+				}
+				out, err := DecompressInto(cmp, make([]byte, len(in.data)))
+				if err != nil || !bytes.Equal(out, in.data) {
+					t.Fatalf("%s level=%d optimal=%t workers=%d: DecompressInto failed: %v", in.name, plan.Level, plan.Optimal, workers, err)
+				}
+
+				opts.Dict = dict
+				cmp, err = compressParallel(in.data, &opts, workers, 1<<10)
+				if err != nil {
+					t.Fatalf("%s level=%d optimal=%t workers=%d: compressParallel with dict failed: %v", in.name, plan.Level, plan.Optimal, workers, err)
+				}
+				out, err = DecompressWithDict(cmp, make([]byte, len(in.data)), dict)
+				if err != nil || !bytes.Equal(out, in.data) {
+					t.Fatalf("%s level=%d optimal=%t workers=%d: DecompressWithDict failed: %v", in.name, plan.Level, plan.Optimal, workers, err)
+				}
+			}
+		}
+	}
+}
+
+func TestCompressParallelRatio(t *testing.T) {
+	in := benchmarkMixedBytes(4 << 20)
+	opts := &CompressOptions{Level: 9}
+
+	seq, err := Compress(in, opts)
+	if err != nil {
+		t.Fatalf("Compress failed: %v", err)
+	}
+	par, err := CompressParallel(in, opts, 8)
+	if err != nil {
+		t.Fatalf("CompressParallel failed: %v", err)
+	}
+	if len(par) > len(seq)+len(seq)/200 {
+		t.Errorf("parallel stream has %d bytes, sequential %d", len(par), len(seq))
+	}
+
+	out, err := Decompress(par, DefaultDecompressOptions(len(in)))
+	if err != nil || !bytes.Equal(out, in) {
+		t.Fatalf("Decompress failed: %v", err)
+	}
+}
+
+func TestCompressParallelFallback(t *testing.T) {
+	in := bytes.Repeat([]byte("fallback "), 1000)
+	for _, opts := range []*CompressOptions{nil, {Level: 9}, {Level: 9, Exact: true}, {Method: MethodLZO1X1_15}} {
+		par, err := CompressParallel(in, opts, 4)
+		if err != nil {
+			t.Fatalf("CompressParallel(%+v) failed: %v", opts, err)
+		}
+		seq, err := Compress(in, opts)
+		if err != nil {
+			t.Fatalf("Compress(%+v) failed: %v", opts, err)
+		}
+		if !bytes.Equal(par, seq) {
+			t.Errorf("opts %+v: small input was not compressed sequentially", opts)
+		}
+	}
+
+	if _, err := CompressParallel(in, &CompressOptions{Method: 200}, 4); !errors.Is(err, ErrUnknownMethod) {
+		t.Fatalf("unknown method: err = %v, want ErrUnknownMethod", err)
+	}
+}
+
+func FuzzCompressParallelRoundTrip(f *testing.F) {
+	f.Add([]byte(""), uint8(2))
+	f.Add(bytes.Repeat([]byte("abcdefgh"), 400), uint8(3))
+	f.Add(benchmarkMixedBytes(8<<10), uint8(8))
+
+	f.Fuzz(func(t *testing.T, data []byte, workers uint8) {
+		cmp, err := compressParallel(data, &CompressOptions{Level: 9}, int(workers%16), 256)
+		if err != nil {
+			t.Fatalf("compressParallel failed: %v", err)
+		}
+
+		out, err := DecompressInto(cmp, make([]byte, len(data)))
+		if err != nil || !bytes.Equal(out, data) {
+			t.Fatalf("round trip failed: %v", err)
+		}
+	})
+}
@@ -117,6 +117,75 @@ func TestCompress_DefaultAndExplicitLevels(t *testing.T) {
 	}
 }
 
+func TestCompressMethods(t *testing.T) {
+	data := ringTestInput()[:256<<10]
+	level1, err := Compress(data, &CompressOptions{Level: 1})
+	if err != nil {
+		t.Fatalf("Compress level=1 failed: %v", err)
+	}
+	level9, err := Compress(data, &CompressOptions{Level: 9})
+	if err != nil {
+		t.Fatalf("Compress level=9 failed: %v", err)
+	}
+
+	tests := []struct {
+		name   string
+		method Method
+		level  int
+		want   []byte
+	}{
+		{name: "1x-1", method: MethodLZO1X1, level: 9, want: level1},
+		{name: "1x-1-11", method: MethodLZO1X1_11},
+		{name: "1x-1-12", method: MethodLZO1X1_12},
+		{name: "1x-1-15", method: MethodLZO1X1_15},
+		{name: "1x-999", method: MethodLZO1X999, level: 9, want: level9},
+	}
+	var enc Encoder
+	sizes := make(map[Method]int)
+	for _, tt := range tests {
+		opts := &CompressOptions{Method: tt.method, Level: tt.level}
+		cmp, err := Compress(data, opts)
+		if err != nil {
+			t.Fatalf("%s: Compress failed: %v", tt.name, err)
+		}
+		if tt.want != nil && !bytes.Equal(cmp, tt.want) {
+			t.Fatalf("%s: output differs from the matching level", tt.name)
+		}
+		if tt.want == nil && bytes.Equal(cmp, level1) {
+			t.Fatalf("%s: output identical to LZO1X-1; hash size not applied", tt.name)
+		}
+		// Larger hash tables keep more candidates; on this input that shows up in the ratio.
+		if tt.method >= MethodLZO1X1 && tt.method <= MethodLZO1X1_15 {
+			sizes[tt.method] = len(cmp)
+		}
+
+		appended, err := AppendCompress(nil, data, opts)
+		if err != nil || !bytes.Equal(appended, cmp) {
+			t.Fatalf("%s: AppendCompress differs: %v", tt.name, err)
+		}
+		encoded, err := enc.AppendCompress(nil, data, opts)
+		if err != nil || !bytes.Equal(encoded, cmp) {
+			t.Fatalf("%s: Encoder.AppendCompress differs: %v", tt.name, err)
+		}
+
+		out, err := DecompressInto(cmp, make([]byte, len(data)))
+		if err != nil || !bytes.Equal(out, data) {
+			t.Fatalf("%s: round trip failed: %v", tt.name, err)
+		}
+	}
+
+	if sizes[MethodLZO1X1_11] <= sizes[MethodLZO1X1_12] || sizes[MethodLZO1X1_12] <= sizes[MethodLZO1X1] || sizes[MethodLZO1X1] <= sizes[MethodLZO1X1_15] {
+		t.Fatalf("compressed size not ordered by hash size: %v", sizes)
+	}
+
+	if _, err := Compress(data, &CompressOptions{Method: MethodLZO1X999 + 1}); !errors.Is(err, ErrUnknownMethod) {
+		t.Fatalf("expected ErrUnknownMethod, got %v", err)
+	}
+	if _, err := CompressInto(data, make([]byte, MaxCompressedSize(len(data))), &CompressOptions{Method: 0xff}); !errors.Is(err, ErrUnknownMethod) {
+		t.Fatalf("CompressInto: expected ErrUnknownMethod, got %v", err)
+	}
+}
+
 func TestFastMatchLenMatchesBytewise(t *testing.T) {
 	bytewise := func(in []byte, left, right int) int {
 		start := right
@@ -0,0 +1,77 @@
+// SPDX-License-Identifier: MIT
+// Copyright (c) 2026 WoozyMasta
+// Source: github.com/woozymasta/lzo
+
+package lzo
+
+import "math"
+
+// DecodedLen returns the decompressed size of the LZO1X stream in src and the
+// number of input bytes up to and including the terminator, without writing any output.
+// It walks the same opcode grammar as DecompressNInto and validates every
+// back-reference against the running output size, so a stream accepted here
+// decodes without error into a destination of exactly outLen bytes.
+// Returns ErrEmptyInput if src is empty; on error outLen and inConsumed are 0.
+func DecodedLen(src []byte) (outLen, inConsumed int, err error) {
+	if len(src) == 0 {
+		return 0, 0, ErrEmptyInput
+	}
+
+	var s streamScan
+	for {
+		ins, err := s.next(src)
+		if err != nil {
+			return 0, 0, err
+		}
+		if ins.kind == TokenEnd {
+			return s.outPos, s.inPos, nil
+		}
+	}
+}
+
+// streamScan walks an LZO1X stream instruction by instruction without writing output.
+type streamScan struct {
+	inPos  int // inPos is the number of input bytes consumed.
+	outPos int // outPos is the number of output bytes the consumed input expands to.
+	state  int // state is the literal state left by the previous instruction (0–4).
+}
+
+// next parses the instruction at s.inPos, validates it against the running
+// output size and advances past it, including its literal bytes.
+// It fails exactly where decompressCore would with an unbounded destination.
+func (s *streamScan) next(src []byte) (instruction, error) {
+	ins, err := parseInstruction(src, &s.inPos, s.state, 0, formatLZO1X)
+	if err != nil {
+		return instruction{}, err
+	}
+
+	if ins.isMatch() {
+		if ins.matchDist > s.outPos {
+			return instruction{}, ErrLookBehindUnderrun
+		}
+		if ins.matchLen > math.MaxInt-s.outPos {
+			return instruction{}, ErrOutputOverrun
+		}
+
+		s.outPos += ins.matchLen
+	} else if ins.kind == TokenEnd {
+		return ins, nil
+	}
+
+	if ins.litLen > len(src)-s.inPos {
+		return instruction{}, ErrInputOverrun
+	}
+	if ins.litLen > math.MaxInt-s.outPos {
+		return instruction{}, ErrOutputOverrun
+	}
+	s.inPos += ins.litLen
+	s.outPos += ins.litLen
+
+	// Same rule as decompressCore: a plain literal-run stream without terminator is malformed.
+	if ins.kind == TokenLiteral && s.inPos >= len(src) {
+		return instruction{}, ErrInputOverrun
+	}
+
+	s.state = min(ins.litLen, 4)
+	return ins, nil
+}
@@ -0,0 +1,92 @@
+package lzo
+
+import (
+	"bytes"
+	"errors"
+	"testing"
+)
+
+func TestDecodedLenMatchesDecompressedSize(t *testing.T) {
+	for _, in := range testInputSet(t) {
+		if len(in.data) == 0 {
+			continue
+		}
+		for _, level := range []int{1, 5, 9} {
+			cmp, err := Compress(in.data, &CompressOptions{Level: level})
+			if err != nil {
+				t.Fatalf("%s level=%d: Compress failed: %v", in.name, level, err)
+			}
+
+			outLen, nRead, err := DecodedLen(append(cmp, 0xEE, 0xEE))
+			if err != nil {
+				t.Fatalf("%s level=%d: DecodedLen failed: %v", in.name, level, err)
+			}
+			if outLen != len(in.data) || nRead != len(cmp) {
+				t.Fatalf("%s level=%d: got (%d, %d), want (%d, %d)", in.name, level, outLen, nRead, len(in.data), len(cmp))
+			}
+
+			out, err := DecompressInto(cmp, make([]byte, outLen))
+			if err != nil || !bytes.Equal(out, in.data) {
+				t.Fatalf("%s level=%d: exact-size DecompressInto failed: %v", in.name, level, err)
+			}
+		}
+	}
+}
+
+func TestDecodedLenErrors(t *testing.T) {
+	cmp, err := Compress(bytes.Repeat([]byte("decoded-len"), 100), nil)
+	if err != nil {
+		t.Fatalf("Compress failed: %v", err)
+	}
+
+	tests := []struct {
+		name string
+		src  []byte
+		want error
+	}{
+		{name: "empty", src: nil, want: ErrEmptyInput},
+		{name: "truncated", src: cmp[:len(cmp)-1], want: ErrInputOverrun},
+		{name: "missing-terminator", src: cmp[:len(cmp)-3], want: ErrUnexpectedEOF},
+		{name: "underrun", src: []byte{0x12, 'a', markerM3 | 1, 1 << 2, 0}, want: ErrLookBehindUnderrun},
+		{name: "bad-terminator", src: []byte{markerM4 | 2, 0, 0}, want: ErrInputOverrun},
+	}
+	for _, tt := range tests {
+		if _, _, err := DecodedLen(tt.src); !errors.Is(err, tt.want) {
+			t.Fatalf("%s: expected %v, got %v", tt.name, tt.want, err)
+		}
+	}
+}
+
+func FuzzDecodedLenMatchesDecompressNInto(f *testing.F) {
+	f.Add([]byte{markerM4 | 1, 0, 0})
+	f.Add([]byte{0x12, 0x00, 0x20, 0x00, 0xdf, 0x00, 0x00, 0x11, 0x00, 0x00})
+	for _, level := range []int{1, 9} {
+		if compressed, err := Compress(bytes.Repeat([]byte("abcdef"), 1000), &CompressOptions{Level: level}); err == nil {
+			f.Add(compressed)
+		}
+	}
+
+	f.Fuzz(func(t *testing.T, src []byte) {
+		if len(src) > 1<<12 {
+			src = src[:1<<12]
+		}
+
+		// Each input byte expands to at most 255 output bytes, so this never overruns.
+		_, wantRead, wantErr := DecompressNInto(src, make([]byte, 256*len(src)+256))
+		outLen, nRead, err := DecodedLen(src)
+		if (wantErr == nil) != (err == nil) {
+			t.Fatalf("error mismatch: DecompressNInto=%v DecodedLen=%v", wantErr, err)
+		}
+		if err != nil {
+			return
+		}
+		if nRead != wantRead {
+			t.Fatalf("nRead mismatch: got=%d want=%d", nRead, wantRead)
+		}
+
+		out, exactRead, err := DecompressNInto(src, make([]byte, outLen))
+		if err != nil || len(out) != outLen || exactRead != nRead {
+			t.Fatalf("exact-size decode failed: len=%d want=%d err=%v", len(out), outLen, err)
+		}
+	})
+}
@@ -0,0 +1,375 @@
+// SPDX-License-Identifier: MIT
+// Copyright (c) 2026 WoozyMasta
+// Source: github.com/woozymasta/lzo
+
+package lzo
+
+import "io"
+
+// decoderPhase is the position of a Decoder inside the LZO1X instruction grammar.
+type decoderPhase uint8
+
+const (
+	phaseFirst    decoderPhase = iota // phaseFirst expects the first stream byte (optional literal prefix).
+	phaseOpcode                       // phaseOpcode expects the next instruction byte.
+	phaseZeroRun                      // phaseZeroRun consumes a zero-extended length.
+	phaseDistByte                     // phaseDistByte expects the distance byte of an M1/M2 instruction.
+	phaseDistLE16                     // phaseDistLE16 expects the two distance bytes of an M3/M4 instruction.
+	phaseLiteral                      // phaseLiteral copies literal bytes.
+	phaseDone                         // phaseDone follows the stream terminator.
+)
+
+// Decoder decodes one LZO1X stream whose compressed input arrives in arbitrary chunks.
+// It keeps the decoder state machine between calls, so decoding can pause at any
+// byte boundary and continue when more input is written.
+// The output is identical to DecompressInto for the same stream and destination,
+// and so are the errors, except that a stream cut short is reported by Finish as
+// ErrUnexpectedEOF where DecompressInto may report ErrInputOverrun.
+// A Decoder must not be used concurrently.
+type Decoder struct {
+	dst    []byte
+	err    error
+	litErr error // litErr is reported once the current literal run, which did not fit dst, is read.
+
+	// base is the whole growable output including a preserved prefix of
+	// prefix bytes; dst is base[prefix:] (growable mode only).
+	base []byte
+
+	// w receives the output in ring mode, where dst is a ring of ringBufferSize
+	// bytes that is written out whenever it fills up.
+	w       io.Writer
+	flushed int64 // flushed is the number of bytes written to w.
+
+	outPos int // outPos is the number of bytes written to dst.
+	inPos  int // inPos is the number of input bytes consumed.
+	prefix int // prefix is the length of the preserved prefix in base.
+	maxOut int // maxOut caps dst growth in growable mode and the output in ring mode (0 = no limit).
+
+	matchLen  int // matchLen is the length of the match or literal run being decoded.
+	zeroRun   int // zeroRun counts zero bytes of the current zero-extended length.
+	litLeft   int // litLeft is the number of literal bytes still to copy.
+	state     int // state is the literal state left by the previous instruction (0–4).
+	nextState int // nextState is the literal state after the current literal run.
+
+	inst   byte         // inst is the current instruction byte.
+	distLo byte         // distLo is the first byte of a partially read LE16 distance.
+	halfLE bool         // halfLE reports whether distLo holds a pending distance byte.
+	grow   bool         // grow reports whether dst is reallocated instead of overrunning.
+	phase  decoderPhase // phase is the current position in the instruction grammar.
+}
+
+// NewDecoder returns a Decoder that writes decoded output into dst.
+// dst bounds the decompressed size exactly like DecompressInto.
+func NewDecoder(dst []byte) *Decoder {
+	d := &Decoder{}
+	d.Reset(dst)
+	return d
+}
+
+// Reset discards all state and prepares d to decode a new stream into dst.
+func (d *Decoder) Reset(dst []byte) {
+	*d = Decoder{dst: dst}
+}
+
+// Write consumes compressed input from p and decodes as much of it as possible.
+// Input after the stream terminator is not consumed: Write then returns the number
+// of bytes up to and including the terminator together with io.ErrShortWrite.
+// Decoding errors are sticky.
+func (d *Decoder) Write(p []byte) (int, error) {
+	if d.err != nil {
+		return 0, d.err
+	}
+
+	n, err := d.decode(p)
+	d.inPos += n
+	if err != nil {
+		d.err = err
+		return n, err
+	}
+	if n < len(p) {
+		return n, io.ErrShortWrite
+	}
+
+	return n, nil
+}
+
+// Done reports whether the stream terminator has been decoded.
+func (d *Decoder) Done() bool {
+	return d.phase == phaseDone
+}
+
+// Finish completes decoding and returns:
+// 1) decoded output slice (dst[:n]),
+// 2) consumed input bytes up to and including the terminator (nRead),
+// 3) error: ErrEmptyInput when nothing was written, ErrUnexpectedEOF when the
+// terminator has not been reached (whether the input ended between instructions
+// or inside one), or the first decoding error.
+func (d *Decoder) Finish() ([]byte, int, error) {
+	if d.err != nil {
+		return nil, 0, d.err
+	}
+	if d.phase != phaseDone {
+		if d.inPos == 0 {
+			return nil, 0, ErrEmptyInput
+		}
+		return nil, 0, ErrUnexpectedEOF
+	}
+
+	return d.dst[:d.outPos], d.inPos, nil
+}
+
+// decode advances the state machine over p and returns the number of bytes consumed.
+func (d *Decoder) decode(p []byte) (int, error) {
+	pos := 0
+	for pos < len(p) {
+		switch d.phase {
+		case phaseFirst:
+			inst := p[pos]
+			pos++
+
+			// First byte can encode an initial literal run directly; otherwise it is
+			// the first instruction, decoded in state 0.
+			switch {
+			case inst >= 22:
+				d.startLiteral(int(inst)-17, 4)
+			case inst >= 18:
+				d.startLiteral(int(inst)-17, int(inst)-17)
+			default:
+				d.opcode(inst)
+			}
+
+		case phaseOpcode:
+			d.opcode(p[pos])
+			pos++
+
+		case phaseLiteral:
+			n := min(d.litLeft, len(p)-pos)
+			if d.litErr == nil {
+				if err := d.putLiteral(p[pos : pos+n]); err != nil {
+					return pos, err
+				}
+			}
+			d.litLeft -= n
+			pos += n
+			if d.litLeft == 0 {
+				if d.litErr != nil {
+					return pos, d.litErr
+				}
+				d.state = d.nextState
+				d.phase = phaseOpcode
+			}
+
+		case phaseZeroRun:
+			start := pos
+			for pos < len(p) && p[pos] == 0 {
+				pos++
+			}
+			d.zeroRun += pos - start
+			if d.zeroRun > maxZeroExtendedChunks {
+				return pos, ErrInputOverrun
+			}
+			if pos == len(p) {
+				break
+			}
+
+			d.finishZeroRun(int(p[pos]))
+			pos++
+
+		case phaseDistByte:
+			b := p[pos]
+			pos++
+			if err := d.shortMatch(b); err != nil {
+				return pos, err
+			}
+
+		case phaseDistLE16:
+			if !d.halfLE {
+				d.distLo = p[pos]
+				d.halfLE = true
+				pos++
+				continue
+			}
+
+			v16 := uint16(d.distLo) | uint16(p[pos])<<8
+			d.halfLE = false
+			pos++
+			if err := d.longMatch(v16); err != nil {
+				return pos, err
+			}
+
+		case phaseDone:
+			return pos, nil
+		}
+	}
+
+	return pos, nil
+}
+
+// opcode dispatches one instruction byte in the current literal state.
+func (d *Decoder) opcode(inst byte) {
+	d.inst = inst
+
+	switch {
+	case inst >= markerM2:
+		d.matchLen = lenM2(inst)
+		d.phase = phaseDistByte
+
+	case inst >= markerM3:
+		d.matchLen = lenM3(inst)
+		d.beginLength(d.matchLen == 2)
+
+	case inst >= markerM4:
+		d.matchLen = lenM4(inst)
+		d.beginLength(d.matchLen == 2)
+
+	case d.state == 0:
+		// In state 0, this opcode form encodes a literal-run length directly
+		// (with optional zero-extension for long runs).
+		d.matchLen = lenLiteral(inst)
+		if d.matchLen == 3 {
+			d.zeroRun = 0
+			d.phase = phaseZeroRun
+			return
+		}
+		d.startLiteral(d.matchLen, 4)
+
+	default:
+		// In non-zero states this opcode form is a short back-reference and
+		// needs one trailing byte to complete distance bits.
+		d.phase = phaseDistByte
+	}
+}
+
+// beginLength selects the next phase of an M3/M4 instruction.
+func (d *Decoder) beginLength(zeroExtended bool) {
+	if zeroExtended {
+		d.zeroRun = 0
+		d.phase = phaseZeroRun
+		return
+	}
+
+	d.phase = phaseDistLE16
+}
+
+// finishZeroRun completes a zero-extended length with its tail byte.
+func (d *Decoder) finishZeroRun(tail int) {
+	switch {
+	case d.inst >= markerM3:
+		d.matchLen = maxLenM3 + zeroExtension(d.zeroRun, tail)
+		d.phase = phaseDistLE16
+
+	case d.inst >= markerM4:
+		d.matchLen = maxLenM4 + zeroExtension(d.zeroRun, tail)
+		d.phase = phaseDistLE16
+
+	default:
+		d.matchLen = maxLenLiteral + zeroExtension(d.zeroRun, tail)
+		d.startLiteral(d.matchLen, 4)
+	}
+}
+
+// shortMatch completes an M2 or short M1 instruction with its distance byte.
+func (d *Decoder) shortMatch(b byte) error {
+	d.nextState = int(d.inst & 0x03)
+
+	switch {
+	case d.inst >= markerM2:
+		return d.copyMatch(distM2(d.inst, b), d.matchLen)
+
+	case d.state != 4:
+		// General short-match form: fixed length 2, distance starts at 1.
+		return d.copyMatch(distM1(d.inst, b), 2)
+
+	default:
+		// Special short-match form used after a 4-literal tail.
+		return d.copyMatch(maxOffsetM2+distM1(d.inst, b), 3)
+	}
+}
+
+// longMatch completes an M3 or M4 instruction (or the terminator) with its LE16 distance.
+func (d *Decoder) longMatch(v16 uint16) error {
+	d.nextState = int(v16 & 0x03)
+	if d.inst >= markerM3 {
+		return d.copyMatch(distM3(v16), d.matchLen)
+	}
+
+	baseDist := distM4(d.inst, v16)
+	if baseDist == 0 {
+		// Stream terminator is encoded as M4 with distance=0 and length=3.
+		if d.matchLen != 3 {
+			return ErrInputOverrun
+		}
+
+		d.phase = phaseDone
+		return nil
+	}
+
+	return d.copyMatch(baseDist+maxOffsetM3, d.matchLen)
+}
+
+// copyMatch expands one back-reference and schedules its trailing literals.
+func (d *Decoder) copyMatch(dist, length int) error {
+	if d.w != nil {
+		if err := d.copyRingMatch(dist, length); err != nil {
+			return err
+		}
+	} else {
+		matchPos := d.outPos - dist
+		if matchPos < 0 {
+			return ErrLookBehindUnderrun
+		}
+		if d.outPos+length > len(d.dst) {
+			if err := d.growOutput(d.outPos + length); err != nil {
+				return err
+			}
+		}
+
+		copyBackRefUnchecked(d.dst, d.outPos, matchPos, dist, length)
+		d.outPos += length
+	}
+
+	if d.nextState > 0 {
+		d.startLiteral(d.nextState, d.nextState)
+		return nil
+	}
+
+	d.state = 0
+	d.phase = phaseOpcode
+	return nil
+}
+
+// startLiteral begins copying a literal run of n bytes, followed by literal state next.
+func (d *Decoder) startLiteral(n, next int) {
+	// DecompressInto checks that a literal run is complete before it checks
+	// that it fits, so the error waits until the run has been read.
+	switch {
+	case d.w == nil && d.outPos+n > len(d.dst):
+		d.litErr = d.growOutput(d.outPos + n)
+	case d.w != nil && d.ringOverrun(n):
+		d.litErr = ErrOutputOverrun
+	}
+
+	d.litLeft = n
+	d.nextState = next
+	d.phase = phaseLiteral
+}
+
+// putLiteral appends literal bytes to the output.
+// Capacity of a caller-provided dst has already been secured by startLiteral.
+func (d *Decoder) putLiteral(p []byte) error {
+	if d.w == nil {
+		d.outPos += copy(d.dst[d.outPos:], p)
+		return nil
+	}
+
+	for len(p) > 0 {
+		n := copy(d.dst[d.outPos:], p)
+		d.outPos += n
+		p = p[n:]
+		if err := d.flushFullRing(); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
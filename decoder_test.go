@@ -0,0 +1,207 @@
+package lzo
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"testing"
+)
+
+// decodeInChunks feeds src to a Decoder in chunks of the given size.
+func decodeInChunks(t *testing.T, src, dst []byte, chunk int) ([]byte, int, error) {
+	t.Helper()
+
+	d := NewDecoder(dst)
+	for len(src) > 0 {
+		n := min(chunk, len(src))
+		written, err := d.Write(src[:n])
+		if err != nil {
+			return nil, 0, err
+		}
+		if written != n {
+			t.Fatalf("Write consumed %d of %d bytes without error", written, n)
+		}
+		src = src[n:]
+	}
+
+	return d.Finish()
+}
+
+func TestDecoderMatchesDecompressIntoForAllChunkSizes(t *testing.T) {
+	chunks := []int{1, 2, 3, 7, 64, 4096, 1 << 20}
+
+	for _, in := range testInputSet(t) {
+		if len(in.data) == 0 {
+			continue
+		}
+		for _, level := range []int{1, 9} {
+			cmp, err := Compress(in.data, &CompressOptions{Level: level})
+			if err != nil {
+				t.Fatalf("%s level=%d: Compress failed: %v", in.name, level, err)
+			}
+
+			want, wantRead, err := DecompressNInto(cmp, make([]byte, len(in.data)))
+			if err != nil {
+				t.Fatalf("%s level=%d: DecompressNInto failed: %v", in.name, level, err)
+			}
+
+			for _, chunk := range chunks {
+				got, nRead, err := decodeInChunks(t, cmp, make([]byte, len(in.data)), chunk)
+				if err != nil {
+					t.Fatalf("%s level=%d chunk=%d: decode failed: %v", in.name, level, chunk, err)
+				}
+				if nRead != wantRead {
+					t.Fatalf("%s level=%d chunk=%d: nRead=%d want=%d", in.name, level, chunk, nRead, wantRead)
+				}
+				if !bytes.Equal(got, want) {
+					t.Fatalf("%s level=%d chunk=%d: output mismatch", in.name, level, chunk)
+				}
+			}
+		}
+	}
+}
+
+func TestDecoderStopsAtTerminator(t *testing.T) {
+	data := bytes.Repeat([]byte("terminator"), 300)
+	cmp, err := Compress(data, &CompressOptions{Level: 9})
+	if err != nil {
+		t.Fatalf("Compress failed: %v", err)
+	}
+
+	src := append(append([]byte(nil), cmp...), "tail"...)
+	d := NewDecoder(make([]byte, len(data)))
+	n, err := d.Write(src)
+	if !errors.Is(err, io.ErrShortWrite) {
+		t.Fatalf("expected io.ErrShortWrite, got %v", err)
+	}
+	if n != len(cmp) || !d.Done() {
+		t.Fatalf("Write consumed %d bytes (done=%v), want %d", n, d.Done(), len(cmp))
+	}
+
+	if n, err := d.Write([]byte("more")); n != 0 || !errors.Is(err, io.ErrShortWrite) {
+		t.Fatalf("Write after terminator: n=%d err=%v", n, err)
+	}
+
+	out, nRead, err := d.Finish()
+	if err != nil {
+		t.Fatalf("Finish failed: %v", err)
+	}
+	if nRead != len(cmp) || !bytes.Equal(out, data) {
+		t.Fatalf("Finish mismatch: nRead=%d want=%d", nRead, len(cmp))
+	}
+}
+
+func TestDecoderFinishErrors(t *testing.T) {
+	data := bytes.Repeat([]byte("finish-errors"), 100)
+	cmp, err := Compress(data, &CompressOptions{Level: 5})
+	if err != nil {
+		t.Fatalf("Compress failed: %v", err)
+	}
+
+	d := NewDecoder(make([]byte, len(data)))
+	if _, _, err := d.Finish(); !errors.Is(err, ErrEmptyInput) {
+		t.Fatalf("empty: expected ErrEmptyInput, got %v", err)
+	}
+
+	for cut := 1; cut < len(cmp); cut++ {
+		d.Reset(make([]byte, len(data)))
+		if _, err := d.Write(cmp[:cut]); err != nil {
+			t.Fatalf("cut=%d: unexpected Write error: %v", cut, err)
+		}
+		if _, _, err := d.Finish(); !errors.Is(err, ErrUnexpectedEOF) {
+			t.Fatalf("cut=%d: expected ErrUnexpectedEOF, got %v", cut, err)
+		}
+	}
+
+	d.Reset(make([]byte, len(data)-1))
+	if _, err := d.Write(cmp); !errors.Is(err, ErrOutputOverrun) {
+		t.Fatalf("small dst: expected ErrOutputOverrun, got %v", err)
+	}
+	if _, err := d.Write(cmp); !errors.Is(err, ErrOutputOverrun) {
+		t.Fatalf("error is not sticky: %v", err)
+	}
+	if _, _, err := d.Finish(); !errors.Is(err, ErrOutputOverrun) {
+		t.Fatalf("Finish: expected ErrOutputOverrun, got %v", err)
+	}
+}
+
+func TestDecoderLiteralOverrunWaitsForInput(t *testing.T) {
+	// A literal run of 8 bytes into a 4-byte dst: like DecompressInto, the
+	// Decoder reports the overrun only once the whole run has arrived.
+	src := []byte{17 + 8, 'a', 'b', 'c', 'd', 'e', 'f', 'g', 'h', markerM4 | 1, 0, 0}
+
+	d := NewDecoder(make([]byte, 4))
+	if _, err := d.Write(src[:5]); err != nil {
+		t.Fatalf("partial run: unexpected error %v", err)
+	}
+	if _, _, err := d.Finish(); !errors.Is(err, ErrUnexpectedEOF) {
+		t.Fatalf("cut short: expected ErrUnexpectedEOF, got %v", err)
+	}
+	if _, err := d.Write(src[5:]); !errors.Is(err, ErrOutputOverrun) {
+		t.Fatalf("complete run: expected ErrOutputOverrun, got %v", err)
+	}
+	if _, _, err := DecompressNInto(src, make([]byte, 4)); !errors.Is(err, ErrOutputOverrun) {
+		t.Fatalf("DecompressNInto: expected ErrOutputOverrun, got %v", err)
+	}
+}
+
+func TestDecoderLookBehindUnderrun(t *testing.T) {
+	// One literal followed by an M3 match with distance 2.
+	src := []byte{0x12, 'a', markerM3 | 1, 1 << 2, 0}
+	_, err := NewDecoder(make([]byte, 16)).Write(src)
+	if !errors.Is(err, ErrLookBehindUnderrun) {
+		t.Fatalf("expected ErrLookBehindUnderrun, got %v", err)
+	}
+}
+
+func FuzzDecoderMatchesDecompressInto(f *testing.F) {
+	f.Add([]byte{markerM4 | 1, 0, 0}, []byte{0})
+	f.Add([]byte{0x12, 0x00, 0x20, 0x00, 0xdf, 0x00, 0x00, 0x11, 0x00, 0x00}, []byte{2, 0, 5})
+	for _, level := range []int{1, 9} {
+		if compressed, err := Compress(bytes.Repeat([]byte("abcdef"), 1000), &CompressOptions{Level: level}); err == nil {
+			f.Add(compressed, []byte{4, 0, 1, 200, 17})
+		}
+	}
+
+	// chunks gives the sizes, less one, of the successive Writes, cycling.
+	f.Fuzz(func(t *testing.T, src, chunks []byte) {
+		if len(src) > 1<<16 {
+			src = src[:1<<16]
+		}
+
+		want, wantRead, wantErr := DecompressNInto(src, make([]byte, 1<<16))
+
+		d := NewDecoder(make([]byte, 1<<16))
+		rest := src
+		var gotErr error
+		for i := 0; len(rest) > 0 && gotErr == nil && !d.Done(); i++ {
+			n := len(rest)
+			if len(chunks) > 0 {
+				n = min(int(chunks[i%len(chunks)])+1, n)
+			}
+			var written int
+			written, gotErr = d.Write(rest[:n])
+			rest = rest[written:]
+		}
+		if errors.Is(gotErr, io.ErrShortWrite) {
+			gotErr = nil
+		}
+		got, nRead, err := d.Finish()
+		if gotErr == nil {
+			gotErr = err
+		}
+
+		if (wantErr == nil) != (gotErr == nil) {
+			t.Fatalf("error mismatch: DecompressNInto=%v Decoder=%v", wantErr, gotErr)
+		}
+		// Decoder cannot tell a cut-short stream from one still arriving, so it
+		// reports ErrUnexpectedEOF for it; every other error is the same.
+		truncated := errors.Is(gotErr, ErrUnexpectedEOF) && errors.Is(wantErr, ErrInputOverrun)
+		if wantErr != nil && !errors.Is(gotErr, wantErr) && !truncated {
+			t.Fatalf("error class mismatch: DecompressNInto=%v Decoder=%v", wantErr, gotErr)
+		}
+		if wantErr == nil && (nRead != wantRead || !bytes.Equal(got, want)) {
+			t.Fatalf("result mismatch: nRead=%d want=%d", nRead, wantRead)
+		}
+	})
+}
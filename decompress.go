@@ -7,6 +7,7 @@ package lzo
 import (
 	"io"
 	"math"
+	"math/bits"
 	"unsafe"
 )
 
@@ -17,7 +18,13 @@ const maxZeroExtendedChunks = math.MaxInt/255 - 2
 // Decompress decompresses LZO1X data from src into a buffer of length opts.OutLen.
 // Returns ErrOptionsRequired if opts is nil; ErrEmptyInput if src is empty.
 // On success returns the decompressed slice (length may be less than OutLen if stream ended with terminator).
+// With opts.GrowOutput the output may also be longer than OutLen, up to opts.MaxOutLen.
 func Decompress(src []byte, opts *DecompressOptions) ([]byte, error) {
+	if opts != nil && opts.GrowOutput {
+		out, _, err := decompressGrowOptions(src, opts)
+		return out, err
+	}
+
 	dst, err := makeDecompressBuffer(opts)
 	if err != nil {
 		return nil, err
@@ -52,7 +59,7 @@ func DecompressNInto(src []byte, dst []byte) ([]byte, int, error) {
 		return nil, 0, ErrEmptyInput
 	}
 
-	outWritten, inConsumed, err := decompressCore(src, dst)
+	outWritten, inConsumed, err := decompressCore(src, dst, nil, formatLZO1X)
 	if err != nil {
 		return nil, 0, err
 	}
@@ -60,10 +67,33 @@ func DecompressNInto(src []byte, dst []byte) ([]byte, int, error) {
 	return dst[:outWritten], inConsumed, nil
 }
 
+// DecompressWithDict decompresses src into caller-provided dst and returns dst[:n].
+// Back-references may reach past dst[0] into dict, the preset dictionary the stream
+// was compressed with (CompressOptions.Dict, lzo1x_decompress_dict_safe); only its
+// last 0xbfff bytes are reachable. ErrLookBehindUnderrun is returned only for
+// references before the start of dict.
+func DecompressWithDict(src, dst, dict []byte) ([]byte, error) {
+	if len(src) == 0 {
+		return nil, ErrEmptyInput
+	}
+
+	dict = dict[len(dict)-min(len(dict), maxOffsetM4):]
+	outWritten, _, err := decompressCore(src, dst, dict, formatLZO1X)
+	if err != nil {
+		return nil, err
+	}
+
+	return dst[:outWritten], nil
+}
+
 // DecompressN decompresses LZO1X data from src and returns the decoded slice,
 // the number of input bytes consumed (nRead), and an error.
 // nRead is 0 on error. Use this when advancing a stream (e.g. back-to-back compressed blocks).
 func DecompressN(src []byte, opts *DecompressOptions) ([]byte, int, error) {
+	if opts != nil && opts.GrowOutput {
+		return decompressGrowOptions(src, opts)
+	}
+
 	dst, err := makeDecompressBuffer(opts)
 	if err != nil {
 		return nil, 0, err
@@ -146,14 +176,106 @@ func makeDecompressBuffer(opts *DecompressOptions) ([]byte, error) {
 	return make([]byte, outLen), nil
 }
 
-// decompressCore decompresses LZO1X data from src into dst using a state machine.
+// decompressGrowOptions validates options and decodes src into a growable buffer.
+func decompressGrowOptions(src []byte, opts *DecompressOptions) ([]byte, int, error) {
+	if opts.OutLen < 0 || opts.MaxOutLen < 0 {
+		return nil, 0, ErrOptionsRequired
+	}
+
+	return decompressGrow(nil, src, opts.OutLen, opts.MaxOutLen)
+}
+
+// instruction is one decoded LZO1X instruction.
+// Match instructions are followed by litLen trailing literals (0–3); literal-run
+// instructions carry only litLen. Either way the literal state left for the next
+// instruction is min(litLen, 4).
+type instruction struct {
+	litLen    int
+	matchLen  int
+	matchDist int
+	kind      TokenKind
+}
+
+// tokenZeroRun is the LZO-RLE zero-run instruction: matchLen zero bytes followed
+// by litLen literals. It only occurs in formatLZORLE streams and is not exported
+// through Tokens.
+const tokenZeroRun = TokenEnd + 1
+
+// isMatch reports whether the instruction encodes a back-reference.
+func (ins *instruction) isMatch() bool {
+	return ins.kind >= TokenM1 && ins.kind < TokenEnd
+}
+
+// decompressCore decompresses data of format f from src into dst using a state machine.
 // It writes starting at dst[0] and returns (bytes written, input bytes consumed, nil) on success.
+// preset is an optional dictionary that back-references may reach into before dst[0].
 // On stream terminator it returns (outputOffset, inputOffset, nil). On error it returns (0, 0, err).
-func decompressCore(src, dst []byte) (outWritten, inConsumed int, err error) {
+func decompressCore(src, dst, preset []byte, f format) (outWritten, inConsumed int, err error) {
 	if len(src) == 0 {
 		return 0, 0, ErrEmptyInput
 	}
+	if f == formatLZO1X {
+		return decompressLZO1X(src, dst, preset)
+	}
+
+	var (
+		state    int
+		inPos    int
+		outPos   int
+		lastDist int
+	)
+
+	for {
+		ins, err := parseInstruction(src, &inPos, state, lastDist, f)
+		if err != nil {
+			return 0, 0, err
+		}
+
+		if ins.isMatch() {
+			lastDist = ins.matchDist
+			matchPos := outPos - ins.matchDist
+			if matchPos < 0 && -matchPos > len(preset) {
+				return 0, 0, ErrLookBehindUnderrun
+			}
+			if outPos+ins.matchLen > len(dst) {
+				return 0, 0, ErrOutputOverrun
+			}
+
+			if matchPos < 0 {
+				copyPresetBackRef(dst, outPos, preset, ins.matchDist, ins.matchLen)
+			} else {
+				copyBackRefUnchecked(dst, outPos, matchPos, ins.matchDist, ins.matchLen)
+			}
+			outPos += ins.matchLen
+		} else if ins.kind == TokenEnd {
+			return outPos, inPos, nil
+		} else if ins.kind == tokenZeroRun {
+			if outPos+ins.matchLen > len(dst) {
+				return 0, 0, ErrOutputOverrun
+			}
+
+			clear(dst[outPos : outPos+ins.matchLen])
+			outPos += ins.matchLen
+		}
+
+		if err := copyLiteralRun(src, &inPos, dst, &outPos, ins.litLen); err != nil {
+			return 0, 0, err
+		}
+
+		// Keep historical behavior: a plain literal-run stream without terminator is malformed.
+		if ins.kind == TokenLiteral && inPos >= len(src) {
+			return 0, 0, ErrInputOverrun
+		}
+
+		state = min(ins.litLen, 4)
+	}
+}
 
+// decompressLZO1X is decompressCore specialised for LZO1X, the hot path behind
+// DecompressInto and the stream readers. It decodes the same grammar as
+// parseInstruction but keeps the opcode switch inline, without per-instruction
+// format dispatch or instruction values. src must not be empty.
+func decompressLZO1X(src, dst, preset []byte) (outWritten, inConsumed int, err error) {
 	var (
 		inst      byte
 		state     int
@@ -164,26 +286,17 @@ func decompressCore(src, dst []byte) (outWritten, inConsumed int, err error) {
 		outPos    int
 	)
 
-	inst, err = readCompressedByte(src, &inPos)
-	if err != nil {
-		return 0, 0, err
-	}
+	inst = src[0]
+	inPos = 1
 
 	// First byte can encode an initial literal run directly; otherwise it becomes
 	// the first instruction in the main decode loop.
-	switch {
-	case inst >= 22:
-		if err := copyLiteralRun(src, &inPos, dst, &outPos, int(inst)-17); err != nil {
-			return 0, 0, err
-		}
-		state = 4
-
-	case inst >= 18:
-		nextState = int(inst - 17)
+	if inst >= 18 {
+		nextState = int(inst) - 17
 		if err := copyLiteralRun(src, &inPos, dst, &outPos, nextState); err != nil {
 			return 0, 0, err
 		}
-		state = nextState
+		state = min(nextState, 4)
 	}
 
 	for {
@@ -204,24 +317,19 @@ func decompressCore(src, dst []byte) (outWritten, inConsumed int, err error) {
 				return 0, 0, err
 			}
 
-			matchDist = (int(b) << 3) + ((int(inst) >> 2) & 0x7) + 1
-			matchLen = (int(inst) >> 5) + 1
+			matchDist = distM2(inst, b)
+			matchLen = lenM2(inst)
 			nextState = int(inst & 0x03)
 
 		case inst >= markerM3:
-			matchLen = int(inst&0x1f) + 2
+			matchLen = lenM3(inst)
 			if matchLen == 2 {
-				ext, err := readZeroExtendedChunks(src, &inPos)
-				if err != nil {
-					return 0, 0, err
-				}
-
-				tail, err := readCompressedByte(src, &inPos)
+				ext, err := readZeroExtendedLength(src, &inPos)
 				if err != nil {
 					return 0, 0, err
 				}
 
-				matchLen += ext*255 + 31 + int(tail)
+				matchLen = maxLenM3 + ext
 			}
 
 			v16, err := readCompressedLE16(src, &inPos)
@@ -229,23 +337,18 @@ func decompressCore(src, dst []byte) (outWritten, inConsumed int, err error) {
 				return 0, 0, err
 			}
 
-			matchDist = (int(v16) >> 2) + 1
+			matchDist = distM3(v16)
 			nextState = int(v16 & 0x03)
 
 		case inst >= markerM4:
-			matchLen = int(inst&0x7) + 2
+			matchLen = lenM4(inst)
 			if matchLen == 2 {
-				ext, err := readZeroExtendedChunks(src, &inPos)
+				ext, err := readZeroExtendedLength(src, &inPos)
 				if err != nil {
 					return 0, 0, err
 				}
 
-				tail, err := readCompressedByte(src, &inPos)
-				if err != nil {
-					return 0, 0, err
-				}
-
-				matchLen += ext*255 + 7 + int(tail)
+				matchLen = maxLenM4 + ext
 			}
 
 			v16, err := readCompressedLE16(src, &inPos)
@@ -253,7 +356,7 @@ func decompressCore(src, dst []byte) (outWritten, inConsumed int, err error) {
 				return 0, 0, err
 			}
 
-			baseDist := ((int(inst) & 0x8) << 11) + (int(v16) >> 2)
+			baseDist := distM4(inst, v16)
 			if baseDist == 0 {
 				// Stream terminator is encoded as M4 with distance=0 and length=3.
 				if matchLen != 3 {
@@ -263,41 +366,35 @@ func decompressCore(src, dst []byte) (outWritten, inConsumed int, err error) {
 				return outPos, inPos, nil
 			}
 
-			matchDist = baseDist + 0x4000
+			matchDist = baseDist + maxOffsetM3
 			nextState = int(v16 & 0x03)
 
-		default:
-			if state == 0 {
-				// In state 0, this opcode form encodes a literal-run length directly
-				// (with optional zero-extension for long runs).
-				runLen := int(inst) + 3
-				if runLen == 3 {
-					ext, err := readZeroExtendedChunks(src, &inPos)
-					if err != nil {
-						return 0, 0, err
-					}
-
-					tail, err := readCompressedByte(src, &inPos)
-					if err != nil {
-						return 0, 0, err
-					}
-
-					runLen += ext*255 + 15 + int(tail)
-				}
-
-				if err := copyLiteralRun(src, &inPos, dst, &outPos, runLen); err != nil {
+		case state == 0:
+			// In state 0, this opcode form encodes a literal-run length directly
+			// (with optional zero-extension for long runs).
+			runLen := lenLiteral(inst)
+			if runLen == 3 {
+				ext, err := readZeroExtendedLength(src, &inPos)
+				if err != nil {
 					return 0, 0, err
 				}
 
-				// Keep historical behavior: a plain literal-run stream without terminator is malformed.
-				if inPos >= len(src) {
-					return 0, 0, ErrInputOverrun
-				}
+				runLen = maxLenLiteral + ext
+			}
 
-				state = 4
-				continue
+			if err := copyLiteralRun(src, &inPos, dst, &outPos, runLen); err != nil {
+				return 0, 0, err
+			}
+
+			// Keep historical behavior: a plain literal-run stream without terminator is malformed.
+			if inPos >= len(src) {
+				return 0, 0, ErrInputOverrun
 			}
 
+			state = 4
+			continue
+
+		default:
 			// In non-zero states this opcode form is a short back-reference and
 			// needs one trailing byte to complete distance bits.
 			tail, err := readCompressedByte(src, &inPos)
@@ -306,29 +403,32 @@ func decompressCore(src, dst []byte) (outWritten, inConsumed int, err error) {
 			}
 
 			nextState = int(inst & 0x03)
-			switch {
-			case state != 4:
+			if state != 4 {
 				// General short-match form: fixed length 2, distance starts at 1.
-				matchDist = (int(inst) >> 2) + (int(tail) << 2) + 1
+				matchDist = distM1(inst, tail)
 				matchLen = 2
-
-			default:
+			} else {
 				// Special short-match form used after a 4-literal tail.
-				matchDist = shortMatchBaseOffset + 1 + (int(inst) >> 2) + (int(tail) << 2)
+				matchDist = maxOffsetM2 + distM1(inst, tail)
 				matchLen = 3
 			}
 		}
 
 		matchPos := outPos - matchDist
-		if matchPos < 0 {
+		if matchPos < 0 && -matchPos > len(preset) {
 			return 0, 0, ErrLookBehindUnderrun
 		}
 		if outPos+matchLen > len(dst) {
 			return 0, 0, ErrOutputOverrun
 		}
 
-		copyBackRefUnchecked(dst, outPos, matchPos, matchDist, matchLen)
+		if matchPos < 0 {
+			copyPresetBackRef(dst, outPos, preset, matchDist, matchLen)
+		} else {
+			copyBackRefUnchecked(dst, outPos, matchPos, matchDist, matchLen)
+		}
 		outPos += matchLen
+
 		if nextState > 0 {
 			if err := copyLiteralRun(src, &inPos, dst, &outPos, nextState); err != nil {
 				return 0, 0, err
@@ -339,6 +439,269 @@ func decompressCore(src, dst []byte) (outWritten, inConsumed int, err error) {
 	}
 }
 
+// parseInstruction reads one instruction of format f at src[*inPos] in the given
+// literal state and advances *inPos past its opcode and length/distance bytes, but
+// not past its literal bytes. lastDist is the distance of the previous match,
+// which LZO1Z M2 instructions may repeat. It is the single parser of the LZO1X
+// opcode grammar shared by decompressCore (for LZO1Y, LZO1Z and LZO-RLE),
+// DecodedLen and Tokens; decompressLZO1X inlines the LZO1X subset of it.
+func parseInstruction(src []byte, inPos *int, state, lastDist int, f format) (instruction, error) {
+	if *inPos >= len(src) {
+		return instruction{}, ErrUnexpectedEOF
+	}
+
+	inst := src[*inPos]
+	*inPos++
+
+	// First byte can encode an initial literal run directly; otherwise it is
+	// the first instruction, decoded in state 0.
+	if *inPos == 1 && inst >= 18 {
+		return instruction{kind: TokenInitialLiteral, litLen: int(inst) - 17}, nil
+	}
+
+	switch {
+	case inst >= markerM2 && f == formatLZO1Z:
+		return parseM2LZO1Z(src, inPos, inst, lastDist)
+
+	case inst >= markerM2:
+		b, err := readCompressedByte(src, inPos)
+		if err != nil {
+			return instruction{}, err
+		}
+
+		if f == formatLZO1Y {
+			return instruction{
+				kind:      TokenM2,
+				matchLen:  (int(inst) >> 4) - 1,
+				matchDist: (int(b) << 2) + ((int(inst) >> 2) & 0x3) + 1,
+				litLen:    int(inst & 0x03),
+			}, nil
+		}
+
+		return instruction{
+			kind:      TokenM2,
+			matchLen:  lenM2(inst),
+			matchDist: distM2(inst, b),
+			litLen:    int(inst & 0x03),
+		}, nil
+
+	case inst >= markerM3:
+		matchLen := lenM3(inst)
+		if matchLen == 2 {
+			ext, err := readZeroExtendedLength(src, inPos)
+			if err != nil {
+				return instruction{}, err
+			}
+
+			matchLen = maxLenM3 + ext
+		}
+
+		v16, err := readCompressedLE16(src, inPos)
+		if err != nil {
+			return instruction{}, err
+		}
+		if f == formatLZO1Z {
+			// LZO1Z stores the distance high bits first; swapped, the fields line up with LZO1X.
+			v16 = bits.ReverseBytes16(v16)
+		}
+
+		return instruction{
+			kind:      TokenM3,
+			matchLen:  matchLen,
+			matchDist: distM3(v16),
+			litLen:    int(v16 & 0x03),
+		}, nil
+
+	case inst >= markerM4 && f == formatLZORLE && isZeroRun(src, *inPos, inst):
+		// Opcode 0x18–0x1f followed by 0xfc–0xff, 0xff would be an M4 at a
+		// distance no LZO-RLE compressor emits, so version 1 uses it for zero runs.
+		if *inPos+3 > len(src) {
+			return instruction{}, ErrInputOverrun
+		}
+		runLen := int(inst&0x7) | int(src[*inPos+2])<<3
+		litLen := int(src[*inPos] & 0x03)
+		*inPos += 3
+
+		return instruction{kind: tokenZeroRun, matchLen: runLen + minLenZeroRun, litLen: litLen}, nil
+
+	case inst >= markerM4:
+		matchLen := lenM4(inst)
+		if matchLen == 2 {
+			ext, err := readZeroExtendedLength(src, inPos)
+			if err != nil {
+				return instruction{}, err
+			}
+
+			matchLen = maxLenM4 + ext
+		}
+
+		v16, err := readCompressedLE16(src, inPos)
+		if err != nil {
+			return instruction{}, err
+		}
+		if f == formatLZO1Z {
+			// LZO1Z stores the distance high bits first; swapped, the fields line up with LZO1X.
+			v16 = bits.ReverseBytes16(v16)
+		}
+
+		baseDist := distM4(inst, v16)
+		if baseDist == 0 {
+			// Stream terminator is encoded as M4 with distance=0 and length=3.
+			if matchLen != 3 {
+				return instruction{}, ErrInputOverrun
+			}
+
+			return instruction{kind: TokenEnd, matchLen: matchLen}, nil
+		}
+
+		return instruction{
+			kind:      TokenM4,
+			matchLen:  matchLen,
+			matchDist: baseDist + maxOffsetM3,
+			litLen:    int(v16 & 0x03),
+		}, nil
+
+	case state == 0:
+		// In state 0, this opcode form encodes a literal-run length directly
+		// (with optional zero-extension for long runs).
+		runLen := lenLiteral(inst)
+		if runLen == 3 {
+			ext, err := readZeroExtendedLength(src, inPos)
+			if err != nil {
+				return instruction{}, err
+			}
+
+			runLen = maxLenLiteral + ext
+		}
+
+		return instruction{kind: TokenLiteral, litLen: runLen}, nil
+	}
+
+	// In non-zero states this opcode form is a short back-reference and
+	// needs one trailing byte to complete distance bits.
+	tail, err := readCompressedByte(src, inPos)
+	if err != nil {
+		return instruction{}, err
+	}
+
+	if f == formatLZO1Z {
+		// LZO1Z puts the distance high bits in the opcode and the literal count in the tail byte.
+		kind, matchLen, base := TokenM1, 2, 0
+		if state == 4 {
+			kind, matchLen, base = TokenM1Long, 3, maxOffsetM2Z
+		}
+		return instruction{
+			kind:      kind,
+			matchLen:  matchLen,
+			matchDist: base + (int(inst) << 6) + (int(tail) >> 2) + 1,
+			litLen:    int(tail & 0x03),
+		}, nil
+	}
+
+	if state != 4 {
+		// General short-match form: fixed length 2, distance starts at 1.
+		return instruction{
+			kind:      TokenM1,
+			matchLen:  2,
+			matchDist: distM1(inst, tail),
+			litLen:    int(inst & 0x03),
+		}, nil
+	}
+
+	// Special short-match form used after a 4-literal tail.
+	return instruction{
+		kind:      TokenM1Long,
+		matchLen:  3,
+		matchDist: f.maxOffsetM2() + distM1(inst, tail),
+		litLen:    int(inst & 0x03),
+	}, nil
+}
+
+// lenM2, lenM3, lenM4 and lenLiteral return the length an LZO1X opcode encodes
+// directly. For M3, M4 and literal runs a zero length field returns the
+// smallest value (2, 2 and 3); the actual length is then maxLenM3, maxLenM4 or
+// maxLenLiteral plus the zero extension that follows the opcode.
+func lenM2(inst byte) int      { return int(inst>>5) + 1 }
+func lenM3(inst byte) int      { return int(inst&0x1f) + 2 }
+func lenM4(inst byte) int      { return int(inst&0x7) + 2 }
+func lenLiteral(inst byte) int { return int(inst) + 3 }
+
+// distM1 returns the distance of a short LZO1X match with opcode inst and
+// distance byte b. The 3-byte form after a four-literal tail adds the
+// farthest M2 distance.
+func distM1(inst, b byte) int {
+	return int(inst>>2) + int(b)<<2 + 1
+}
+
+// distM2 returns the distance of an LZO1X M2 match with opcode inst and distance byte b.
+func distM2(inst, b byte) int {
+	return int(b)<<3 + int(inst>>2&0x7) + 1
+}
+
+// distM3 returns the distance of an M3 match with little-endian distance field v16.
+func distM3(v16 uint16) int {
+	return int(v16>>2) + 1
+}
+
+// distM4 returns the distance of an M4 match with opcode inst and distance field
+// v16, less maxOffsetM3. Zero marks the stream terminator.
+func distM4(inst byte, v16 uint16) int {
+	return int(inst&0x8)<<11 + int(v16>>2)
+}
+
+// zeroExtension returns what a zero-extended length adds to the longest direct
+// length: 255 per zero byte plus the tail byte.
+func zeroExtension(zeros, tail int) int {
+	return zeros*255 + tail
+}
+
+// isZeroRun reports whether opcode inst, whose remaining bytes start at src[pos],
+// is an LZO-RLE zero-run instruction.
+func isZeroRun(src []byte, pos int, inst byte) bool {
+	return inst&0xf8 == 0x18 && pos+2 <= len(src) && src[pos]&0xfc == 0xfc && src[pos+1] == 0xff
+}
+
+// parseM2LZO1Z reads the rest of an LZO1Z M2 instruction. Distance fields of
+// 0x1c and above repeat lastDist and have no distance byte; the literal count
+// then sits in the opcode instead of the distance byte.
+func parseM2LZO1Z(src []byte, inPos *int, inst byte, lastDist int) (instruction, error) {
+	ins := instruction{kind: TokenM2, matchLen: (int(inst) >> 5) + 1}
+
+	if inst&0x1f >= repeatFieldM2 {
+		if lastDist == 0 {
+			return instruction{}, ErrLookBehindUnderrun
+		}
+		ins.matchDist = lastDist
+		ins.litLen = int(inst & 0x03)
+		return ins, nil
+	}
+
+	b, err := readCompressedByte(src, inPos)
+	if err != nil {
+		return instruction{}, err
+	}
+
+	ins.matchDist = (int(inst&0x1f) << 6) + (int(b) >> 2) + 1
+	ins.litLen = int(b & 0x03)
+	return ins, nil
+}
+
+// readZeroExtendedLength reads the zero bytes and tail byte of a zero-extended
+// length and returns the amount they add (255 per zero byte plus the tail).
+func readZeroExtendedLength(src []byte, inPos *int) (int, error) {
+	ext, err := readZeroExtendedChunks(src, inPos)
+	if err != nil {
+		return 0, err
+	}
+
+	tail, err := readCompressedByte(src, inPos)
+	if err != nil {
+		return 0, err
+	}
+
+	return zeroExtension(ext, int(tail)), nil
+}
+
 // readCompressedByte reads one byte from src at *inPos and advances *inPos.
 func readCompressedByte(src []byte, inPos *int) (byte, error) {
 	if *inPos >= len(src) {
@@ -404,6 +767,17 @@ func copyBackRefUnchecked(dst []byte, outputPos, matchPos, dist, length int) {
 	}
 }
 
+// copyPresetBackRef expands a back-reference that starts inside preset,
+// the dictionary that logically precedes dst[0]. Bounds have been validated.
+func copyPresetBackRef(dst []byte, outputPos int, preset []byte, dist, length int) {
+	start := len(preset) + outputPos - dist
+	n := copy(dst[outputPos:outputPos+length], preset[start:])
+	if n < length {
+		// The rest of the match continues from the start of dst.
+		copyBackRefUnchecked(dst, outputPos+n, 0, dist, length-n)
+	}
+}
+
 // copyLiteralRun copies `n` bytes from src[*inPos:] to dst[*outPos:] and advances both pointers.
 func copyLiteralRun(src []byte, inPos *int, dst []byte, outPos *int, n int) error {
 	if n == 0 {
@@ -0,0 +1,127 @@
+// SPDX-License-Identifier: MIT
+// Copyright (c) 2026 WoozyMasta
+// Source: github.com/woozymasta/lzo
+
+package lzo
+
+import "math"
+
+const (
+	// minGrowOutLen is the smallest initial destination of a growable decode.
+	minGrowOutLen = 256
+
+	// growEstimateRatio is the assumed compression ratio used to size the
+	// initial destination when no size hint is given.
+	growEstimateRatio = 4
+)
+
+// DecompressAuto decompresses LZO1X data from src without knowing the decompressed size.
+// The destination starts from an estimate and grows as needed; decoding resumes
+// where it stopped instead of restarting. maxOut caps the decompressed size
+// (0 = no limit) and guards against decompression bombs: a stream that expands
+// past it fails with ErrOutputOverrun.
+func DecompressAuto(src []byte, maxOut int) ([]byte, error) {
+	if maxOut < 0 {
+		return nil, ErrOptionsRequired
+	}
+
+	out, _, err := decompressGrow(nil, src, 0, maxOut)
+	if err != nil {
+		return nil, err
+	}
+
+	return out, nil
+}
+
+// AppendDecompress appends decompressed src to dst and returns the extended slice.
+// Spare capacity of dst is used first and the slice grows as needed.
+// dst[:len(dst)] is preserved and is not visible to back-references.
+// src and dst must not overlap.
+func AppendDecompress(dst, src []byte) ([]byte, error) {
+	out, _, err := decompressGrow(dst, src, 0, 0)
+	if err != nil {
+		return dst, err
+	}
+
+	return out, nil
+}
+
+// decompressGrow decodes src after dst[:len(dst)] into a growable buffer.
+// sizeHint is the expected decompressed size (0 = estimate from len(src)) and
+// maxOut caps the decompressed size (0 = no limit).
+// It returns the whole buffer (prefix and output) and the consumed input bytes.
+func decompressGrow(dst, src []byte, sizeHint, maxOut int) ([]byte, int, error) {
+	if len(src) == 0 {
+		return nil, 0, ErrEmptyInput
+	}
+
+	prefix := len(dst)
+	initial := sizeHint
+	if initial <= 0 {
+		initial = minGrowOutLen
+		if len(src) <= math.MaxInt/growEstimateRatio {
+			initial = max(initial, len(src)*growEstimateRatio)
+		}
+	}
+	if maxOut > 0 {
+		initial = min(initial, maxOut)
+	}
+
+	// Spare capacity already present in dst is enough to start; only a nil or
+	// full dst needs an initial allocation.
+	base := dst
+	if cap(dst)-prefix < initial {
+		base = make([]byte, prefix, prefix+initial)
+		copy(base, dst)
+	}
+	base = base[:cap(base)]
+	if maxOut > 0 && len(base)-prefix > maxOut {
+		base = base[:prefix+maxOut]
+	}
+
+	d := &Decoder{
+		dst:    base[prefix:],
+		base:   base,
+		prefix: prefix,
+		maxOut: maxOut,
+		grow:   true,
+	}
+	if _, err := d.Write(src); err != nil && d.err != nil {
+		return nil, 0, err
+	}
+
+	out, nRead, err := d.Finish()
+	if err != nil {
+		return nil, 0, err
+	}
+
+	return d.base[:prefix+len(out)], nRead, nil
+}
+
+// growOutput reallocates dst so that it holds at least need bytes.
+// The decoded output and the preserved prefix are carried over, so decoding
+// continues in place. It returns ErrOutputOverrun outside growable mode or
+// when need exceeds maxOut.
+func (d *Decoder) growOutput(need int) error {
+	if !d.grow || (d.maxOut > 0 && need > d.maxOut) {
+		return ErrOutputOverrun
+	}
+
+	size := need
+	if len(d.dst) <= math.MaxInt/2 {
+		size = max(size, 2*len(d.dst))
+	}
+	if d.maxOut > 0 {
+		size = min(size, d.maxOut)
+	}
+	if size > math.MaxInt-d.prefix {
+		return ErrOutputOverrun
+	}
+
+	base := make([]byte, d.prefix+size)
+	copy(base, d.base[:d.prefix+d.outPos])
+	d.base = base
+	d.dst = base[d.prefix:]
+
+	return nil
+}
@@ -0,0 +1,106 @@
+package lzo
+
+import (
+	"bytes"
+	"errors"
+	"testing"
+)
+
+func TestDecompressAutoMatchesInput(t *testing.T) {
+	inputs := testInputSet(t)
+	inputs = append(inputs, struct {
+		name string
+		data []byte
+	}{name: "zeros-1MiB", data: make([]byte, 1<<20)})
+
+	for _, in := range inputs {
+		if len(in.data) == 0 {
+			continue
+		}
+		for _, level := range []int{1, 9} {
+			cmp, err := Compress(in.data, &CompressOptions{Level: level})
+			if err != nil {
+				t.Fatalf("%s level=%d: Compress failed: %v", in.name, level, err)
+			}
+
+			out, err := DecompressAuto(cmp, 0)
+			if err != nil {
+				t.Fatalf("%s level=%d: DecompressAuto failed: %v", in.name, level, err)
+			}
+			if !bytes.Equal(out, in.data) {
+				t.Fatalf("%s level=%d: output mismatch (got=%d want=%d)", in.name, level, len(out), len(in.data))
+			}
+
+			out, nRead, err := DecompressN(append(cmp, 0xEE), &DecompressOptions{GrowOutput: true, OutLen: 1})
+			if err != nil {
+				t.Fatalf("%s level=%d: DecompressN grow failed: %v", in.name, level, err)
+			}
+			if nRead != len(cmp) || !bytes.Equal(out, in.data) {
+				t.Fatalf("%s level=%d: grow mismatch (nRead=%d want=%d)", in.name, level, nRead, len(cmp))
+			}
+		}
+	}
+}
+
+func TestDecompressAutoMaxOut(t *testing.T) {
+	data := bytes.Repeat([]byte("bomb-guard"), 1000)
+	cmp, err := Compress(data, &CompressOptions{Level: 9})
+	if err != nil {
+		t.Fatalf("Compress failed: %v", err)
+	}
+
+	if _, err := DecompressAuto(cmp, len(data)-1); !errors.Is(err, ErrOutputOverrun) {
+		t.Fatalf("expected ErrOutputOverrun, got %v", err)
+	}
+	out, err := DecompressAuto(cmp, len(data))
+	if err != nil || !bytes.Equal(out, data) {
+		t.Fatalf("exact limit: err=%v", err)
+	}
+
+	_, err = Decompress(cmp, &DecompressOptions{GrowOutput: true, MaxOutLen: len(data) / 2})
+	if !errors.Is(err, ErrOutputOverrun) {
+		t.Fatalf("options limit: expected ErrOutputOverrun, got %v", err)
+	}
+	if _, err := DecompressAuto(cmp, -1); !errors.Is(err, ErrOptionsRequired) {
+		t.Fatalf("negative limit: expected ErrOptionsRequired, got %v", err)
+	}
+	if _, err := DecompressAuto(nil, 0); !errors.Is(err, ErrEmptyInput) {
+		t.Fatalf("empty: expected ErrEmptyInput, got %v", err)
+	}
+}
+
+func TestAppendDecompressPreservesPrefix(t *testing.T) {
+	data := bytes.Repeat([]byte("append-decompress"), 300)
+	cmp, err := Compress(data, nil)
+	if err != nil {
+		t.Fatalf("Compress failed: %v", err)
+	}
+
+	prefix := []byte("prefix:")
+	for _, spare := range []int{0, 16, len(data) + 64} {
+		dst := make([]byte, len(prefix), len(prefix)+spare)
+		copy(dst, prefix)
+
+		out, err := AppendDecompress(dst, cmp)
+		if err != nil {
+			t.Fatalf("spare=%d: AppendDecompress failed: %v", spare, err)
+		}
+		if !bytes.Equal(out[:len(prefix)], prefix) || !bytes.Equal(out[len(prefix):], data) {
+			t.Fatalf("spare=%d: output mismatch", spare)
+		}
+		if spare >= len(data) && &out[0] != &dst[0] {
+			t.Fatalf("spare=%d: expected dst capacity to be reused", spare)
+		}
+	}
+
+	// Back-references must not reach into the preserved prefix.
+	underrun := []byte{0x12, 'a', markerM3 | 1, 1 << 2, 0}
+	dst := []byte("prefix")
+	out, err := AppendDecompress(dst, underrun)
+	if !errors.Is(err, ErrLookBehindUnderrun) {
+		t.Fatalf("expected ErrLookBehindUnderrun, got %v", err)
+	}
+	if !bytes.Equal(out, dst) {
+		t.Fatal("dst must be returned unchanged on error")
+	}
+}
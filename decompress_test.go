@@ -378,6 +378,27 @@ func TestCopyBackRefMatchesBytewiseReference(t *testing.T) {
 	}
 }
 
+// checkMalformedDecode fails t unless a decoder of the LZO1X family either
+// returned output within dst or failed with one of its decoding errors and no output.
+func checkMalformedDecode(t *testing.T, dst, out []byte, err error) {
+	t.Helper()
+
+	if err != nil {
+		if out != nil {
+			t.Fatalf("error %v with output %d bytes", err, len(out))
+		}
+		for _, known := range []error{ErrEmptyInput, ErrInputOverrun, ErrOutputOverrun, ErrLookBehindUnderrun, ErrUnexpectedEOF} {
+			if errors.Is(err, known) {
+				return
+			}
+		}
+		t.Fatalf("unexpected error %v", err)
+	}
+	if len(out) > len(dst) {
+		t.Fatalf("output %d of %d bytes", len(out), len(dst))
+	}
+}
+
 func FuzzDecompressIntoMalformedInput(f *testing.F) {
 	f.Add([]byte{})
 	f.Add([]byte{markerM4 | 1, 0, 0})
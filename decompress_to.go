@@ -0,0 +1,171 @@
+// SPDX-License-Identifier: MIT
+// Copyright (c) 2026 WoozyMasta
+// Source: github.com/woozymasta/lzo
+
+package lzo
+
+import (
+	"errors"
+	"io"
+)
+
+const (
+	// ringBufferSize is the output ring used by DecompressTo.
+	// It must exceed maxOffsetM4 so every back-reference stays inside the ring.
+	ringBufferSize = 64 << 10
+
+	// readChunkSize is the compressed chunk size read by DecompressFromReaderTo.
+	readChunkSize = 32 << 10
+)
+
+// DecompressTo decompresses LZO1X data from src and writes the output to w.
+// It keeps only a 64 KiB ring of decoded history instead of the whole output,
+// so memory stays constant regardless of the decompressed size.
+// maxOut caps the decompressed size (0 = no limit) and guards against
+// decompression bombs: a stream that expands past it fails with ErrOutputOverrun
+// after the output up to the cap has been written to w.
+// Input after the stream terminator is ignored.
+// Returns the number of bytes written to w; ErrEmptyInput if src is empty.
+func DecompressTo(w io.Writer, src []byte, maxOut int) (int64, error) {
+	if maxOut < 0 {
+		return 0, ErrOptionsRequired
+	}
+	if len(src) == 0 {
+		return 0, ErrEmptyInput
+	}
+
+	d := newRingDecoder(w, maxOut)
+	if _, err := d.Write(src); err != nil && !errors.Is(err, io.ErrShortWrite) {
+		return d.flushed, err
+	}
+
+	return d.finishRing()
+}
+
+// DecompressFromReaderTo reads compressed data from r in chunks and writes the
+// decompressed output to w with the same constant memory as DecompressTo.
+// Reading stops once the terminator has been decoded; bytes of the last chunk that
+// follow it are discarded. opts may be nil; opts.OutLen is not used,
+// opts.MaxInputSize limits the compressed bytes read (ErrInputTooLarge) and
+// opts.MaxOutLen caps the decompressed size like maxOut of DecompressTo.
+func DecompressFromReaderTo(w io.Writer, r io.Reader, opts *DecompressOptions) (int64, error) {
+	maxInputSize, maxOut := 0, 0
+	if opts != nil {
+		maxInputSize, maxOut = opts.MaxInputSize, opts.MaxOutLen
+	}
+	if maxOut < 0 {
+		return 0, ErrOptionsRequired
+	}
+	if maxInputSize > 0 {
+		r = &io.LimitedReader{R: r, N: int64(maxInputSize) + 1}
+	}
+
+	d := newRingDecoder(w, maxOut)
+	buf := make([]byte, readChunkSize)
+	for !d.Done() {
+		n, err := r.Read(buf)
+		if n > 0 {
+			if _, werr := d.Write(buf[:n]); werr != nil && !errors.Is(werr, io.ErrShortWrite) {
+				return d.flushed, werr
+			}
+			if maxInputSize > 0 && d.inPos > maxInputSize {
+				return d.flushed, ErrInputTooLarge
+			}
+		}
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return d.flushed, err
+		}
+	}
+
+	return d.finishRing()
+}
+
+// newRingDecoder returns a Decoder that writes its output to w through a ring buffer.
+// maxOut caps the total output (0 = no limit).
+func newRingDecoder(w io.Writer, maxOut int) *Decoder {
+	return &Decoder{dst: make([]byte, ringBufferSize), w: w, maxOut: maxOut}
+}
+
+// finishRing writes the rest of the ring to w once the terminator has been decoded.
+func (d *Decoder) finishRing() (int64, error) {
+	if _, _, err := d.Finish(); err != nil {
+		return d.flushed, err
+	}
+
+	if err := d.flushRing(); err != nil {
+		return d.flushed, err
+	}
+
+	return d.flushed, nil
+}
+
+// copyRingMatch expands one back-reference inside the ring.
+// The copy is split wherever the source or destination wraps around the ring end.
+func (d *Decoder) copyRingMatch(dist, length int) error {
+	if int64(dist) > d.flushed+int64(d.outPos) {
+		return ErrLookBehindUnderrun
+	}
+	if d.ringOverrun(length) {
+		return ErrOutputOverrun
+	}
+
+	for length > 0 {
+		matchPos := d.outPos - dist
+		if matchPos < 0 {
+			matchPos += len(d.dst)
+		}
+
+		n := min(length, len(d.dst)-d.outPos, len(d.dst)-matchPos)
+		if matchPos < d.outPos {
+			copyBackRefUnchecked(d.dst, d.outPos, matchPos, dist, n)
+		} else {
+			// Source wrapped behind the ring end and lies ahead of the destination,
+			// so a forward copy never reads bytes written by this match.
+			copy(d.dst[d.outPos:d.outPos+n], d.dst[matchPos:matchPos+n])
+		}
+		d.outPos += n
+		length -= n
+
+		if err := d.flushFullRing(); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// ringOverrun reports whether n more bytes would take the output past maxOut.
+func (d *Decoder) ringOverrun(n int) bool {
+	return d.maxOut > 0 && d.flushed+int64(d.outPos)+int64(n) > int64(d.maxOut)
+}
+
+// flushFullRing writes the ring to w and wraps around once it is full.
+func (d *Decoder) flushFullRing() error {
+	if d.outPos < len(d.dst) {
+		return nil
+	}
+
+	if err := d.flushRing(); err != nil {
+		return err
+	}
+
+	d.outPos = 0
+	return nil
+}
+
+// flushRing writes the decoded part of the ring to w.
+func (d *Decoder) flushRing() error {
+	n, err := d.w.Write(d.dst[:d.outPos])
+	d.flushed += int64(n)
+	if err != nil {
+		return err
+	}
+	if n != d.outPos {
+		return io.ErrShortWrite
+	}
+
+	return nil
+}
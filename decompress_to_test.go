@@ -0,0 +1,167 @@
+package lzo
+
+import (
+	"bytes"
+	"errors"
+	"math/rand"
+	"testing"
+	"testing/iotest"
+)
+
+// ringTestInput returns data larger than the ring with matches spanning its wrap.
+func ringTestInput() []byte {
+	rng := rand.New(rand.NewSource(5))
+	data := make([]byte, 0, 5*ringBufferSize)
+	for len(data) < cap(data)-64 {
+		if len(data) > maxOffsetM4 && rng.Intn(3) == 0 {
+			// Copy a run from far back so M4 matches cross the ring end.
+			start := len(data) - maxOffsetM4 + rng.Intn(64)
+			data = append(data, data[start:start+32+rng.Intn(32)]...)
+			continue
+		}
+		data = append(data, byte(rng.Intn(4)), 'x', byte(rng.Intn(256)))
+	}
+
+	return data
+}
+
+func TestDecompressToMatchesDecompressInto(t *testing.T) {
+	inputs := testInputSet(t)
+	inputs = append(inputs, struct {
+		name string
+		data []byte
+	}{name: "ring-wrap", data: ringTestInput()})
+
+	for _, in := range inputs {
+		if len(in.data) == 0 {
+			continue
+		}
+		for _, level := range []int{1, 9} {
+			cmp, err := Compress(in.data, &CompressOptions{Level: level})
+			if err != nil {
+				t.Fatalf("%s level=%d: Compress failed: %v", in.name, level, err)
+			}
+
+			var out bytes.Buffer
+			n, err := DecompressTo(&out, cmp, 0)
+			if err != nil {
+				t.Fatalf("%s level=%d: DecompressTo failed: %v", in.name, level, err)
+			}
+			if n != int64(len(in.data)) || !bytes.Equal(out.Bytes(), in.data) {
+				t.Fatalf("%s level=%d: output mismatch (n=%d want=%d)", in.name, level, n, len(in.data))
+			}
+
+			out.Reset()
+			n, err = DecompressFromReaderTo(&out, iotest.HalfReader(bytes.NewReader(cmp)), nil)
+			if err != nil {
+				t.Fatalf("%s level=%d: DecompressFromReaderTo failed: %v", in.name, level, err)
+			}
+			if n != int64(len(in.data)) || !bytes.Equal(out.Bytes(), in.data) {
+				t.Fatalf("%s level=%d: reader output mismatch", in.name, level)
+			}
+		}
+	}
+}
+
+func TestDecompressToLongRunAcrossRing(t *testing.T) {
+	data := bytes.Repeat([]byte{0xAB}, 3*ringBufferSize+17)
+	cmp, err := Compress(data, &CompressOptions{Level: 9})
+	if err != nil {
+		t.Fatalf("Compress failed: %v", err)
+	}
+
+	var out bytes.Buffer
+	if _, err := DecompressTo(&out, cmp, 0); err != nil {
+		t.Fatalf("DecompressTo failed: %v", err)
+	}
+	if !bytes.Equal(out.Bytes(), data) {
+		t.Fatal("output mismatch")
+	}
+}
+
+func TestDecompressToMaxOut(t *testing.T) {
+	random := make([]byte, 3*ringBufferSize)
+	rand.New(rand.NewSource(9)).Read(random)
+	inputs := []struct {
+		name string
+		data []byte
+	}{
+		{name: "matches", data: bytes.Repeat([]byte("max-out "), 3*ringBufferSize/8)},
+		{name: "literals", data: random},
+	}
+
+	for _, in := range inputs {
+		cmp, err := Compress(in.data, nil)
+		if err != nil {
+			t.Fatalf("%s: Compress failed: %v", in.name, err)
+		}
+
+		for _, maxOut := range []int{len(in.data), len(in.data) - 1, ringBufferSize + 1, 100} {
+			var out bytes.Buffer
+			n, err := DecompressTo(&out, cmp, maxOut)
+			if maxOut >= len(in.data) {
+				if err != nil || !bytes.Equal(out.Bytes(), in.data) {
+					t.Fatalf("%s maxOut=%d: err=%v", in.name, maxOut, err)
+				}
+				continue
+			}
+			if !errors.Is(err, ErrOutputOverrun) {
+				t.Fatalf("%s maxOut=%d: expected ErrOutputOverrun, got %v", in.name, maxOut, err)
+			}
+			if n > int64(maxOut) || !bytes.Equal(out.Bytes(), in.data[:n]) {
+				t.Fatalf("%s maxOut=%d: wrote %d bytes past the cap or wrong output", in.name, maxOut, n)
+			}
+
+			out.Reset()
+			_, err = DecompressFromReaderTo(&out, bytes.NewReader(cmp), &DecompressOptions{MaxOutLen: maxOut})
+			if !errors.Is(err, ErrOutputOverrun) {
+				t.Fatalf("%s MaxOutLen=%d: expected ErrOutputOverrun, got %v", in.name, maxOut, err)
+			}
+		}
+	}
+
+	var out bytes.Buffer
+	if _, err := DecompressTo(&out, []byte{0x11, 0, 0}, -1); !errors.Is(err, ErrOptionsRequired) {
+		t.Fatalf("negative maxOut: expected ErrOptionsRequired, got %v", err)
+	}
+	if _, err := DecompressFromReaderTo(&out, bytes.NewReader(nil), &DecompressOptions{MaxOutLen: -1}); !errors.Is(err, ErrOptionsRequired) {
+		t.Fatalf("negative MaxOutLen: expected ErrOptionsRequired, got %v", err)
+	}
+}
+
+func TestDecompressToErrors(t *testing.T) {
+	data := bytes.Repeat([]byte("decompress-to"), 200)
+	cmp, err := Compress(data, &CompressOptions{Level: 5})
+	if err != nil {
+		t.Fatalf("Compress failed: %v", err)
+	}
+
+	var out bytes.Buffer
+	if _, err := DecompressTo(&out, nil, 0); !errors.Is(err, ErrEmptyInput) {
+		t.Fatalf("empty: expected ErrEmptyInput, got %v", err)
+	}
+	if _, err := DecompressTo(&out, cmp[:len(cmp)-1], 0); !errors.Is(err, ErrUnexpectedEOF) {
+		t.Fatalf("truncated: expected ErrUnexpectedEOF, got %v", err)
+	}
+	if _, err := DecompressTo(&out, []byte{0x12, 'a', markerM3 | 1, 1 << 2, 0}, 0); !errors.Is(err, ErrLookBehindUnderrun) {
+		t.Fatalf("underrun: expected ErrLookBehindUnderrun, got %v", err)
+	}
+
+	wantErr := errors.New("sink failed")
+	if _, err := DecompressTo(failingWriter{err: wantErr}, cmp, 0); !errors.Is(err, wantErr) {
+		t.Fatalf("writer error: expected %v, got %v", wantErr, err)
+	}
+
+	_, err = DecompressFromReaderTo(&out, bytes.NewReader(cmp), &DecompressOptions{MaxInputSize: len(cmp) - 1})
+	if !errors.Is(err, ErrInputTooLarge) {
+		t.Fatalf("limit: expected ErrInputTooLarge, got %v", err)
+	}
+	out.Reset()
+	_, err = DecompressFromReaderTo(&out, bytes.NewReader(cmp), &DecompressOptions{MaxInputSize: len(cmp)})
+	if err != nil || !bytes.Equal(out.Bytes(), data) {
+		t.Fatalf("exact limit: err=%v", err)
+	}
+	if _, err := DecompressFromReaderTo(&out, bytes.NewReader(nil), nil); !errors.Is(err, ErrEmptyInput) {
+		t.Fatalf("empty reader: expected ErrEmptyInput, got %v", err)
+	}
+}
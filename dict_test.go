@@ -0,0 +1,148 @@
+package lzo
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"testing"
+)
+
+// dictTestRecords returns small, similar records and a dictionary built from samples of them.
+func dictTestRecords() (dict []byte, records [][]byte) {
+	for i := range 64 {
+		record := fmt.Appendf(nil, `{"id":%d,"user":"user-%03d","status":"active","region":"eu-west-1","tags":["alpha","beta"]}`, i, i%17)
+		if i < 8 {
+			dict = append(dict, record...)
+			continue
+		}
+		records = append(records, record)
+	}
+
+	return dict, records
+}
+
+func TestCompressDictRoundTripAndRatio(t *testing.T) {
+	dict, records := dictTestRecords()
+	for _, level := range []int{0, 1, 5, 9} {
+		plainSize, dictSize := 0, 0
+		for _, record := range records {
+			plain, err := Compress(record, &CompressOptions{Level: level})
+			if err != nil {
+				t.Fatalf("level=%d: Compress failed: %v", level, err)
+			}
+			withDict, err := Compress(record, &CompressOptions{Level: level, Dict: dict})
+			if err != nil {
+				t.Fatalf("level=%d: Compress with dict failed: %v", level, err)
+			}
+			plainSize += len(plain)
+			dictSize += len(withDict)
+
+			out, err := DecompressWithDict(withDict, make([]byte, len(record)), dict)
+			if err != nil || !bytes.Equal(out, record) {
+				t.Fatalf("level=%d: DecompressWithDict failed: %v", level, err)
+			}
+			if _, err := DecompressInto(withDict, make([]byte, len(record))); !errors.Is(err, ErrLookBehindUnderrun) {
+				t.Fatalf("level=%d: expected ErrLookBehindUnderrun without dict, got %v", level, err)
+			}
+		}
+
+		if dictSize*2 > plainSize {
+			t.Fatalf("level=%d: dictionary gave too little gain: %d vs %d bytes", level, dictSize, plainSize)
+		}
+	}
+}
+
+func TestCompressDictUsesWindowTail(t *testing.T) {
+	data := ringTestInput()
+	// Only the last maxOffsetM4 bytes are reachable, so the head of a longer
+	// dictionary must not matter to either side.
+	dict := append(bytes.Repeat([]byte{0xEE}, 5000), data[len(data)-2*maxOffsetM4:]...)
+	src := data[:200<<10]
+
+	for _, level := range []int{1, 9} {
+		cmp, err := Compress(src, &CompressOptions{Level: level, Dict: dict})
+		if err != nil {
+			t.Fatalf("level=%d: Compress failed: %v", level, err)
+		}
+		tail, err := Compress(src, &CompressOptions{Level: level, Dict: dict[len(dict)-maxOffsetM4:]})
+		if err != nil {
+			t.Fatalf("level=%d: Compress with tail failed: %v", level, err)
+		}
+		if !bytes.Equal(cmp, tail) {
+			t.Fatalf("level=%d: output depends on bytes outside the window", level)
+		}
+
+		out, err := DecompressWithDict(cmp, make([]byte, len(src)), dict)
+		if err != nil || !bytes.Equal(out, src) {
+			t.Fatalf("level=%d: DecompressWithDict failed: %v", level, err)
+		}
+	}
+}
+
+func TestEncoderDictDoesNotLeakIntoLaterCalls(t *testing.T) {
+	dict, records := dictTestRecords()
+	var enc Encoder
+	for _, record := range records[:4] {
+		want, err := Compress(record, &CompressOptions{Level: 9})
+		if err != nil {
+			t.Fatalf("Compress failed: %v", err)
+		}
+		if _, err := enc.AppendCompress(nil, record, &CompressOptions{Level: 9, Dict: dict}); err != nil {
+			t.Fatalf("AppendCompress with dict failed: %v", err)
+		}
+		got, err := enc.AppendCompress(nil, record, &CompressOptions{Level: 9})
+		if err != nil {
+			t.Fatalf("AppendCompress failed: %v", err)
+		}
+		if !bytes.Equal(got, want) {
+			t.Fatal("output after a dictionary run differs from a fresh compression")
+		}
+	}
+}
+
+func TestDecompressWithDictMatchAcrossBoundary(t *testing.T) {
+	dict := []byte("abcde")
+	// Literal 'X', then an M3 match of 20 bytes at distance 5 that starts in the
+	// dictionary and continues into the output, then the terminator.
+	src := []byte{0x12, 'X', markerM3 | (20 - 2), 4 << 2, 0, markerM4 | 1, 0, 0}
+
+	history := append(append([]byte{}, dict...), 'X')
+	for range 20 {
+		history = append(history, history[len(history)-5])
+	}
+	want := history[len(dict):]
+
+	out, err := DecompressWithDict(src, make([]byte, len(want)), dict)
+	if err != nil || !bytes.Equal(out, want) {
+		t.Fatalf("got %q, %v; want %q", out, err, want)
+	}
+
+	if _, err := DecompressWithDict(src, make([]byte, len(want)), dict[2:]); !errors.Is(err, ErrLookBehindUnderrun) {
+		t.Fatalf("short dict: expected ErrLookBehindUnderrun, got %v", err)
+	}
+	if _, err := DecompressWithDict(src, make([]byte, len(want)-1), dict); !errors.Is(err, ErrOutputOverrun) {
+		t.Fatalf("short dst: expected ErrOutputOverrun, got %v", err)
+	}
+	if _, err := DecompressWithDict(nil, nil, dict); !errors.Is(err, ErrEmptyInput) {
+		t.Fatalf("empty: expected ErrEmptyInput, got %v", err)
+	}
+}
+
+func TestWriterIgnoresDict(t *testing.T) {
+	dict, records := dictTestRecords()
+	data := bytes.Join(records, nil)
+
+	var stream bytes.Buffer
+	w := NewWriter(&stream, &CompressOptions{Level: 9, Dict: dict})
+	if _, err := w.Write(data); err != nil {
+		t.Fatalf("Write failed: %v", err)
+	}
+	if err := w.Close(); err != nil {
+		t.Fatalf("Close failed: %v", err)
+	}
+
+	var out bytes.Buffer
+	if _, err := out.ReadFrom(NewReader(&stream)); err != nil || !bytes.Equal(out.Bytes(), data) {
+		t.Fatalf("Reader failed: %v", err)
+	}
+}
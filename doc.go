@@ -14,7 +14,7 @@ references. Main implementation reference: AxioDL/lzokay (MIT).
 
 # Decompress
 
-OutLen is required (use DecompressOptions). From a byte slice:
+OutLen is required (use DecompressOptions) unless the output is allowed to grow. From a byte slice:
 
 	out, err := lzo.Decompress(compressed, lzo.DefaultDecompressOptions(expectedLen))
 
@@ -37,12 +37,57 @@ From an io.Reader (e.g. stream with known decompressed size):
 Reader APIs read the complete compressed stream before decoding.
 DecompressOptions.MaxInputSize bounds the number of compressed bytes read.
 
+When compressed input arrives in chunks, Decoder decodes it incrementally and can
+pause at any byte; Finish returns the output and the input offset past the terminator:
+
+	d := lzo.NewDecoder(dst)
+	_, err := d.Write(chunk) // repeat for each chunk
+	out, nRead, err := d.Finish()
+
+To decode straight into an io.Writer with a 64 KiB history ring (constant memory,
+no OutLen needed); maxOut and MaxOutLen cap the output (0 = no limit):
+
+	n, err := lzo.DecompressTo(w, compressed, maxOut)
+	n, err := lzo.DecompressFromReaderTo(w, r, &lzo.DecompressOptions{MaxOutLen: maxOut})
+
+Without a known decompressed size, the destination can grow instead; maxOut caps
+it (0 = no limit):
+
+	out, err := lzo.DecompressAuto(compressed, maxOut)
+	out, err := lzo.AppendDecompress(dst, compressed)
+	out, err := lzo.Decompress(compressed, &lzo.DecompressOptions{GrowOutput: true, MaxOutLen: maxOut})
+
+DecodedLen computes the exact decompressed size by scanning opcodes, without
+writing output, so a destination can be sized (or a bomb rejected) up front:
+
+	outLen, nRead, err := lzo.DecodedLen(compressed)
+
+Tokens iterates over the instructions of a stream for inspection and debugging:
+
+	for tok, err := range lzo.Tokens(compressed) {
+		fmt.Println(tok.Kind, tok.InOffset, tok.MatchLen, tok.Distance, err)
+	}
+
 # Compress
 
-Options may be nil (default level 1). Level 0 or 1 = fast LZO1X-1; 2–9 = LZO1X-999:
+Options may be nil (default level 1). Level 0 or 1 = fast LZO1X-1; 2–9 = LZO1X-999.
+Optimal selects LZO1X-999 with an optimal parse, for data compressed once and decoded often:
 
 	out, err := lzo.Compress(data, nil)
 	out, err := lzo.Compress(data, &lzo.CompressOptions{Level: 9})
+	out, err := lzo.Compress(data, &lzo.CompressOptions{Level: 9, Optimal: true})
+
+Method selects an algorithm explicitly, including the LZO1X-1(11), (12) and
+(15) variants with smaller or larger hash tables:
+
+	out, err := lzo.Compress(data, &lzo.CompressOptions{Method: lzo.MethodLZO1X1_15})
+
+Exact makes the LZO1X-1 methods emit the same bytes as liblzo2's
+lzo1x_1_compress, and LZO1X-999 the same bytes as lzo1x_999_compress_level at
+the same level, for builds that compare output with the C library:
+
+	out, err := lzo.Compress(data, &lzo.CompressOptions{Exact: true})
+	out, err := lzo.Compress(data, &lzo.CompressOptions{Level: 8, Exact: true})
 
 To reuse caller-managed output memory:
 
@@ -57,5 +102,112 @@ To retain LZO1X-999 state across calls without relying on a shared pool:
 
 Each Encoder retains one LZO1X-999 dictionary. It must not be copied after
 first use or used concurrently.
+
+CompressParallel compresses one large buffer at an LZO1X-999 level on several
+goroutines and joins the segments into a single stream for Decompress:
+
+	out, err := lzo.CompressParallel(data, &lzo.CompressOptions{Level: 9}, 0)
+
+A preset dictionary primes the LZO1X-999 window; the stream must then be
+decoded with the same dictionary:
+
+	out, err := lzo.Compress(record, &lzo.CompressOptions{Level: 9, Dict: dict})
+	dec, err := lzo.DecompressWithDict(out, dst, dict)
+
+Assembler builds a stream from an explicit parse and validates each request
+against the LZO1X opcode rules:
+
+	var a lzo.Assembler
+	err := a.Literals([]byte("abcd"))
+	err = a.Match(8, 4)
+	stream, err := a.Finish()
+
+# Streams
+
+Writer compresses arbitrary writes into a block-framed stream without buffering
+the whole payload. Each block is compressed independently and prefixed with its
+uncompressed and stored sizes; Close writes the end-of-stream marker:
+
+	w := lzo.NewWriter(dst, &lzo.CompressOptions{Level: 9, BlockSize: 1 << 20})
+	_, err := io.Copy(w, src)
+	err = w.Close()
+
+Reader decodes such a stream block by block into a reused buffer, so memory
+stays at about one block (at most MaxBlockSize):
+
+	_, err := io.Copy(dst, lzo.NewReader(src))
+
+With Concurrency above 1, Writer compresses and NewReaderOptions decodes that
+many blocks in parallel while keeping stream order and the exact same bytes:
+
+	w := lzo.NewWriter(dst, &lzo.CompressOptions{Level: 9, Concurrency: 8})
+	r := lzo.NewReaderOptions(src, &lzo.DecompressOptions{Concurrency: 8})
+
+LinkedBlocks compresses each block with the last 0xbfff bytes of the
+preceding input as its dictionary, which recovers most of the ratio small
+blocks lose; the Reader needs DecompressOptions.LinkedBlocks to decode it:
+
+	w := lzo.NewWriter(dst, &lzo.CompressOptions{BlockSize: 4 << 10, LinkedBlocks: true})
+	r := lzo.NewReaderOptions(src, &lzo.DecompressOptions{LinkedBlocks: true})
+
+SeekableWriter writes independently compressed blocks followed by a block
+index, and SeekableReader uses the index to implement io.ReaderAt and
+io.ReadSeeker, decoding only the blocks a read touches:
+
+	w := lzo.NewSeekableWriter(f, &lzo.CompressOptions{BlockSize: 64 << 10})
+	_, err := io.Copy(w, src)
+	err = w.Close()
+
+	r, err := lzo.NewSeekableReader(f, size)
+	n, err := r.ReadAt(buf, off)
+
+# Frames
+
+CompressOptions.Frame wraps the block stream in a self-describing frame with
+a magic number, flags, the optional content size and optional CRC-32 or XXH64
+checksums of every block and of the content. Writer.WriteSkippableFrame
+writes user metadata frames before or after a data frame:
+
+	w := lzo.NewWriter(dst, &lzo.CompressOptions{Frame: &lzo.FrameOptions{
+		ContentSize:    int64(len(data)),
+		HasContentSize: true,
+		Checksum:       lzo.ChecksumXXH64,
+	}})
+	err := w.WriteSkippableFrame(1, meta)
+	_, err = w.Write(data)
+	err = w.Close()
+
+A Reader created with DecompressOptions.Frame verifies checksums and sizes
+and exposes the header and skippable frames:
+
+	r := lzo.NewReaderOptions(src, &lzo.DecompressOptions{Frame: true})
+	h, err := r.Header()
+	_, err = io.Copy(dst, r)
+	frames := r.SkippableFrames()
+
+# Other formats
+
+CompressLZO1Y and DecompressLZO1Y handle LZO1Y, which differs from LZO1X only
+in how M2 matches split distance and length bits:
+
+	out, err := lzo.CompressLZO1Y(data, nil)
+	dec, err := lzo.DecompressLZO1Y(out, dst)
+
+CompressLZO1Z and DecompressLZO1Z handle LZO1Z, which stores distances high
+bits first and lets M2 matches repeat the previous distance. Like liblzo2,
+only the 999 parser produces it.
+
+DecompressLZO1B, DecompressLZO1C and DecompressLZO1F read the older LZO1B,
+LZO1C and LZO1F formats. They have no compressors and return the consumed
+input size like DecompressNInto:
+
+	dec, nRead, err := lzo.DecompressLZO1B(src, dst)
+
+DecompressLZO2A decodes LZO2A the same way, and CompressLZO2A produces it with
+the 999 match finder.
+
+CompressRLE and DecompressRLE handle the Linux lzo-rle format of zram and
+zswap: LZO1X-1 behind a version header, with an instruction for zero runs.
+CompressRLE reproduces the kernel compressor byte for byte.
 */
 package lzo
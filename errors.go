@@ -17,7 +17,9 @@ var (
 	// ErrOutputOverrun is returned when the decoder would write past the output buffer.
 	ErrOutputOverrun = errors.New("output overrun")
 
-	// ErrLookBehindUnderrun is returned when a back-reference points before the start of the output.
+	// ErrLookBehindUnderrun is returned when a back-reference points before the
+	// start of the output or has no distance: an LZO1Z distance repeat before the
+	// first match or an LZO2A match at distance 0.
 	ErrLookBehindUnderrun = errors.New("lookbehind underrun")
 
 	// ErrUnexpectedEOF is returned when the stream ends before the terminator or expected size.
@@ -35,4 +37,45 @@ var (
 
 	// ErrCompressBufferTooSmall is returned when CompressInto dst is smaller than MaxCompressedSize.
 	ErrCompressBufferTooSmall = errors.New("compression output buffer too small")
+
+	// ErrUnknownMethod is returned when CompressOptions.Method is not a known Method.
+	ErrUnknownMethod = errors.New("unknown compression method")
+
+	// ErrInvalidBlock is returned when a block-framed stream has an invalid block header
+	// or a block payload that does not decode to exactly the declared size.
+	ErrInvalidBlock = errors.New("invalid block")
+
+	// ErrInvalidFrame is returned when a frame has an unknown magic number, reserved
+	// or inconsistent flags, a bad header check byte or an oversized skippable
+	// frame, when Writer without CompressOptions.Frame writes a skippable frame,
+	// and when FrameOptions has an unknown checksum or a negative size other than -1.
+	ErrInvalidFrame = errors.New("invalid frame")
+
+	// ErrChecksum is returned when a frame block or content checksum does not match.
+	ErrChecksum = errors.New("checksum mismatch")
+
+	// ErrContentSize is returned when a frame holds a different number of bytes
+	// than its header declares, or Writer was given a different number of
+	// bytes than FrameOptions.ContentSize.
+	ErrContentSize = errors.New("content size mismatch")
+
+	// ErrFrameOpen is returned when Writer is asked to write a skippable frame
+	// while a data frame is in progress.
+	ErrFrameOpen = errors.New("frame in progress")
+
+	// ErrInvalidIndex is returned when a seekable container has a missing or
+	// malformed footer or a block index that does not describe its blocks.
+	ErrInvalidIndex = errors.New("invalid seekable index")
+
+	// ErrInvalidOffset is returned when SeekableReader is asked for a negative
+	// offset or an unknown Seek whence.
+	ErrInvalidOffset = errors.New("invalid offset")
+
+	// ErrWriterClosed is returned when Writer or SeekableWriter is used after
+	// Close or Assembler is used after Finish.
+	ErrWriterClosed = errors.New("write to closed writer")
+
+	// ErrInvalidToken is returned when an Assembler request cannot be encoded
+	// as a legal LZO1X instruction in the current stream context.
+	ErrInvalidToken = errors.New("invalid token")
 )
@@ -13,19 +13,87 @@ const (
 	maxOffsetM3 = 0x4000
 	maxOffsetM4 = 0xbfff
 	maxOffsetMX = maxOffsetM1 + maxOffsetM2
+)
+
+// Match length bounds per type. maxLenM3, maxLenM4 and maxLenLiteral are also
+// the longest lengths an opcode encodes without zero extension.
+const (
+	minLenM2      = 3
+	maxLenM2      = 8
+	maxLenM3      = 33
+	maxLenM4      = 9
+	maxLenLiteral = 18
+)
+
+// LZO1Y moves one distance bit of M2 into its length field; every other
+// instruction is encoded as in LZO1X.
+const (
+	maxOffsetM2Y = 0x0400
+	maxLenM2Y    = 14
+)
+
+// LZO1Z stores distances high bits first and keeps the previous match distance:
+// M2 distance fields 0x1c–0x1f repeat it, which leaves 0x700 new M2 distances.
+const (
+	maxOffsetM2Z  = 0x0700
+	repeatFieldM2 = 0x1c
+)
 
-	// Base distance of the short-match form selected after a four-literal tail.
-	shortMatchBaseOffset = maxOffsetM2
+// LZO-RLE, the Linux lzo-rle bitstream, is LZO1X behind a two-byte header
+// (0x11 and the version). Version 1 adds a zero-run instruction that takes the
+// place of M4 matches at distance 0xbfff, so its matches stop at maxOffsetM4RLE.
+const (
+	rleHeader      = 0x11
+	rleVersion     = 1
+	maxOffsetM4RLE = 0xbffe
+	minLenZeroRun  = 4
+	maxLenZeroRun  = 2047 + minLenZeroRun
 )
 
-// Match length bounds per type.
+// format identifies a member of the LZO1X family sharing the opcode grammar.
+type format uint8
+
+// Supported stream formats.
 const (
-	minLenM2 = 3
-	maxLenM2 = 8
-	maxLenM3 = 33
-	maxLenM4 = 9
+	formatLZO1X  format = iota // formatLZO1X is LZO1X: 3 distance bits in the M2 opcode.
+	formatLZO1Y                // formatLZO1Y is LZO1Y: 2 distance bits in the M2 opcode.
+	formatLZO1Z                // formatLZO1Z is LZO1Z: big-end-first distances and distance repeats.
+	formatLZORLE               // formatLZORLE is LZO-RLE version 1: LZO1X plus zero-run instructions.
 )
 
+// maxOffsetM2 returns the farthest M2 distance of f.
+func (f format) maxOffsetM2() int {
+	switch f {
+	case formatLZO1Y:
+		return maxOffsetM2Y
+	case formatLZO1Z:
+		return maxOffsetM2Z
+	}
+	return maxOffsetM2
+}
+
+// maxLenM2 returns the longest M2 match of f.
+func (f format) maxLenM2() int {
+	if f == formatLZO1Y {
+		return maxLenM2Y
+	}
+	return maxLenM2
+}
+
+// maxOffsetM4 returns the farthest match distance of f.
+func (f format) maxOffsetM4() int {
+	if f == formatLZORLE {
+		return maxOffsetM4RLE
+	}
+	return maxOffsetM4
+}
+
+// maxOffsetMX returns the farthest distance of the 3-byte short match after a
+// four-literal tail, whose distances start right after the M2 range.
+func (f format) maxOffsetMX() int {
+	return maxOffsetM1 + f.maxOffsetM2()
+}
+
 // Instruction byte markers for match types.
 const (
 	markerM1 = 0
@@ -33,3 +101,29 @@ const (
 	markerM3 = 32
 	markerM4 = 16
 )
+
+// LZO1B and LZO1C predate LZO1X and share one marker layout: literal runs
+// below markerR0Min, M3/M4 matches below markerM2 and M2 matches above it.
+// LZO1C keeps only 6 distance bits in the first M3 distance byte and spends
+// the other two on a count of up to 3 literals after the match.
+const (
+	markerR0Min     = 32
+	r0Fast          = 280
+	minLenM3B       = 9
+	m3DistBitsLZO1B = 8
+	m3DistBitsLZO1C = 6
+)
+
+// LZO1F is the direct ancestor of LZO1X: M2 matches up to maxOffsetM2 and a
+// 3-byte short match beyond it after literals, but a single M3 form for the rest.
+const markerM3F = 224
+
+// LZO2A interleaves LSB-first flag bits with its bytes. M1 matches reach 0x100
+// bytes back with lengths 2–5; other matches carry a 13-bit distance, and a flag
+// after a zero length field moves it 0x2000 further.
+const (
+	maxOffsetM1A = 0x0100
+	maxLenM1A    = 5
+	maxOffsetM2A = 0x1fff
+	maxOffsetM3A = 0x3fff
+)
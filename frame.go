@@ -0,0 +1,261 @@
+// SPDX-License-Identifier: MIT
+// Copyright (c) 2026 WoozyMasta
+// Source: github.com/woozymasta/lzo
+
+package lzo
+
+import (
+	"encoding/binary"
+	"hash/crc32"
+)
+
+// Frame format written by Writer with CompressOptions.Frame and read by
+// Reader with DecompressOptions.Frame.
+//
+// A file is a sequence of frames. A data frame starts with frameMagic, a
+// flags byte, the little-endian uint64 content size when frameFlagSize is
+// set, and a header check byte: bits 8–15 of the XXH64 of the flags byte and
+// content size. The blocks follow in the block-stream layout; with
+// frameFlagBlockSum each payload is followed by the checksum of the stored
+// payload. The end-of-stream marker closes the frame, followed by the checksum
+// of the uncompressed content when frameFlagContentSum is set. Checksums are
+// little-endian CRC-32 (IEEE, 4 bytes) or XXH64 (seed 0, 8 bytes), as named
+// by the low two flag bits.
+//
+// A skippable frame is skippableMagic, a user-defined kind byte, the
+// little-endian uint32 data length and the data. Readers that do not know a
+// kind skip the frame.
+
+const (
+	// frameFlagChecksum masks the checksum algorithm in the frame flags.
+	frameFlagChecksum = 0x03
+
+	// frameFlagContentSum marks a content checksum after the end marker.
+	frameFlagContentSum = 0x04
+
+	// frameFlagBlockSum marks a checksum after every block payload.
+	frameFlagBlockSum = 0x08
+
+	// frameFlagSize marks the content size in the frame header.
+	frameFlagSize = 0x10
+
+	// frameFlagLinked marks a linked stream (CompressOptions.LinkedBlocks).
+	frameFlagLinked = 0x20
+
+	// frameFlagReserved masks flag bits that must be zero.
+	frameFlagReserved = 0xc0
+
+	// frameMagicLen is the length of frameMagic and skippableMagic.
+	frameMagicLen = 4
+
+	// frameHeaderMaxLen is the length of the largest data frame header.
+	frameHeaderMaxLen = frameMagicLen + 1 + 8 + 1
+
+	// skippableHeaderLen is the length of a skippable frame header.
+	skippableHeaderLen = frameMagicLen + 1 + 4
+)
+
+var (
+	// frameMagic starts every data frame.
+	frameMagic = [frameMagicLen]byte{0x89, 'L', 'Z', 'F'}
+
+	// skippableMagic starts every skippable frame.
+	skippableMagic = [frameMagicLen]byte{0x8a, 'L', 'Z', 'S'}
+)
+
+// Checksum selects the checksum algorithm of a frame.
+type Checksum uint8
+
+// Frame checksum algorithms.
+const (
+	ChecksumNone  Checksum = iota // ChecksumNone stores no checksums.
+	ChecksumCRC32                 // ChecksumCRC32 is CRC-32 (IEEE), 4 bytes.
+	ChecksumXXH64                 // ChecksumXXH64 is XXH64 with seed 0, 8 bytes.
+)
+
+// size returns the stored length of a checksum, or 0 for ChecksumNone.
+func (c Checksum) size() int {
+	switch c {
+	case ChecksumCRC32:
+		return 4
+	case ChecksumXXH64:
+		return 8
+	default:
+		return 0
+	}
+}
+
+// FrameOptions configures the frame Writer writes when CompressOptions.Frame is set.
+type FrameOptions struct {
+	// ContentSize is the total uncompressed size stored in the frame header
+	// when HasContentSize is set. Writer.Close fails with ErrContentSize when
+	// a different number of bytes was written, and negative values make
+	// Writer fail with ErrInvalidFrame.
+	ContentSize int64
+
+	// HasContentSize stores ContentSize in the frame header. Without it the
+	// size is unknown and not stored, so the zero FrameOptions stores none.
+	HasContentSize bool
+
+	// Checksum selects the algorithm of the content checksum written after the
+	// last block (ChecksumNone = no checksums). Unknown values make Writer
+	// fail with ErrInvalidFrame.
+	Checksum Checksum
+
+	// BlockChecksums adds a checksum of every stored block payload, so
+	// corruption is detected before a block is decoded. It needs Checksum.
+	BlockChecksums bool
+}
+
+// FrameHeader describes a data frame read by Reader.
+type FrameHeader struct {
+	// ContentSize is the uncompressed size of the frame, or -1 if not stored.
+	ContentSize int64
+
+	// Checksum is the checksum algorithm of the frame.
+	Checksum Checksum
+
+	// ContentChecksum reports whether the frame ends with a content checksum.
+	ContentChecksum bool
+
+	// BlockChecksums reports whether every block carries a payload checksum.
+	BlockChecksums bool
+
+	// LinkedBlocks reports whether blocks may reference earlier blocks.
+	LinkedBlocks bool
+}
+
+// SkippableFrame is a user-defined metadata frame that decoders may skip.
+type SkippableFrame struct {
+	// Kind is the user-defined frame type.
+	Kind uint8
+
+	// Data is the frame payload, at most MaxBlockSize bytes.
+	Data []byte
+}
+
+// flags returns the frame flags byte for h.
+func (h *FrameHeader) flags() byte {
+	flags := byte(h.Checksum)
+	if h.ContentChecksum {
+		flags |= frameFlagContentSum
+	}
+	if h.BlockChecksums {
+		flags |= frameFlagBlockSum
+	}
+	if h.ContentSize >= 0 {
+		flags |= frameFlagSize
+	}
+	if h.LinkedBlocks {
+		flags |= frameFlagLinked
+	}
+
+	return flags
+}
+
+// appendFrameHeader appends the data frame header of h to dst.
+func appendFrameHeader(dst []byte, h *FrameHeader) []byte {
+	dst = append(dst, frameMagic[:]...)
+	start := len(dst)
+	dst = append(dst, h.flags())
+	if h.ContentSize >= 0 {
+		dst = binary.LittleEndian.AppendUint64(dst, uint64(h.ContentSize)) //nolint:gosec // G115: ContentSize is non-negative here
+	}
+
+	return append(dst, frameHeaderCheck(dst[start:]))
+}
+
+// parseFrameFlags returns the header described by flags, with ContentSize
+// still to be filled in, and the length of the header after the flags byte.
+func parseFrameFlags(flags byte) (FrameHeader, int, error) {
+	h := FrameHeader{
+		ContentSize:     -1,
+		Checksum:        Checksum(flags & frameFlagChecksum),
+		ContentChecksum: flags&frameFlagContentSum != 0,
+		BlockChecksums:  flags&frameFlagBlockSum != 0,
+		LinkedBlocks:    flags&frameFlagLinked != 0,
+	}
+	if flags&frameFlagReserved != 0 || h.Checksum > ChecksumXXH64 ||
+		(h.Checksum == ChecksumNone) != (!h.ContentChecksum && !h.BlockChecksums) {
+		return h, 0, ErrInvalidFrame
+	}
+
+	rest := 1
+	if flags&frameFlagSize != 0 {
+		rest += 8
+	}
+	return h, rest, nil
+}
+
+// frameHeaderCheck returns the header check byte of the flags and content size in p.
+func frameHeaderCheck(p []byte) byte {
+	return byte(xxh64Sum(p) >> 8)
+}
+
+// appendSkippableFrame appends a skippable frame holding data to dst.
+func appendSkippableFrame(dst []byte, kind uint8, data []byte) []byte {
+	dst = append(dst, skippableMagic[:]...)
+	dst = append(dst, kind)
+	dst = binary.LittleEndian.AppendUint32(dst, uint32(len(data))) //nolint:gosec // G115: bounded by MaxBlockSize
+	return append(dst, data...)
+}
+
+// frameChecksum computes a content checksum incrementally.
+type frameChecksum struct {
+	kind Checksum
+	crc  uint32
+	xxh  xxh64
+}
+
+// reset restarts the checksum with algorithm kind.
+func (c *frameChecksum) reset(kind Checksum) {
+	c.kind = kind
+	c.crc = 0
+	c.xxh.reset()
+}
+
+// write adds p to the checksum.
+func (c *frameChecksum) write(p []byte) {
+	switch c.kind {
+	case ChecksumCRC32:
+		c.crc = crc32.Update(c.crc, crc32.IEEETable, p)
+	case ChecksumXXH64:
+		c.xxh.write(p)
+	}
+}
+
+// sum returns the checksum of the bytes written so far.
+func (c *frameChecksum) sum() uint64 {
+	if c.kind == ChecksumCRC32 {
+		return uint64(c.crc)
+	}
+
+	return c.xxh.sum()
+}
+
+// checksumOf returns the kind checksum of p.
+func checksumOf(kind Checksum, p []byte) uint64 {
+	if kind == ChecksumCRC32 {
+		return uint64(crc32.ChecksumIEEE(p))
+	}
+
+	return xxh64Sum(p)
+}
+
+// appendChecksum appends the stored form of the kind checksum sum to dst.
+func appendChecksum(dst []byte, kind Checksum, sum uint64) []byte {
+	if kind == ChecksumCRC32 {
+		return binary.LittleEndian.AppendUint32(dst, uint32(sum)) //nolint:gosec // G115: CRC-32 fits
+	}
+
+	return binary.LittleEndian.AppendUint64(dst, sum)
+}
+
+// loadChecksum returns the kind checksum stored in p.
+func loadChecksum(kind Checksum, p []byte) uint64 {
+	if kind == ChecksumCRC32 {
+		return uint64(binary.LittleEndian.Uint32(p))
+	}
+
+	return binary.LittleEndian.Uint64(p)
+}
@@ -0,0 +1,321 @@
+package lzo
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"hash/crc32"
+	"io"
+	"testing"
+	"testing/iotest"
+)
+
+// writeFrame compresses data into one data frame.
+func writeFrame(t testing.TB, data []byte, opts *CompressOptions) []byte {
+	t.Helper()
+
+	var out bytes.Buffer
+	w := NewWriter(&out, opts)
+	if _, err := w.Write(data); err != nil {
+		t.Fatalf("Write failed: %v", err)
+	}
+	if err := w.Close(); err != nil {
+		t.Fatalf("Close failed: %v", err)
+	}
+	return out.Bytes()
+}
+
+// newFrameReader returns a frame Reader over stream.
+func newFrameReader(stream []byte, concurrency int) *Reader {
+	return NewReaderOptions(bytes.NewReader(stream), &DecompressOptions{Frame: true, Concurrency: concurrency})
+}
+
+func TestFrameRoundTrip(t *testing.T) {
+	data := logTestInput(200 << 10)
+	data = append(data, benchmarkRandomBytes(10<<10)...)
+
+	for _, checksum := range []Checksum{ChecksumNone, ChecksumCRC32, ChecksumXXH64} {
+		for _, blockSums := range []bool{false, true} {
+			for _, linked := range []bool{false, true} {
+				name := fmt.Sprintf("checksum=%d/blocks=%v/linked=%v", checksum, blockSums, linked)
+				t.Run(name, func(t *testing.T) {
+					frame := &FrameOptions{ContentSize: int64(len(data)), HasContentSize: true, Checksum: checksum, BlockChecksums: blockSums}
+					opts := &CompressOptions{Level: 5, BlockSize: 8 << 10, LinkedBlocks: linked, Frame: frame}
+					stream := writeFrame(t, data, opts)
+
+					opts.Concurrency = 3
+					if got := writeFrame(t, data, opts); !bytes.Equal(got, stream) {
+						t.Fatal("concurrent frame differs from sequential")
+					}
+
+					for _, concurrency := range []int{0, 4} {
+						r := newFrameReader(stream, concurrency)
+						h, err := r.Header()
+						if err != nil {
+							t.Fatalf("Header failed: %v", err)
+						}
+						want := FrameHeader{
+							ContentSize:     int64(len(data)),
+							Checksum:        checksum,
+							ContentChecksum: checksum != ChecksumNone,
+							BlockChecksums:  blockSums && checksum != ChecksumNone,
+							LinkedBlocks:    linked,
+						}
+						if h != want {
+							t.Fatalf("Header = %+v, want %+v", h, want)
+						}
+						if err := iotest.TestReader(r, data); err != nil {
+							t.Fatalf("concurrency=%d: %v", concurrency, err)
+						}
+					}
+				})
+			}
+		}
+	}
+}
+
+func TestFrameHeaderLayout(t *testing.T) {
+	opts := &CompressOptions{Frame: &FrameOptions{ContentSize: 5, HasContentSize: true, Checksum: ChecksumCRC32}}
+	stream := writeFrame(t, []byte("hello"), opts)
+
+	want := []byte{0x89, 'L', 'Z', 'F', 0x15, 5, 0, 0, 0, 0, 0, 0, 0}
+	want = append(want, byte(xxh64Sum(want[4:])>>8))
+	want = binary.LittleEndian.AppendUint32(want, 5)
+	want = binary.LittleEndian.AppendUint32(want, 5)
+	want = append(want, "hello"...)
+	want = binary.LittleEndian.AppendUint32(want, 0)
+	want = binary.LittleEndian.AppendUint32(want, 0x3610a686) // CRC-32 of "hello"
+	if !bytes.Equal(stream, want) {
+		t.Fatalf("frame = % x\nwant    % x", stream, want)
+	}
+
+	// Without a size or checksums a frame is the bare block stream with a
+	// minimal header.
+	stream = writeFrame(t, nil, &CompressOptions{Frame: &FrameOptions{}})
+	if want := []byte{0x89, 'L', 'Z', 'F', 0, byte(xxh64Sum([]byte{0}) >> 8), 0, 0, 0, 0}; !bytes.Equal(stream, want) {
+		t.Fatalf("empty frame = % x, want % x", stream, want)
+	}
+	r := newFrameReader(stream, 0)
+	if h, err := r.Header(); err != nil || h.ContentSize != -1 {
+		t.Fatalf("Header = %+v, %v; want unknown content size", h, err)
+	}
+	if out, err := io.ReadAll(r); err != nil || len(out) != 0 {
+		t.Fatalf("ReadAll = %q, %v", out, err)
+	}
+
+	// A content size of 0 is stored like any other.
+	stream = writeFrame(t, nil, &CompressOptions{Frame: &FrameOptions{HasContentSize: true}})
+	want = []byte{0x89, 'L', 'Z', 'F', frameFlagSize, 0, 0, 0, 0, 0, 0, 0, 0}
+	want = append(want, byte(xxh64Sum(want[4:])>>8))
+	if want = binary.LittleEndian.AppendUint32(want, 0); !bytes.Equal(stream, want) {
+		t.Fatalf("empty sized frame = % x, want % x", stream, want)
+	}
+	if h, err := newFrameReader(stream, 0).Header(); err != nil || h.ContentSize != 0 {
+		t.Fatalf("Header = %+v, %v; want content size 0", h, err)
+	}
+}
+
+func TestFrameSkippableFrames(t *testing.T) {
+	first := []byte("first frame data")
+	second := bytes.Repeat([]byte("second frame data "), 100)
+	opts := &CompressOptions{BlockSize: 256, Frame: &FrameOptions{Checksum: ChecksumXXH64}}
+
+	var stream bytes.Buffer
+	w := NewWriter(&stream, opts)
+	if err := w.WriteSkippableFrame(1, []byte(`{"name":"first"}`)); err != nil {
+		t.Fatalf("WriteSkippableFrame before data failed: %v", err)
+	}
+	if _, err := w.Write(first); err != nil {
+		t.Fatalf("Write failed: %v", err)
+	}
+	if err := w.WriteSkippableFrame(2, nil); !errors.Is(err, ErrFrameOpen) {
+		t.Fatalf("WriteSkippableFrame inside frame error = %v, want ErrFrameOpen", err)
+	}
+	if err := w.Close(); err != nil {
+		t.Fatalf("Close failed: %v", err)
+	}
+	if err := w.WriteSkippableFrame(2, nil); err != nil {
+		t.Fatalf("WriteSkippableFrame after Close failed: %v", err)
+	}
+	if err := w.WriteSkippableFrame(3, make([]byte, MaxBlockSize+1)); !errors.Is(err, ErrInvalidFrame) {
+		t.Fatalf("oversized skippable frame error = %v, want ErrInvalidFrame", err)
+	}
+
+	// Reset on the same destination appends a second data frame.
+	w.Reset(&stream)
+	if _, err := w.Write(second); err != nil {
+		t.Fatalf("second Write failed: %v", err)
+	}
+	if err := w.Close(); err != nil {
+		t.Fatalf("second Close failed: %v", err)
+	}
+	if err := w.WriteSkippableFrame(4, []byte("trailer")); err != nil {
+		t.Fatalf("trailing WriteSkippableFrame failed: %v", err)
+	}
+
+	r := newFrameReader(stream.Bytes(), 2)
+	if _, err := r.Header(); err != nil {
+		t.Fatalf("Header failed: %v", err)
+	}
+	if got := r.SkippableFrames(); len(got) != 1 || got[0].Kind != 1 || string(got[0].Data) != `{"name":"first"}` {
+		t.Fatalf("skippable frames before data = %+v", got)
+	}
+
+	out, err := io.ReadAll(r)
+	if err != nil {
+		t.Fatalf("ReadAll failed: %v", err)
+	}
+	if want := append(bytes.Clone(first), second...); !bytes.Equal(out, want) {
+		t.Fatalf("decoded %d bytes, want %d", len(out), len(want))
+	}
+
+	got := r.SkippableFrames()
+	if len(got) != 3 || got[1].Kind != 2 || len(got[1].Data) != 0 || got[2].Kind != 4 || string(got[2].Data) != "trailer" {
+		t.Fatalf("skippable frames = %+v", got)
+	}
+	if _, err := r.Header(); err != io.EOF {
+		t.Fatalf("Header at end error = %v, want io.EOF", err)
+	}
+
+	// A stream of metadata only has no data to read.
+	var meta bytes.Buffer
+	w.Reset(&meta)
+	if err := w.WriteSkippableFrame(9, []byte("only metadata")); err != nil {
+		t.Fatalf("WriteSkippableFrame failed: %v", err)
+	}
+	r = newFrameReader(meta.Bytes(), 0)
+	if out, err := io.ReadAll(r); err != nil || len(out) != 0 || len(r.SkippableFrames()) != 1 {
+		t.Fatalf("metadata-only stream: %q, %v, %d frames", out, err, len(r.SkippableFrames()))
+	}
+}
+
+func TestFrameWriterErrors(t *testing.T) {
+	var out bytes.Buffer
+	w := NewWriter(&out, nil)
+	if err := w.WriteSkippableFrame(0, nil); !errors.Is(err, ErrInvalidFrame) {
+		t.Fatalf("unframed WriteSkippableFrame error = %v, want ErrInvalidFrame", err)
+	}
+
+	w = NewWriter(&out, &CompressOptions{Frame: &FrameOptions{ContentSize: 10, HasContentSize: true}})
+	if _, err := w.Write([]byte("short")); err != nil {
+		t.Fatalf("Write failed: %v", err)
+	}
+	if err := w.Close(); !errors.Is(err, ErrContentSize) {
+		t.Fatalf("Close error = %v, want ErrContentSize", err)
+	}
+
+	w = NewWriter(&out, &CompressOptions{Frame: &FrameOptions{Checksum: 7}})
+	if _, err := w.Write([]byte("data")); !errors.Is(err, ErrInvalidFrame) {
+		t.Fatalf("unknown checksum error = %v, want ErrInvalidFrame", err)
+	}
+
+	w = NewWriter(&out, &CompressOptions{Frame: &FrameOptions{ContentSize: -2, HasContentSize: true}})
+	if err := w.Close(); !errors.Is(err, ErrInvalidFrame) {
+		t.Fatalf("negative content size error = %v, want ErrInvalidFrame", err)
+	}
+
+	w = NewWriter(&out, &CompressOptions{Frame: &FrameOptions{HasContentSize: true}})
+	if _, err := w.Write([]byte("data")); err != nil {
+		t.Fatalf("Write failed: %v", err)
+	}
+	if err := w.Close(); !errors.Is(err, ErrContentSize) {
+		t.Fatalf("Close error = %v, want ErrContentSize for content size 0", err)
+	}
+
+	// A size mismatch still ends the frame, and readers reject it.
+	out.Reset()
+	w = NewWriter(&out, &CompressOptions{Frame: &FrameOptions{ContentSize: 10, HasContentSize: true, Checksum: ChecksumCRC32}})
+	if _, err := w.Write([]byte("short")); err != nil {
+		t.Fatalf("Write failed: %v", err)
+	}
+	if err := w.Close(); !errors.Is(err, ErrContentSize) {
+		t.Fatalf("Close error = %v, want ErrContentSize", err)
+	}
+	if err := w.Close(); !errors.Is(err, ErrContentSize) {
+		t.Fatalf("second Close error = %v, want ErrContentSize", err)
+	}
+	if !bytes.HasSuffix(out.Bytes(), binary.LittleEndian.AppendUint32([]byte{0, 0, 0, 0}, crc32.ChecksumIEEE([]byte("short")))) {
+		t.Fatalf("frame % x does not end with the end marker and content checksum", out.Bytes())
+	}
+	if _, err := io.ReadAll(newFrameReader(out.Bytes(), 0)); !errors.Is(err, ErrContentSize) {
+		t.Fatalf("ReadAll error = %v, want ErrContentSize", err)
+	}
+}
+
+func TestFrameReaderRejectsCorruption(t *testing.T) {
+	data := bytes.Repeat([]byte("frame corruption test "), 200)
+	opts := &CompressOptions{BlockSize: 1024, Frame: &FrameOptions{ContentSize: int64(len(data)), HasContentSize: true, Checksum: ChecksumCRC32, BlockChecksums: true}}
+	stream := writeFrame(t, data, opts)
+	unchecked := writeFrame(t, data, &CompressOptions{BlockSize: 1024, Frame: &FrameOptions{Checksum: ChecksumXXH64}})
+	headerLen := frameHeaderMaxLen
+
+	corrupt := func(src []byte, edit func(b []byte)) []byte {
+		b := bytes.Clone(src)
+		edit(b)
+		return b
+	}
+	// withHeader rewrites the flags and size and recomputes the header check.
+	withHeader := func(flags byte, size uint64) []byte {
+		return corrupt(stream, func(b []byte) {
+			b[4] = flags
+			binary.LittleEndian.PutUint64(b[5:], size)
+			b[13] = frameHeaderCheck(b[4:13])
+		})
+	}
+
+	cases := []struct {
+		name   string
+		stream []byte
+		want   error
+	}{
+		{name: "empty", stream: nil, want: ErrUnexpectedEOF},
+		{name: "magic", stream: corrupt(stream, func(b []byte) { b[3] = 'X' }), want: ErrInvalidFrame},
+		{name: "header-check", stream: corrupt(stream, func(b []byte) { b[13]++ }), want: ErrInvalidFrame},
+		{name: "reserved-flag", stream: withHeader(stream[4]|0x40, uint64(len(data))), want: ErrInvalidFrame},
+		{name: "checksum-kind", stream: withHeader(stream[4]|frameFlagChecksum, uint64(len(data))), want: ErrInvalidFrame},
+		{name: "checksum-without-kind", stream: withHeader(stream[4]&^frameFlagChecksum, uint64(len(data))), want: ErrInvalidFrame},
+		{name: "content-size", stream: withHeader(stream[4], uint64(len(data))+1), want: ErrContentSize},
+		{name: "block-checksum", stream: corrupt(stream, func(b []byte) { b[headerLen+blockHeaderLen] ^= 1 }), want: ErrChecksum},
+		{name: "content-checksum", stream: corrupt(unchecked, func(b []byte) { b[len(b)-1] ^= 1 }), want: ErrChecksum},
+		{name: "truncated", stream: stream[:len(stream)-2], want: ErrUnexpectedEOF},
+		{name: "trailing-garbage", stream: append(bytes.Clone(stream), 0), want: ErrUnexpectedEOF},
+		{name: "oversized-skippable", stream: binary.LittleEndian.AppendUint32(append(skippableMagic[:], 0), MaxBlockSize+1), want: ErrInvalidFrame},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			for _, concurrency := range []int{0, 3} {
+				_, err := io.ReadAll(newFrameReader(tc.stream, concurrency))
+				if !errors.Is(err, tc.want) {
+					t.Fatalf("concurrency=%d: error = %v, want %v", concurrency, err, tc.want)
+				}
+			}
+		})
+	}
+
+	if _, err := NewReader(bytes.NewReader(stream)).Header(); !errors.Is(err, ErrInvalidFrame) {
+		t.Fatalf("unframed Header error = %v, want ErrInvalidFrame", err)
+	}
+}
+
+func FuzzFrameReader(f *testing.F) {
+	opts := &CompressOptions{BlockSize: 512, Frame: &FrameOptions{ContentSize: 2800, HasContentSize: true, Checksum: ChecksumXXH64, BlockChecksums: true}}
+	f.Add(writeFrame(f, bytes.Repeat([]byte("fuzz frame "), 255)[:2800], opts))
+	f.Add(writeFrame(f, []byte("x"), &CompressOptions{Frame: &FrameOptions{}}))
+	f.Add(appendSkippableFrame(nil, 1, []byte("metadata")))
+
+	f.Fuzz(func(t *testing.T, stream []byte) {
+		r := newFrameReader(stream, 0)
+		out, err := io.ReadAll(r)
+		if err != nil {
+			return
+		}
+
+		// Every accepted stream re-encodes to one that decodes identically.
+		again, err := io.ReadAll(newFrameReader(writeFrame(t, out, &CompressOptions{Frame: &FrameOptions{Checksum: ChecksumCRC32}}), 0))
+		if err != nil || !bytes.Equal(again, out) {
+			t.Fatalf("re-encoded frame failed: %v", err)
+		}
+	})
+}
@@ -0,0 +1,139 @@
+// SPDX-License-Identifier: MIT
+// Copyright (c) 2026 WoozyMasta
+// Source: github.com/woozymasta/lzo
+
+package lzo
+
+// DecompressLZO1B decompresses an LZO1B stream from src into caller-provided dst
+// (lzo1b_decompress_safe). Like DecompressNInto it returns dst[:n], the number of
+// input bytes consumed up to and including the end-of-stream marker, and an error;
+// nRead is 0 on error. Input and output are bounds-checked and back-references
+// before dst[0] return ErrLookBehindUnderrun. LZO1B is supported for reading only.
+func DecompressLZO1B(src, dst []byte) ([]byte, int, error) {
+	return decompressLZO1BC(src, dst, m3DistBitsLZO1B)
+}
+
+// DecompressLZO1C decompresses an LZO1C stream from src into caller-provided dst
+// (lzo1c_decompress_safe). Return values and errors are the same as for
+// DecompressLZO1B; LZO1C differs from it only in how M3/M4 distances are stored.
+// LZO1C is supported for reading only.
+func DecompressLZO1C(src, dst []byte) ([]byte, int, error) {
+	return decompressLZO1BC(src, dst, m3DistBitsLZO1C)
+}
+
+// decompressLZO1BC decodes the marker grammar shared by LZO1B and LZO1C.
+// m3DistBits is the number of distance bits in the first M3/M4 distance byte;
+// any bits above them count literals that follow the match.
+func decompressLZO1BC(src, dst []byte, m3DistBits uint) ([]byte, int, error) {
+	if len(src) == 0 {
+		return nil, 0, ErrEmptyInput
+	}
+
+	var (
+		inPos  int
+		outPos int
+		// afterLiteral is set after literals, where markers below markerR0Min
+		// are R1 matches (a 3-byte M2 followed by one literal) instead of runs.
+		afterLiteral bool
+	)
+
+	for {
+		if inPos >= len(src) {
+			return nil, 0, ErrUnexpectedEOF
+		}
+		inst := src[inPos]
+		inPos++
+
+		switch {
+		case inst < markerR0Min && afterLiteral:
+			b, err := readCompressedByte(src, &inPos)
+			if err != nil {
+				return nil, 0, err
+			}
+			if err := copyCheckedBackRef(dst, &outPos, (int(inst)|int(b)<<5)+1, minLenM2); err != nil {
+				return nil, 0, err
+			}
+			if err := copyLiteralRun(src, &inPos, dst, &outPos, 1); err != nil {
+				return nil, 0, err
+			}
+
+		case inst < markerR0Min:
+			runLen := int(inst)
+			afterLiteral = true
+			if runLen == 0 {
+				// R0 run: 32–279 literals, or a long run of r0Fast or 512<<n bytes
+				// that may be followed by another literal run.
+				b, err := readCompressedByte(src, &inPos)
+				if err != nil {
+					return nil, 0, err
+				}
+				switch {
+				case int(b) < r0Fast-markerR0Min:
+					runLen = int(b) + markerR0Min
+				case int(b) == r0Fast-markerR0Min:
+					runLen, afterLiteral = r0Fast, false
+				default:
+					runLen, afterLiteral = 256<<(int(b)-(r0Fast-markerR0Min)), false
+				}
+			}
+			if err := copyLiteralRun(src, &inPos, dst, &outPos, runLen); err != nil {
+				return nil, 0, err
+			}
+
+		case inst >= markerM2:
+			b, err := readCompressedByte(src, &inPos)
+			if err != nil {
+				return nil, 0, err
+			}
+			if err := copyCheckedBackRef(dst, &outPos, (int(inst&0x1f)|int(b)<<5)+1, int(inst>>5)+1); err != nil {
+				return nil, 0, err
+			}
+			afterLiteral = false
+
+		default:
+			matchLen := int(inst&0x1f) + minLenM3B - 1
+			if matchLen == minLenM3B-1 {
+				ext, err := readZeroExtendedLength(src, &inPos)
+				if err != nil {
+					return nil, 0, err
+				}
+
+				matchLen += ext + 31
+			}
+
+			v16, err := readCompressedLE16(src, &inPos)
+			if err != nil {
+				return nil, 0, err
+			}
+			lo := int(v16 & 0xff)
+			dist := lo&(1<<m3DistBits-1) | int(v16>>8)<<m3DistBits
+			if dist == 0 {
+				return dst[:outPos], inPos, nil
+			}
+			if err := copyCheckedBackRef(dst, &outPos, dist, matchLen); err != nil {
+				return nil, 0, err
+			}
+
+			litLen := lo >> m3DistBits
+			if err := copyLiteralRun(src, &inPos, dst, &outPos, litLen); err != nil {
+				return nil, 0, err
+			}
+			afterLiteral = litLen > 0
+		}
+	}
+}
+
+// copyCheckedBackRef validates a back-reference against dst and expands it at *outPos.
+func copyCheckedBackRef(dst []byte, outPos *int, dist, length int) error {
+	if dist > *outPos {
+		return ErrLookBehindUnderrun
+	}
+	if *outPos+length > len(dst) {
+		return ErrOutputOverrun
+	}
+
+	copyBackRefUnchecked(dst, *outPos, *outPos-dist, dist, length)
+	*outPos += length
+
+	return nil
+}
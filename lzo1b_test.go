@@ -0,0 +1,160 @@
+package lzo
+
+import (
+	"bytes"
+	"errors"
+	"math/rand"
+	"testing"
+)
+
+// legacyStream builds a hand-assembled stream next to the output it decodes to.
+type legacyStream struct {
+	src, want []byte
+}
+
+// code appends instruction bytes.
+func (s *legacyStream) code(b ...byte) { s.src = append(s.src, b...) }
+
+// lit appends literal bytes to both the stream and the output.
+func (s *legacyStream) lit(b []byte) {
+	s.src = append(s.src, b...)
+	s.want = append(s.want, b...)
+}
+
+// match expands a back-reference in the expected output.
+func (s *legacyStream) match(dist, length int) {
+	for range length {
+		s.want = append(s.want, s.want[len(s.want)-dist])
+	}
+}
+
+// lzo1bVector uses every LZO1B/LZO1C instruction form; m3DistBits selects the
+// M3 distance layout, and with LZO1C's layout an M3 match carries two literals.
+func lzo1bVector(m3DistBits uint) legacyStream {
+	rng := rand.New(rand.NewSource(14))
+	random := func(n int) []byte {
+		b := make([]byte, n)
+		rng.Read(b)
+		return b
+	}
+	m3Dist := func(dist, lits int) (byte, byte) {
+		return byte(dist&(1<<m3DistBits-1) | lits<<m3DistBits), byte(dist >> m3DistBits)
+	}
+
+	var s legacyStream
+	s.code(5) // short literal run
+	s.lit([]byte("abcde"))
+	s.code(2, 0) // R1: 3 bytes at distance 3, then one literal
+	s.match(3, 3)
+	s.lit([]byte("f"))
+	s.code(4<<5|7, 0) // M2 of 5 at distance 8
+	s.match(8, 5)
+	s.code(0, 40-markerR0Min) // R0 run of 40
+	s.lit(random(40))
+	s.code(2<<5|29, 0) // M2 of 3 at distance 30
+	s.match(30, 3)
+	s.code(0, r0Fast-markerR0Min) // long R0 run of 280
+	s.lit(random(r0Fast))
+	s.code(0, r0Fast-markerR0Min+2) // long R0 run of 1024
+	s.lit(random(1024))
+	s.code(3)
+	s.lit([]byte("xyz"))
+	s.code(2<<5|(299&0x1f), 299>>5) // M2 of 3 at distance 300
+	s.match(300, 3)
+
+	lo, hi := m3Dist(10, 0)
+	s.code(markerR0Min|(12-minLenM3B+1), lo, hi) // M3 of 12 at distance 10
+	s.match(10, 12)
+	if m3DistBits < 8 {
+		lo, hi = m3Dist(1000, 2)
+		s.code(markerR0Min|(20-minLenM3B+1), lo, hi) // M3 of 20 at distance 1000, then 2 literals
+		s.match(1000, 20)
+		s.lit([]byte("pq"))
+		s.code(1, 0) // R1 after the M3 literals
+		s.match(2, 3)
+		s.lit([]byte("r"))
+	}
+
+	lo, hi = m3Dist(1300, 0)
+	s.code(markerR0Min, 0, 300-(minLenM3B+30)-255, lo, hi) // M4 of 300 at distance 1300
+	s.match(1300, 300)
+	s.code(markerR0Min|1, 0, 0)
+
+	return s
+}
+
+func TestDecompressLZO1BVector(t *testing.T) {
+	for _, tc := range []struct {
+		name       string
+		decompress func(src, dst []byte) ([]byte, int, error)
+		m3DistBits uint
+	}{
+		{name: "lzo1b", decompress: DecompressLZO1B, m3DistBits: m3DistBitsLZO1B},
+		{name: "lzo1c", decompress: DecompressLZO1C, m3DistBits: m3DistBitsLZO1C},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			s := lzo1bVector(tc.m3DistBits)
+			src := append(append([]byte{}, s.src...), 0xAA, 0xBB)
+
+			out, nRead, err := tc.decompress(src, make([]byte, len(s.want)))
+			if err != nil || !bytes.Equal(out, s.want) {
+				t.Fatalf("decompress failed: %v (mismatch at %d)", err, firstMismatchBytes(out, s.want))
+			}
+			if nRead != len(s.src) {
+				t.Fatalf("nRead=%d, want %d", nRead, len(s.src))
+			}
+
+			if _, _, err := tc.decompress(s.src, make([]byte, len(s.want)-1)); !errors.Is(err, ErrOutputOverrun) {
+				t.Fatalf("short dst: expected ErrOutputOverrun, got %v", err)
+			}
+			if _, _, err := tc.decompress(s.src[:len(s.src)-3], make([]byte, len(s.want))); !errors.Is(err, ErrUnexpectedEOF) {
+				t.Fatalf("no terminator: expected ErrUnexpectedEOF, got %v", err)
+			}
+			if _, _, err := tc.decompress(s.src[:100], make([]byte, len(s.want))); !errors.Is(err, ErrInputOverrun) {
+				t.Fatalf("truncated run: expected ErrInputOverrun, got %v", err)
+			}
+			if _, _, err := tc.decompress(nil, nil); !errors.Is(err, ErrEmptyInput) {
+				t.Fatalf("empty: expected ErrEmptyInput, got %v", err)
+			}
+		})
+	}
+}
+
+func TestDecompressLZO1BLookBehind(t *testing.T) {
+	// Two literals, then an M2 match at distance 3.
+	src := []byte{2, 'a', 'b', 2<<5 | 2, 0, markerR0Min | 1, 0, 0}
+	if _, _, err := DecompressLZO1B(src, make([]byte, 16)); !errors.Is(err, ErrLookBehindUnderrun) {
+		t.Fatalf("expected ErrLookBehindUnderrun, got %v", err)
+	}
+
+	// The same bytes mean an R1 match at distance 3 after the literals.
+	src[3] = 2
+	if _, _, err := DecompressLZO1C(src, make([]byte, 16)); !errors.Is(err, ErrLookBehindUnderrun) {
+		t.Fatalf("R1: expected ErrLookBehindUnderrun, got %v", err)
+	}
+}
+
+func FuzzDecompressLegacyMalformedInput(f *testing.F) {
+	f.Add(lzo1bVector(m3DistBitsLZO1B).src)
+	f.Add(lzo1bVector(m3DistBitsLZO1C).src)
+	f.Add(lzo1fVector().src)
+	f.Add([]byte{0, 0xff})
+	f.Add([]byte{markerM3F, 0, 0, 0, 0})
+
+	decoders := []func(src, dst []byte) ([]byte, int, error){DecompressLZO1B, DecompressLZO1C, DecompressLZO1F}
+	f.Fuzz(func(t *testing.T, src []byte) {
+		for _, decompress := range decoders {
+			dst := make([]byte, 4096)
+			out, nRead, err := decompress(src, dst)
+			if err != nil {
+				if out != nil || nRead != 0 {
+					t.Fatalf("error %v with output %d bytes and nRead=%d", err, len(out), nRead)
+				}
+				continue
+			}
+			if nRead > len(src) || len(out) > len(dst) {
+				t.Fatalf("nRead=%d of %d, output %d of %d bytes", nRead, len(src), len(out), len(dst))
+			}
+		}
+	})
+}
@@ -0,0 +1,97 @@
+// SPDX-License-Identifier: MIT
+// Copyright (c) 2026 WoozyMasta
+// Source: github.com/woozymasta/lzo
+
+package lzo
+
+// DecompressLZO1F decompresses an LZO1F stream from src into caller-provided dst
+// (lzo1f_decompress_safe). Return values and errors are the same as for
+// DecompressLZO1B. LZO1F is the predecessor of LZO1X, but its M3 marker range and
+// literal encoding differ, so its streams cannot be decoded as LZO1X.
+func DecompressLZO1F(src, dst []byte) ([]byte, int, error) {
+	if len(src) == 0 {
+		return nil, 0, ErrEmptyInput
+	}
+
+	var (
+		inPos  int
+		outPos int
+		// afterLiteral is set after literals, where opcodes below markerM3 are
+		// 3-byte short matches beyond maxOffsetM2 instead of literal runs.
+		afterLiteral bool
+	)
+
+	for {
+		if inPos >= len(src) {
+			return nil, 0, ErrUnexpectedEOF
+		}
+		inst := src[inPos]
+		inPos++
+
+		var matchLen, dist, litLen int
+		switch {
+		case inst < markerM3 && !afterLiteral:
+			runLen := int(inst)
+			if runLen == 0 {
+				ext, err := readZeroExtendedLength(src, &inPos)
+				if err != nil {
+					return nil, 0, err
+				}
+
+				runLen = ext + 31
+			}
+			if err := copyLiteralRun(src, &inPos, dst, &outPos, runLen); err != nil {
+				return nil, 0, err
+			}
+			afterLiteral = true
+			continue
+
+		case inst < markerM3:
+			b, err := readCompressedByte(src, &inPos)
+			if err != nil {
+				return nil, 0, err
+			}
+			matchLen = 3
+			dist = maxOffsetM2 + 1 + int(inst>>2&0x7) + int(b)<<3
+			litLen = int(inst & 0x03)
+
+		case inst < markerM3F:
+			b, err := readCompressedByte(src, &inPos)
+			if err != nil {
+				return nil, 0, err
+			}
+			matchLen = int(inst>>5) + 2
+			dist = 1 + int(inst>>2&0x7) + int(b)<<3
+			litLen = int(inst & 0x03)
+
+		default:
+			matchLen = int(inst&0x1f) + 2
+			if matchLen == 2 {
+				ext, err := readZeroExtendedLength(src, &inPos)
+				if err != nil {
+					return nil, 0, err
+				}
+
+				matchLen += ext + 31
+			}
+
+			v16, err := readCompressedLE16(src, &inPos)
+			if err != nil {
+				return nil, 0, err
+			}
+			dist = int(v16 >> 2)
+			if dist == 0 {
+				return dst[:outPos], inPos, nil
+			}
+			litLen = int(v16 & 0x03)
+		}
+
+		if err := copyCheckedBackRef(dst, &outPos, dist, matchLen); err != nil {
+			return nil, 0, err
+		}
+		if err := copyLiteralRun(src, &inPos, dst, &outPos, litLen); err != nil {
+			return nil, 0, err
+		}
+		afterLiteral = litLen > 0
+	}
+}
@@ -0,0 +1,78 @@
+package lzo
+
+import (
+	"bytes"
+	"errors"
+	"math/rand"
+	"testing"
+)
+
+// lzo1fVector uses every LZO1F instruction form.
+func lzo1fVector() legacyStream {
+	lit := make([]byte, 31+9*255+10)
+	rand.New(rand.NewSource(15)).Read(lit)
+
+	var s legacyStream
+	s.code(0)
+	s.code(make([]byte, 9)...)
+	s.code(10) // literal run of 31+9*255+10
+	s.lit(lit)
+	s.code(5<<2|1, 9) // short match of 3 at distance 0x801+0x4d, then one literal
+	s.match(maxOffsetM2+1+0x4d, 3)
+	s.lit([]byte("a"))
+	s.code(6<<5|3<<2|2, 2) // M2 of 8 at distance 20, then two literals
+	s.match(20, 8)
+	s.lit([]byte("bc"))
+	s.code(0<<2|0, 0) // short match of 3 at distance 0x801
+	s.match(maxOffsetM2+1, 3)
+	s.code(10) // literal run of 10
+	s.lit([]byte("0123456789"))
+	s.code(markerM3F|(20-2), 700<<2&0xff|3, 700>>6) // M3 of 20 at distance 700, then three literals
+	s.match(700, 20)
+	s.lit([]byte("def"))
+	s.code(markerM3F, 100-33, 2000<<2&0xff, 2000>>6) // M3 of 100 at distance 2000
+	s.match(2000, 100)
+	s.code(1<<5|1<<2, 0) // M2 of 3 at distance 2
+	s.match(2, 3)
+	s.code(markerM3F|1, 0, 0)
+
+	return s
+}
+
+func TestDecompressLZO1FVector(t *testing.T) {
+	s := lzo1fVector()
+	src := append(append([]byte{}, s.src...), 0xAA)
+
+	out, nRead, err := DecompressLZO1F(src, make([]byte, len(s.want)))
+	if err != nil || !bytes.Equal(out, s.want) {
+		t.Fatalf("DecompressLZO1F failed: %v (mismatch at %d)", err, firstMismatchBytes(out, s.want))
+	}
+	if nRead != len(s.src) {
+		t.Fatalf("nRead=%d, want %d", nRead, len(s.src))
+	}
+
+	if _, _, err := DecompressLZO1F(s.src, make([]byte, len(s.want)-1)); !errors.Is(err, ErrOutputOverrun) {
+		t.Fatalf("short dst: expected ErrOutputOverrun, got %v", err)
+	}
+	if _, _, err := DecompressLZO1F(s.src[:len(s.src)-3], make([]byte, len(s.want))); !errors.Is(err, ErrUnexpectedEOF) {
+		t.Fatalf("no terminator: expected ErrUnexpectedEOF, got %v", err)
+	}
+	if _, _, err := DecompressLZO1F(s.src[:100], make([]byte, len(s.want))); !errors.Is(err, ErrInputOverrun) {
+		t.Fatalf("truncated run: expected ErrInputOverrun, got %v", err)
+	}
+	if _, _, err := DecompressLZO1F(nil, nil); !errors.Is(err, ErrEmptyInput) {
+		t.Fatalf("empty: expected ErrEmptyInput, got %v", err)
+	}
+	if out, _, err := DecompressNInto(s.src, make([]byte, len(s.want))); err == nil && bytes.Equal(out, s.want) {
+		t.Fatal("LZO1F stream decoded as LZO1X")
+	}
+}
+
+func TestDecompressLZO1FLookBehind(t *testing.T) {
+	// Ten literals, then a short match at distance 0x801.
+	src := append([]byte{10}, "0123456789"...)
+	src = append(src, 0, 0, markerM3F|1, 0, 0)
+	if _, _, err := DecompressLZO1F(src, make([]byte, 64)); !errors.Is(err, ErrLookBehindUnderrun) {
+		t.Fatalf("expected ErrLookBehindUnderrun, got %v", err)
+	}
+}
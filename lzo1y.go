@@ -0,0 +1,56 @@
+// SPDX-License-Identifier: MIT
+// Copyright (c) 2026 WoozyMasta
+// Source: github.com/woozymasta/lzo
+
+package lzo
+
+// DecompressLZO1Y decompresses an LZO1Y stream from src into caller-provided dst
+// and returns dst[:n] (lzo1y_decompress_safe). LZO1Y shares the LZO1X grammar
+// except for M2 matches, which reach 0x400 bytes back with lengths up to 14;
+// decoding it as LZO1X silently produces wrong output.
+// Errors are the same as for DecompressInto.
+func DecompressLZO1Y(src, dst []byte) ([]byte, error) {
+	if len(src) == 0 {
+		return nil, ErrEmptyInput
+	}
+
+	outWritten, _, err := decompressCore(src, dst, nil, formatLZO1Y)
+	if err != nil {
+		return nil, err
+	}
+
+	return dst[:outWritten], nil
+}
+
+// CompressLZO1Y compresses src as an LZO1Y stream. opts may be nil (uses default level 1).
+// Level and Method select the engine as for Compress: level 0 or 1 matches
+// lzo1y_1_compress, 2–9 use the LZO1X-999 parser as lzo1y_999_compress does and
+// Optimal its optimal parse.
+// opts.Dict is ignored. Decode the result with DecompressLZO1Y.
+func CompressLZO1Y(src []byte, opts *CompressOptions) ([]byte, error) {
+	if opts == nil {
+		opts = DefaultCompressOptions()
+	}
+	plan := *opts
+	plan.Dict = nil
+	dictBits, level, err := compressPlan(&plan)
+	if err != nil {
+		return nil, err
+	}
+
+	if dictBits > 0 {
+		required := MaxCompressedSize(len(src))
+		buf := acquireCompressBuffer(required)
+		tmp := compress1x1(buf.data[:0], src, dictBits, plan.Exact, formatLZO1Y)
+		result := make([]byte, len(tmp))
+		copy(result, tmp)
+		releaseCompressBuffer(buf)
+		return result, nil
+	}
+
+	if plan.Exact {
+		return compress999ExactLevel(src, level, nil, formatLZO1Y)
+	}
+
+	return compress999Level(src, level, nil, formatLZO1Y)
+}
@@ -0,0 +1,109 @@
+package lzo
+
+import (
+	"bytes"
+	"errors"
+	"testing"
+)
+
+func TestLZO1YRoundTrip(t *testing.T) {
+	inputs := testInputSet(t)
+	inputs = append(inputs, struct {
+		name string
+		data []byte
+	}{name: "far-matches", data: ringTestInput()})
+
+	for _, in := range inputs {
+		for _, level := range []int{1, 5, 9} {
+			cmp, err := CompressLZO1Y(in.data, &CompressOptions{Level: level})
+			if err != nil {
+				t.Fatalf("%s level=%d: CompressLZO1Y failed: %v", in.name, level, err)
+			}
+
+			out, err := DecompressLZO1Y(cmp, make([]byte, len(in.data)))
+			if err != nil || !bytes.Equal(out, in.data) {
+				t.Fatalf("%s level=%d: DecompressLZO1Y failed: %v", in.name, level, err)
+			}
+		}
+	}
+}
+
+func TestLZO1YUsesLongM2(t *testing.T) {
+	// 14-byte repeats at distance 0x300 fit LZO1Y's M2 but not LZO1X's.
+	var data []byte
+	for i := range 0x300 {
+		data = append(data, byte(i*7+i>>3))
+	}
+	for range 100 {
+		data = append(data, data[len(data)-0x300:len(data)-0x300+14]...)
+		data = append(data, 0xEE, byte(len(data)))
+	}
+
+	for _, level := range []int{1, 9} {
+		y, err := CompressLZO1Y(data, &CompressOptions{Level: level})
+		if err != nil {
+			t.Fatalf("level=%d: CompressLZO1Y failed: %v", level, err)
+		}
+		x, err := Compress(data, &CompressOptions{Level: level})
+		if err != nil {
+			t.Fatalf("level=%d: Compress failed: %v", level, err)
+		}
+		if len(y) >= len(x) {
+			t.Fatalf("level=%d: LZO1Y output %d bytes, LZO1X %d bytes", level, len(y), len(x))
+		}
+
+		if out, err := DecompressInto(y, make([]byte, len(data))); err == nil && bytes.Equal(out, data) {
+			t.Fatalf("level=%d: LZO1Y stream decoded as LZO1X", level)
+		}
+	}
+}
+
+func TestDecompressLZO1YHandcrafted(t *testing.T) {
+	// A long literal run, an M2 of 14 bytes at distance 4, four literals, a
+	// 3-byte short match at distance 0x401+3, then the terminator.
+	lit := bytes.Repeat([]byte("wxyz"), 257)
+	src := appendFastMultiple([]byte{0}, len(lit)-18)
+	src = append(src, lit...)
+	src = append(src, ((14+1)<<4)|(3<<2), 0)
+	src = append(src, 4-3, 'a', 'b', 'c', 'd')
+	src = append(src, 3<<2, 0)
+	src = append(src, markerM4|1, 0, 0)
+
+	want := append([]byte{}, lit...)
+	for range 14 {
+		want = append(want, want[len(want)-4])
+	}
+	want = append(want, "abcd"...)
+	for range 3 {
+		want = append(want, want[len(want)-(maxOffsetM2Y+1+3)])
+	}
+
+	out, err := DecompressLZO1Y(src, make([]byte, len(want)))
+	if err != nil || !bytes.Equal(out, want) {
+		t.Fatalf("got %q, %v; want %q", out, err, want)
+	}
+
+	if _, err := DecompressLZO1Y(src, make([]byte, len(want)-1)); !errors.Is(err, ErrOutputOverrun) {
+		t.Fatalf("short dst: expected ErrOutputOverrun, got %v", err)
+	}
+	if _, err := DecompressLZO1Y(nil, nil); !errors.Is(err, ErrEmptyInput) {
+		t.Fatalf("empty: expected ErrEmptyInput, got %v", err)
+	}
+}
+
+func FuzzDecompressLZO1YMalformedInput(f *testing.F) {
+	f.Add([]byte{markerM4 | 1, 0, 0})
+	// An M2 match reaching before the start of the output.
+	f.Add([]byte{0x12, 'a', markerM2 | 0x3c, 0xff, markerM4 | 1, 0, 0})
+	for _, level := range []int{1, 9} {
+		if cmp, err := CompressLZO1Y(bytes.Repeat([]byte("abcdef"), 1000), &CompressOptions{Level: level}); err == nil {
+			f.Add(cmp)
+		}
+	}
+
+	f.Fuzz(func(t *testing.T, src []byte) {
+		dst := make([]byte, 4096)
+		out, err := DecompressLZO1Y(src, dst)
+		checkMalformedDecode(t, dst, out, err)
+	})
+}
@@ -0,0 +1,36 @@
+// SPDX-License-Identifier: MIT
+// Copyright (c) 2026 WoozyMasta
+// Source: github.com/woozymasta/lzo
+
+package lzo
+
+// DecompressLZO1Z decompresses an LZO1Z stream from src into caller-provided dst
+// and returns dst[:n] (lzo1z_decompress_safe). LZO1Z stores match distances high
+// bits first and lets M2 matches repeat the previous distance in a single byte.
+// Errors are the same as for DecompressInto; a distance repeat before the first
+// match is ErrLookBehindUnderrun.
+func DecompressLZO1Z(src, dst []byte) ([]byte, error) {
+	if len(src) == 0 {
+		return nil, ErrEmptyInput
+	}
+
+	outWritten, _, err := decompressCore(src, dst, nil, formatLZO1Z)
+	if err != nil {
+		return nil, err
+	}
+
+	return dst[:outWritten], nil
+}
+
+// CompressLZO1Z compresses src as an LZO1Z stream with the LZO1X-999 parser, as
+// lzo1z_999_compress does; liblzo2 has no fast LZO1Z compressor. opts may be nil.
+// opts.Level selects the level (clamped to 1–9, default 9); Method and Dict are
+// ignored. Decode the result with DecompressLZO1Z.
+func CompressLZO1Z(src []byte, opts *CompressOptions) ([]byte, error) {
+	level := 9
+	if opts != nil && opts.Level != 0 {
+		level = min(max(opts.Level, 1), 9)
+	}
+
+	return compress999Level(src, level, nil, formatLZO1Z)
+}
@@ -0,0 +1,179 @@
+package lzo
+
+import (
+	"bytes"
+	"errors"
+	"math/rand"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// lzo1zVector is a hand-assembled LZO1Z stream that uses every instruction form,
+// including distance repeats after M1, M1-after-4-literals and M4 matches.
+// TestDecompressLZO1ZLibLZO2Vectors checks the decoder against liblzo2 output.
+func lzo1zVector() (src, want []byte) {
+	lit := make([]byte, 17000)
+	rand.New(rand.NewSource(26)).Read(lit)
+
+	src = appendFastMultiple([]byte{0}, len(lit)-18)
+	src = append(src, lit...)
+	src = append(src,
+		0x01, 5<<2|1, 'X', // M1 after 4 literals: distance 0x701+0x45, then 1 literal
+		0x00, 4<<2|2, 'Y', 'Z', // M1: distance 5, then 2 literals
+		(3-1)<<5|repeatFieldM2,  // M2 of 3 repeating distance 5
+		4-3, 'P', 'Q', 'R', 'S', // literal run of 4
+		markerM3, 40-33, 999>>6, 999<<2&0xff, // M3 of 40 at distance 1000
+		markerM4|(5-2), 300>>6, 300<<2&0xff|3, 'k', 'l', 'm', // M4 of 5 at distance 0x4000+300, then 3 literals
+		(8-1)<<5|repeatFieldM2, // M2 of 8 repeating distance 0x4000+300
+		markerM4|1, 0, 0,
+	)
+
+	want = append([]byte{}, lit...)
+	copyMatch := func(dist, length int) {
+		for range length {
+			want = append(want, want[len(want)-dist])
+		}
+	}
+	copyMatch(maxOffsetM2Z+1+0x45, 3)
+	want = append(want, 'X')
+	copyMatch(5, 2)
+	want = append(want, "YZ"...)
+	copyMatch(5, 3)
+	want = append(want, "PQRS"...)
+	copyMatch(1000, 40)
+	copyMatch(0x4000+300, 5)
+	want = append(want, "klm"...)
+	copyMatch(0x4000+300, 8)
+
+	return src, want
+}
+
+func TestDecompressLZO1ZVector(t *testing.T) {
+	src, want := lzo1zVector()
+
+	out, err := DecompressLZO1Z(src, make([]byte, len(want)))
+	if err != nil || !bytes.Equal(out, want) {
+		t.Fatalf("DecompressLZO1Z failed: %v (mismatch at %d)", err, firstMismatchBytes(out, want))
+	}
+
+	if _, err := DecompressLZO1Z(src, make([]byte, len(want)-1)); !errors.Is(err, ErrOutputOverrun) {
+		t.Fatalf("short dst: expected ErrOutputOverrun, got %v", err)
+	}
+	if _, err := DecompressLZO1Z(src[:len(src)-4], make([]byte, len(want))); !errors.Is(err, ErrUnexpectedEOF) {
+		t.Fatalf("truncated: expected ErrUnexpectedEOF, got %v", err)
+	}
+	if out, err := DecompressInto(src, make([]byte, len(want))); err == nil && bytes.Equal(out, want) {
+		t.Fatal("LZO1Z stream decoded as LZO1X")
+	}
+	if _, err := DecompressLZO1Z(nil, nil); !errors.Is(err, ErrEmptyInput) {
+		t.Fatalf("empty: expected ErrEmptyInput, got %v", err)
+	}
+}
+
+func TestDecompressLZO1ZLibLZO2Vectors(t *testing.T) {
+	// Every testdata/lzo1z/<name>.lzo1z is <name>.txt compressed by liblzo2's
+	// lzo1z_999_compress (see testdata/lzo1z/generate.sh).
+	streams, err := filepath.Glob(filepath.Join("testdata", "lzo1z", "*.lzo1z"))
+	if err != nil {
+		t.Fatalf("Glob failed: %v", err)
+	}
+	if len(streams) == 0 {
+		if os.Getenv("LZO_REQUIRE_FIXTURES") != "" {
+			t.Fatal("no LZO1Z vectors in testdata/lzo1z")
+		}
+		t.Skip("no LZO1Z vectors in testdata/lzo1z; run make testdata-fixtures")
+	}
+
+	for _, name := range streams {
+		src, err := os.ReadFile(name)
+		if err != nil {
+			t.Fatalf("read %s: %v", name, err)
+		}
+		want, err := os.ReadFile(strings.TrimSuffix(name, ".lzo1z") + ".txt")
+		if err != nil {
+			t.Fatalf("read plaintext of %s: %v", name, err)
+		}
+
+		out, err := DecompressLZO1Z(src, make([]byte, len(want)))
+		if err != nil || !bytes.Equal(out, want) {
+			t.Fatalf("%s: DecompressLZO1Z failed: %v (mismatch at %d)", name, err, firstMismatchBytes(out, want))
+		}
+	}
+}
+
+func TestDecompressLZO1ZRepeatBeforeFirstMatch(t *testing.T) {
+	src := []byte{17 + 4, 'a', 'b', 'c', 'd', (3-1)<<5 | repeatFieldM2, markerM4 | 1, 0, 0}
+	if _, err := DecompressLZO1Z(src, make([]byte, 16)); !errors.Is(err, ErrLookBehindUnderrun) {
+		t.Fatalf("expected ErrLookBehindUnderrun, got %v", err)
+	}
+}
+
+func TestLZO1ZRoundTrip(t *testing.T) {
+	inputs := testInputSet(t)
+	inputs = append(inputs, struct {
+		name string
+		data []byte
+	}{name: "far-matches", data: ringTestInput()})
+
+	for _, in := range inputs {
+		for _, level := range []int{0, 1, 5, 9} {
+			cmp, err := CompressLZO1Z(in.data, &CompressOptions{Level: level})
+			if err != nil {
+				t.Fatalf("%s level=%d: CompressLZO1Z failed: %v", in.name, level, err)
+			}
+
+			out, err := DecompressLZO1Z(cmp, make([]byte, len(in.data)))
+			if err != nil || !bytes.Equal(out, in.data) {
+				t.Fatalf("%s level=%d: DecompressLZO1Z failed: %v", in.name, level, err)
+			}
+		}
+	}
+}
+
+func TestLZO1ZRepeatsOffsets(t *testing.T) {
+	// Fixed-stride records differ in one byte, so most matches reuse the stride.
+	rng := rand.New(rand.NewSource(3))
+	record := make([]byte, 3000)
+	rng.Read(record)
+	var data []byte
+	for i := range 40 {
+		for j := 0; j < len(record); j += 11 {
+			record[j] = byte(i + j)
+		}
+		data = append(data, record...)
+	}
+
+	z, err := CompressLZO1Z(data, nil)
+	if err != nil {
+		t.Fatalf("CompressLZO1Z failed: %v", err)
+	}
+	x, err := Compress(data, &CompressOptions{Level: 9})
+	if err != nil {
+		t.Fatalf("Compress failed: %v", err)
+	}
+	if len(z) >= len(x) {
+		t.Fatalf("LZO1Z output %d bytes, LZO1X %d bytes", len(z), len(x))
+	}
+
+	out, err := DecompressLZO1Z(z, make([]byte, len(data)))
+	if err != nil || !bytes.Equal(out, data) {
+		t.Fatalf("DecompressLZO1Z failed: %v", err)
+	}
+}
+
+func FuzzDecompressLZO1ZMalformedInput(f *testing.F) {
+	f.Add([]byte{markerM4 | 1, 0, 0})
+	// A distance repeat before the first match.
+	f.Add([]byte{0x12, 'a', (3-1)<<5 | repeatFieldM2, markerM4 | 1, 0, 0})
+	if cmp, err := CompressLZO1Z(bytes.Repeat([]byte("abcdef"), 1000), nil); err == nil {
+		f.Add(cmp)
+	}
+
+	f.Fuzz(func(t *testing.T, src []byte) {
+		dst := make([]byte, 4096)
+		out, err := DecompressLZO1Z(src, dst)
+		checkMalformedDecode(t, dst, out, err)
+	})
+}
@@ -0,0 +1,259 @@
+// SPDX-License-Identifier: MIT
+// Copyright (c) 2026 WoozyMasta
+// Source: github.com/woozymasta/lzo
+
+package lzo
+
+// DecompressLZO2A decompresses an LZO2A stream from src into caller-provided dst
+// (lzo2a_decompress_safe). Return values and errors are the same as for
+// DecompressLZO1B; a long match at distance 0 is ErrLookBehindUnderrun.
+// LZO2A has no byte-aligned opcodes: a bit stream interleaved with the bytes
+// tells literals from matches.
+func DecompressLZO2A(src, dst []byte) ([]byte, int, error) {
+	if len(src) == 0 {
+		return nil, 0, ErrEmptyInput
+	}
+
+	var (
+		inPos  int
+		outPos int
+		flags  lzo2aBitReader
+	)
+
+	for {
+		isMatch, err := flags.read(src, &inPos, 1)
+		if err != nil {
+			return nil, 0, ErrUnexpectedEOF
+		}
+		if isMatch == 0 {
+			if err := copyLiteralRun(src, &inPos, dst, &outPos, 1); err != nil {
+				return nil, 0, err
+			}
+			continue
+		}
+
+		isLong, err := flags.read(src, &inPos, 1)
+		if err != nil {
+			return nil, 0, err
+		}
+		if isLong == 0 {
+			lenBits, err := flags.read(src, &inPos, 2)
+			if err != nil {
+				return nil, 0, err
+			}
+			b, err := readCompressedByte(src, &inPos)
+			if err != nil {
+				return nil, 0, err
+			}
+			if err := copyCheckedBackRef(dst, &outPos, int(b)+1, int(lenBits)+2); err != nil {
+				return nil, 0, err
+			}
+			continue
+		}
+
+		v16, err := readCompressedLE16(src, &inPos)
+		if err != nil {
+			return nil, 0, err
+		}
+		dist := int(v16&0x1f) | int(v16>>8)<<5
+		matchLen := int(v16>>5&0x7) + 2
+		if matchLen != 2 && dist == 0 {
+			return dst[:outPos], inPos, nil
+		}
+		if matchLen == 2 {
+			// A zero length field is followed by a flag for far distances
+			// and a zero-extended length.
+			far, err := flags.read(src, &inPos, 1)
+			if err != nil {
+				return nil, 0, err
+			}
+			ext, err := readZeroExtendedLength(src, &inPos)
+			if err != nil {
+				return nil, 0, err
+			}
+
+			if far == 1 {
+				dist += maxOffsetM2A + 1
+				matchLen = ext + 2
+			} else {
+				matchLen = ext + 9
+			}
+		}
+		if dist == 0 {
+			return nil, 0, ErrLookBehindUnderrun
+		}
+
+		if err := copyCheckedBackRef(dst, &outPos, dist, matchLen); err != nil {
+			return nil, 0, err
+		}
+	}
+}
+
+// CompressLZO2A compresses src as an LZO2A stream with the LZO1X-999 match
+// finder, as lzo2a_999_compress does; liblzo2 has no fast LZO2A compressor.
+// opts may be nil. opts.Level selects the search depth (clamped to 1–9,
+// default 9); Method and Dict are ignored. Decode the result with DecompressLZO2A.
+func CompressLZO2A(src []byte, opts *CompressOptions) ([]byte, error) {
+	level := 9
+	if opts != nil && opts.Level != 0 {
+		level = min(max(opts.Level, 1), 9)
+	}
+
+	dict := acquireCompressorDict()
+	defer releaseCompressorDict(dict)
+
+	state := hcState{src: src}
+	dict.init(&state, nil, hcFinderHashChain, 0, 0)
+
+	// A literal costs 9 bits, so this capacity covers incompressible input.
+	out := lzo2aBitWriter{out: make([]byte, 0, len(src)+len(src)/8+8)}
+	bestOffsets := hcBestOffsets{}
+	searchDepth := hcSearchDepthByLevel[level]
+
+	matchOff, matchLen := dict.advance(&state, 0, &bestOffsets, false, searchDepth)
+	for state.bufSize > 0 {
+		matchLen, matchOff = reachableLZO2AMatch(bestOffsets.offsets[:], matchLen, matchOff)
+		if matchLen == 0 {
+			out.writeBits(1, 0)
+			out.out = append(out.out, src[state.bufPos])
+			matchOff, matchLen = dict.advance(&state, 0, &bestOffsets, false, searchDepth)
+			continue
+		}
+
+		out.writeMatch(matchLen, matchOff)
+		matchOff, matchLen = dict.advance(&state, matchLen, &bestOffsets, true, searchDepth)
+	}
+
+	// End of stream: a long match of 3 at distance 0.
+	out.writeBits(2, 0b11)
+	out.out = append(out.out, 1<<5, 0)
+	out.flush()
+
+	return out.out, nil
+}
+
+// reachableLZO2AMatch returns the longest candidate LZO2A can encode profitably:
+// the match itself, or a shorter alternative from bestOffsetByLen when the match
+// lies beyond the window. It returns a length of 0 when no candidate fits.
+func reachableLZO2AMatch(bestOffsetByLen []int, matchLen, matchOff int) (int, int) {
+	for {
+		switch {
+		case matchLen < 2:
+			return 0, 0
+		case matchOff <= maxOffsetM1A:
+			return matchLen, matchOff
+		case matchOff <= maxOffsetM2A && matchLen >= 3:
+			return matchLen, matchOff
+		case matchOff <= maxOffsetM3A && matchLen >= 4:
+			// Far matches of 3 cost as much as three literals.
+			return matchLen, matchOff
+		}
+
+		matchLen = min(matchLen, len(bestOffsetByLen)) - 1
+		for matchLen >= 2 && bestOffsetByLen[matchLen] == 0 {
+			matchLen--
+		}
+		if matchLen >= 2 {
+			matchOff = bestOffsetByLen[matchLen]
+		}
+	}
+}
+
+// lzo2aBitReader reads the LSB-first flag bits LZO2A interleaves with its bytes:
+// a new byte is taken from the input only when the buffered bits run out.
+type lzo2aBitReader struct {
+	bits  uint32
+	count uint
+}
+
+// read returns the next n (at most 8) bits.
+func (r *lzo2aBitReader) read(src []byte, inPos *int, n uint) (uint32, error) {
+	if r.count < n {
+		b, err := readCompressedByte(src, inPos)
+		if err != nil {
+			return 0, err
+		}
+		r.bits |= uint32(b) << r.count
+		r.count += 8
+	}
+
+	v := r.bits & (1<<n - 1)
+	r.bits >>= n
+	r.count -= n
+
+	return v, nil
+}
+
+// lzo2aBitWriter builds an LZO2A stream. Each flag byte is reserved in out at the
+// point where the decoder will read it and filled in once its bits are known.
+type lzo2aBitWriter struct {
+	out     []byte
+	flagPos int
+	bits    uint32
+	count   uint
+}
+
+// writeBits appends the low n bits of v, first bit first.
+func (w *lzo2aBitWriter) writeBits(n uint, v uint32) {
+	if w.count == 0 {
+		w.flagPos = len(w.out)
+		w.out = append(w.out, 0)
+	}
+
+	w.bits |= v << w.count
+	w.count += n
+	if w.count >= 8 {
+		w.out[w.flagPos] = byte(w.bits)
+		w.bits >>= 8
+		w.count -= 8
+		if w.count > 0 {
+			w.flagPos = len(w.out)
+			w.out = append(w.out, 0)
+		}
+	}
+}
+
+// writeMatch appends a match that reachableLZO2AMatch accepted.
+func (w *lzo2aBitWriter) writeMatch(matchLen, matchOff int) {
+	if matchLen <= maxLenM1A && matchOff <= maxOffsetM1A {
+		w.writeBits(2, 0b01)
+		w.writeBits(2, uint32(matchLen-2)) //nolint:gosec // G115: 0..3
+		w.out = append(w.out, byte(matchOff-1))
+		return
+	}
+
+	w.writeBits(2, 0b11)
+	dist, far := matchOff, matchOff > maxOffsetM2A
+	if far {
+		dist -= maxOffsetM2A + 1
+	}
+
+	lenField := 0
+	if !far && matchLen <= 9 {
+		lenField = matchLen - 2
+	}
+	w.out = append(w.out, byte(lenField<<5|dist&0x1f), byte(dist>>5))
+	if lenField != 0 {
+		return
+	}
+
+	ext := matchLen - 9
+	if far {
+		w.writeBits(1, 1)
+		ext = matchLen - 2
+	} else {
+		w.writeBits(1, 0)
+	}
+	for ext > 255 {
+		w.out = append(w.out, 0)
+		ext -= 255
+	}
+	w.out = append(w.out, byte(ext))
+}
+
+// flush stores the pending flag bits.
+func (w *lzo2aBitWriter) flush() {
+	if w.count > 0 {
+		w.out[w.flagPos] = byte(w.bits)
+	}
+}
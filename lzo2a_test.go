@@ -0,0 +1,144 @@
+package lzo
+
+import (
+	"bytes"
+	"errors"
+	"testing"
+)
+
+func TestDecompressLZO2AHandcrafted(t *testing.T) {
+	// Flag bits 0,0 (two literals), 1,0 and length bits 2 (an M1 match of 4 at
+	// distance 2), then 1,1 for the end-of-stream match, all in the first byte.
+	src := []byte{0xe4, 'a', 'b', 1, 1 << 5, 0}
+	out, nRead, err := DecompressLZO2A(append(src, 0xff), make([]byte, 6))
+	if err != nil || string(out) != "ababab" || nRead != len(src) {
+		t.Fatalf("got %q, nRead=%d, %v", out, nRead, err)
+	}
+
+	if _, _, err := DecompressLZO2A(src, make([]byte, 5)); !errors.Is(err, ErrOutputOverrun) {
+		t.Fatalf("short dst: expected ErrOutputOverrun, got %v", err)
+	}
+	if _, _, err := DecompressLZO2A(src[:4], make([]byte, 6)); !errors.Is(err, ErrInputOverrun) {
+		t.Fatalf("truncated: expected ErrInputOverrun, got %v", err)
+	}
+	if _, _, err := DecompressLZO2A([]byte("\x0012345678"), make([]byte, 8)); !errors.Is(err, ErrUnexpectedEOF) {
+		t.Fatalf("no terminator: expected ErrUnexpectedEOF, got %v", err)
+	}
+	if _, _, err := DecompressLZO2A(nil, nil); !errors.Is(err, ErrEmptyInput) {
+		t.Fatalf("empty: expected ErrEmptyInput, got %v", err)
+	}
+
+	// The same M1 match at distance 3 reaches before the output.
+	src[3] = 2
+	if _, _, err := DecompressLZO2A(src, make([]byte, 6)); !errors.Is(err, ErrLookBehindUnderrun) {
+		t.Fatalf("expected ErrLookBehindUnderrun, got %v", err)
+	}
+}
+
+func TestDecompressLZO2ALongMatches(t *testing.T) {
+	lit := ringTestInput()[:0x4000]
+
+	var w lzo2aBitWriter
+	want := append([]byte{}, lit...)
+	for _, b := range lit {
+		w.writeBits(1, 0)
+		w.out = append(w.out, b)
+	}
+	for _, m := range []struct{ length, dist int }{
+		{length: 9, dist: 0x1fff},   // longest match with a length field
+		{length: 300, dist: 100},    // zero-extended length
+		{length: 3, dist: 0x2000},   // far match at the smallest far distance
+		{length: 600, dist: 0x3fff}, // far match with a zero-extended length
+	} {
+		w.writeMatch(m.length, m.dist)
+		for range m.length {
+			want = append(want, want[len(want)-m.dist])
+		}
+	}
+	w.writeBits(2, 0b11)
+	w.out = append(w.out, 1<<5, 0)
+	w.flush()
+
+	out, _, err := DecompressLZO2A(w.out, make([]byte, len(want)))
+	if err != nil || !bytes.Equal(out, want) {
+		t.Fatalf("DecompressLZO2A failed: %v (mismatch at %d)", err, firstMismatchBytes(out, want))
+	}
+
+	// A long match with a zero length field may not use distance 0.
+	var bad lzo2aBitWriter
+	bad.writeBits(1, 0)
+	bad.out = append(bad.out, 'x')
+	bad.writeBits(2, 0b11)
+	bad.out = append(bad.out, 0, 0)
+	bad.writeBits(1, 0)
+	bad.out = append(bad.out, 1)
+	bad.flush()
+	if _, _, err := DecompressLZO2A(bad.out, make([]byte, 64)); !errors.Is(err, ErrLookBehindUnderrun) {
+		t.Fatalf("expected ErrLookBehindUnderrun, got %v", err)
+	}
+}
+
+func TestLZO2ARoundTrip(t *testing.T) {
+	inputs := testInputSet(t)
+	inputs = append(inputs, struct {
+		name string
+		data []byte
+	}{name: "far-matches", data: ringTestInput()})
+
+	for _, in := range inputs {
+		for _, level := range []int{0, 1, 9} {
+			cmp, err := CompressLZO2A(in.data, &CompressOptions{Level: level})
+			if err != nil {
+				t.Fatalf("%s level=%d: CompressLZO2A failed: %v", in.name, level, err)
+			}
+			if len(cmp) > len(in.data)+len(in.data)/8+8 {
+				t.Fatalf("%s level=%d: output %d bytes for %d input bytes", in.name, level, len(cmp), len(in.data))
+			}
+
+			out, nRead, err := DecompressLZO2A(cmp, make([]byte, len(in.data)))
+			if err != nil || !bytes.Equal(out, in.data) || nRead != len(cmp) {
+				t.Fatalf("%s level=%d: DecompressLZO2A failed: %v", in.name, level, err)
+			}
+		}
+	}
+}
+
+func FuzzDecompressLZO2AMalformedInput(f *testing.F) {
+	f.Add([]byte{0xe4, 'a', 'b', 1, 1 << 5, 0})
+	f.Add([]byte{0xff, 0, 0, 0, 0})
+	if cmp, err := CompressLZO2A(bytes.Repeat([]byte("abcdef"), 1000), nil); err == nil {
+		f.Add(cmp)
+	}
+
+	f.Fuzz(func(t *testing.T, src []byte) {
+		dst := make([]byte, 4096)
+		out, nRead, err := DecompressLZO2A(src, dst)
+		if err != nil {
+			if out != nil || nRead != 0 {
+				t.Fatalf("error %v with output %d bytes and nRead=%d", err, len(out), nRead)
+			}
+			return
+		}
+		if nRead > len(src) || len(out) > len(dst) {
+			t.Fatalf("nRead=%d of %d, output %d of %d bytes", nRead, len(src), len(out), len(dst))
+		}
+	})
+}
+
+func FuzzLZO2ARoundTrip(f *testing.F) {
+	f.Add([]byte(""), uint8(9))
+	f.Add([]byte("hello hello hello"), uint8(1))
+	f.Add(bytes.Repeat([]byte{0}, 70000), uint8(5))
+
+	f.Fuzz(func(t *testing.T, data []byte, level uint8) {
+		cmp, err := CompressLZO2A(data, &CompressOptions{Level: int(level % 10)})
+		if err != nil {
+			t.Fatalf("CompressLZO2A failed: %v", err)
+		}
+
+		out, _, err := DecompressLZO2A(cmp, make([]byte, len(data)))
+		if err != nil || !bytes.Equal(out, data) {
+			t.Fatalf("round trip failed: %v", err)
+		}
+	})
+}
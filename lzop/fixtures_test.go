@@ -0,0 +1,136 @@
+package lzop
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+// lzopFixture describes a file in testdata written by lzop 1.0x (see testdata/generate.sh).
+type lzopFixture struct {
+	File    string `json:"file"`
+	Stored  bool   `json:"stored"`
+	Members []struct {
+		Input    string `json:"input"`
+		Checksum string `json:"checksum"`
+		Method   Method `json:"method"`
+		Level    uint8  `json:"level"`
+	} `json:"members"`
+}
+
+// loadLzopFixtures reads the fixture manifest, skipping the test when the
+// fixtures have not been generated unless LZO_REQUIRE_FIXTURES is set.
+func loadLzopFixtures(t *testing.T) []lzopFixture {
+	t.Helper()
+
+	manifest, err := os.ReadFile(filepath.Join("testdata", "fixtures.json"))
+	if errors.Is(err, fs.ErrNotExist) {
+		if os.Getenv("LZO_REQUIRE_FIXTURES") != "" {
+			t.Fatal("no lzop fixtures in testdata")
+		}
+		t.Skip("no lzop fixtures in testdata; run make testdata-fixtures")
+	}
+	if err != nil {
+		t.Fatalf("read manifest: %v", err)
+	}
+
+	var fixtures []lzopFixture
+	if err := json.Unmarshal(manifest, &fixtures); err != nil {
+		t.Fatalf("parse manifest: %v", err)
+	}
+	return fixtures
+}
+
+func TestLzopFixtures(t *testing.T) {
+	for _, fx := range loadLzopFixtures(t) {
+		t.Run(fx.File, func(t *testing.T) {
+			file, err := os.ReadFile(filepath.Join("testdata", fx.File))
+			if err != nil {
+				t.Fatalf("read fixture: %v", err)
+			}
+
+			src := bytes.NewReader(file)
+			r, err := NewReader(src)
+			if err != nil {
+				t.Fatalf("NewReader failed: %v", err)
+			}
+			r.Multistream(false)
+
+			for i, m := range fx.Members {
+				if i > 0 {
+					if err := r.Reset(src); err != nil {
+						t.Fatalf("member %d: Reset failed: %v", i, err)
+					}
+					r.Multistream(false)
+				}
+
+				want, err := os.ReadFile(filepath.Join("testdata", m.Input))
+				if err != nil {
+					t.Fatalf("read input: %v", err)
+				}
+				if fx.Stored && !bytes.Contains(file, want) {
+					t.Fatalf("member %d: block of %s is not stored", i, m.Input)
+				}
+
+				out, err := io.ReadAll(r)
+				if err != nil || !bytes.Equal(out, want) {
+					t.Fatalf("member %d: ReadAll: %d bytes (want %d), %v", i, len(out), len(want), err)
+				}
+				checkFixtureHeader(t, i, &r.Header, m.Input, m.Method, m.Level, m.Checksum)
+			}
+
+			if src.Len() != 0 {
+				t.Fatalf("%d trailing bytes after the last member", src.Len())
+			}
+		})
+	}
+}
+
+// checkFixtureHeader compares h with the metadata generate.sh gives every member.
+func checkFixtureHeader(t *testing.T, member int, h *Header, name string, method Method, level uint8, checksum string) {
+	t.Helper()
+
+	if h.Version&0xff00 != 0x1000 || h.VersionNeeded < versionWithLevel || h.LibVersion < 0x2000 {
+		t.Fatalf("member %d: versions %#x/%#x/%#x are not lzop 1.0x", member, h.Version, h.LibVersion, h.VersionNeeded)
+	}
+	if h.Name != name {
+		t.Fatalf("member %d: Name = %q, want %q", member, h.Name, name)
+	}
+	if h.Mode&0o777 != 0o644 {
+		t.Fatalf("member %d: Mode = %o, want 0644", member, h.Mode)
+	}
+	if want := time.Unix(1700000000, 0); !h.ModTime.Equal(want) {
+		t.Fatalf("member %d: ModTime = %v, want %v", member, h.ModTime, want)
+	}
+	if h.Method != method || h.Level != level {
+		t.Fatalf("member %d: method %d level %d, want %d level %d", member, h.Method, h.Level, method, level)
+	}
+	if h.Flags&0xff000000 != FlagOSUnix {
+		t.Fatalf("member %d: Flags = %#x, want Unix OS code", member, h.Flags)
+	}
+
+	adler := h.Flags & (FlagAdler32D | FlagAdler32C)
+	crc := h.Flags & (FlagCRC32D | FlagCRC32C)
+	switch checksum {
+	case "none":
+		if adler != 0 || crc != 0 {
+			t.Fatalf("member %d: Flags = %#x, want no checksums", member, h.Flags)
+		}
+	case "adler32":
+		if h.Flags&FlagAdler32D == 0 || crc != 0 {
+			t.Fatalf("member %d: Flags = %#x, want Adler-32 checksums", member, h.Flags)
+		}
+	case "crc32":
+		if h.Flags&FlagCRC32D == 0 || adler != 0 {
+			t.Fatalf("member %d: Flags = %#x, want CRC-32 checksums", member, h.Flags)
+		}
+	default:
+		t.Fatalf("member %d: unknown checksum %q in manifest", member, checksum)
+	}
+}
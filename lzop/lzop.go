@@ -0,0 +1,118 @@
+// SPDX-License-Identifier: MIT
+// Copyright (c) 2026 WoozyMasta
+// Source: github.com/woozymasta/lzo
+
+/*
+Package lzop reads and writes the lzop file format (.lzo).
+
+An lzop file starts with a magic number and a header carrying version fields,
+the compression method and level, flags, file mode, modification time, file
+name and a header checksum. The header is followed by blocks of uncompressed and
+compressed sizes, optional Adler-32/CRC-32 checksums and block data, terminated
+by a zero uncompressed size. Blocks that do not shrink are stored uncompressed.
+
+Block data is LZO1X and is encoded and decoded with package lzo.
+
+Reading (multi-member files are concatenated like gzip):
+
+	r, err := lzop.NewReader(f)
+	fmt.Println(r.Name, r.ModTime)
+	_, err = io.Copy(dst, r)
+
+Writing:
+
+	w := lzop.NewWriter(f, &lzo.CompressOptions{Level: 9})
+	w.Name = "data.bin"
+	_, err := io.Copy(w, src)
+	err = w.Close()
+*/
+package lzop
+
+import (
+	"errors"
+	"time"
+)
+
+// magic is the 9-byte signature that starts every lzop member.
+var magic = [9]byte{0x89, 'L', 'Z', 'O', 0x00, 0x0d, 0x0a, 0x1a, 0x0a}
+
+// Version fields written by Writer.
+const (
+	// writerVersion is the lzop program version recorded in written headers.
+	writerVersion = 0x1040
+
+	// writerLibVersion is the LZO library version recorded in written headers.
+	writerLibVersion = 0x20a0
+
+	// writerVersionNeeded is the minimum lzop version needed to extract written files.
+	writerVersionNeeded = 0x0940
+
+	// versionWithLevel is the first format version with version-needed, level and mtime-high fields.
+	versionWithLevel = 0x0940
+)
+
+// maxBlockSize is the largest uncompressed block size accepted by lzop.
+const maxBlockSize = 64 << 20
+
+// Method identifies the compression method stored in an lzop header.
+type Method uint8
+
+// Compression methods defined by lzop. Reader supports the LZO1X methods.
+const (
+	MethodLZO1X1    Method = 1 // MethodLZO1X1 is LZO1X-1 (lzop -2 … -6).
+	MethodLZO1X1_15 Method = 2 // MethodLZO1X1_15 is LZO1X-1(15) (lzop -1).
+	MethodLZO1X999  Method = 3 // MethodLZO1X999 is LZO1X-999 (lzop -7 … -9).
+)
+
+// Header flags. The high byte of Flags holds the operating system code
+// and bits 20–23 hold the character set of the file name.
+const (
+	FlagAdler32D    = 0x00000001 // FlagAdler32D stores an Adler-32 of each uncompressed block.
+	FlagAdler32C    = 0x00000002 // FlagAdler32C stores an Adler-32 of each compressed block.
+	FlagStdin       = 0x00000004 // FlagStdin marks input read from stdin.
+	FlagStdout      = 0x00000008 // FlagStdout marks output written to stdout.
+	FlagNameDefault = 0x00000010 // FlagNameDefault marks a generated file name.
+	FlagDOSish      = 0x00000020 // FlagDOSish marks a file name converted from a DOS-like system.
+	FlagExtraField  = 0x00000040 // FlagExtraField marks a header extra field.
+	FlagGMTDiff     = 0x00000080 // FlagGMTDiff marks a header GMT offset.
+	FlagCRC32D      = 0x00000100 // FlagCRC32D stores a CRC-32 of each uncompressed block.
+	FlagCRC32C      = 0x00000200 // FlagCRC32C stores a CRC-32 of each compressed block.
+	FlagMultipart   = 0x00000400 // FlagMultipart marks a multi-part archive.
+	FlagFilter      = 0x00000800 // FlagFilter marks a header filter field.
+	FlagHeaderCRC32 = 0x00001000 // FlagHeaderCRC32 uses CRC-32 instead of Adler-32 for header checksums.
+	FlagPath        = 0x00002000 // FlagPath marks a file name with a path.
+	FlagOSUnix      = 0x03000000 // FlagOSUnix is the Unix operating system code.
+)
+
+// Errors returned by Reader and Writer. Block decoding errors from package lzo
+// (for example lzo.ErrUnexpectedEOF) are returned unchanged.
+var (
+	// ErrHeader is returned when a member does not start with a valid lzop header.
+	ErrHeader = errors.New("invalid lzop header")
+
+	// ErrChecksum is returned when a header or block checksum does not match.
+	ErrChecksum = errors.New("lzop checksum mismatch")
+
+	// ErrUnsupported is returned for methods, filters or versions this package cannot decode.
+	ErrUnsupported = errors.New("unsupported lzop feature")
+
+	// ErrInvalidBlock is returned when a block header declares impossible sizes
+	// or its data does not decode to exactly the declared size.
+	ErrInvalidBlock = errors.New("invalid lzop block")
+)
+
+// Header is the metadata of one lzop member.
+// Reader fills it from the file; Writer writes it before the first block.
+type Header struct {
+	ModTime       time.Time // ModTime is the file modification time (second precision).
+	Name          string    // Name is the original file name (at most 255 bytes).
+	Extra         []byte    // Extra is the optional extra field (FlagExtraField).
+	Flags         uint32    // Flags holds Flag* bits, the OS code and the character set.
+	Mode          uint32    // Mode is the original file mode.
+	Filter        uint32    // Filter is the optional filter number (FlagFilter); only 0 is supported.
+	Version       uint16    // Version is the lzop version that wrote the file.
+	LibVersion    uint16    // LibVersion is the LZO library version that wrote the file.
+	VersionNeeded uint16    // VersionNeeded is the minimum lzop version needed to extract.
+	Method        Method    // Method is the compression method.
+	Level         uint8     // Level is the compression level.
+}
@@ -0,0 +1,455 @@
+package lzop
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"hash/adler32"
+	"hash/crc32"
+	"io"
+	"testing"
+	"time"
+
+	"github.com/woozymasta/lzo"
+)
+
+func randomBytes(size int) []byte {
+	data := make([]byte, size)
+	state := uint64(0x9e3779b97f4a7c15)
+	for i := range data {
+		state ^= state << 13
+		state ^= state >> 7
+		state ^= state << 17
+		data[i] = byte(state)
+	}
+	return data
+}
+
+func mixedBytes(size int) []byte {
+	data := randomBytes(size)
+	pattern := []byte("level=info component=lzop message=test payload=0123456789abcdef\n")
+	for start := 0; start < len(data); start += 4096 {
+		end := min(start+3072, len(data))
+		for pos := start; pos < end; pos += len(pattern) {
+			copy(data[pos:end], pattern)
+		}
+	}
+	return data
+}
+
+// writeFile compresses data into one lzop member with the given header adjustments.
+func writeFile(t *testing.T, data []byte, opts *lzo.CompressOptions, setup func(*Header)) []byte {
+	t.Helper()
+
+	var file bytes.Buffer
+	w := NewWriter(&file, opts)
+	if setup != nil {
+		setup(&w.Header)
+	}
+	if _, err := w.Write(data); err != nil {
+		t.Fatalf("Write failed: %v", err)
+	}
+	if err := w.Close(); err != nil {
+		t.Fatalf("Close failed: %v", err)
+	}
+	return file.Bytes()
+}
+
+func TestRoundTripChecksumFlags(t *testing.T) {
+	data := mixedBytes(300 << 10)
+	checksums := []uint32{
+		0,
+		FlagAdler32D,
+		FlagCRC32D,
+		FlagAdler32D | FlagAdler32C,
+		FlagCRC32D | FlagCRC32C,
+		FlagAdler32D | FlagAdler32C | FlagCRC32D | FlagCRC32C | FlagHeaderCRC32,
+	}
+
+	for _, level := range []int{1, 9} {
+		for _, flags := range checksums {
+			t.Run(fmt.Sprintf("level-%d/flags-%#x", level, flags), func(t *testing.T) {
+				file := writeFile(t, data, &lzo.CompressOptions{Level: level, BlockSize: 64 << 10}, func(h *Header) {
+					h.Flags = flags | FlagOSUnix
+				})
+
+				r, err := NewReader(bytes.NewReader(file))
+				if err != nil {
+					t.Fatalf("NewReader failed: %v", err)
+				}
+				out, err := io.ReadAll(r)
+				if err != nil {
+					t.Fatalf("ReadAll failed: %v", err)
+				}
+				if !bytes.Equal(out, data) {
+					t.Fatalf("round-trip mismatch: got=%d want=%d", len(out), len(data))
+				}
+				if r.Flags != flags|FlagOSUnix {
+					t.Fatalf("Flags = %#x, want %#x", r.Flags, flags|FlagOSUnix)
+				}
+			})
+		}
+	}
+}
+
+func TestHeaderMetadataRoundTrip(t *testing.T) {
+	modTime := time.Date(2026, 6, 21, 12, 30, 45, 0, time.UTC)
+	file := writeFile(t, []byte("metadata"), &lzo.CompressOptions{Level: 7}, func(h *Header) {
+		h.Name = "payload.bin"
+		h.ModTime = modTime
+		h.Mode = 0o100640
+		h.Extra = []byte("extra-field")
+	})
+
+	r, err := NewReader(bytes.NewReader(file))
+	if err != nil {
+		t.Fatalf("NewReader failed: %v", err)
+	}
+
+	if r.Name != "payload.bin" || !r.ModTime.Equal(modTime) || r.Mode != 0o100640 {
+		t.Fatalf("unexpected metadata: name=%q mtime=%v mode=%o", r.Name, r.ModTime, r.Mode)
+	}
+	if string(r.Extra) != "extra-field" || r.Flags&FlagExtraField == 0 {
+		t.Fatalf("unexpected extra field %q (flags %#x)", r.Extra, r.Flags)
+	}
+	if r.Method != MethodLZO1X999 || r.Level != 7 {
+		t.Fatalf("method/level = %d/%d, want %d/7", r.Method, r.Level, MethodLZO1X999)
+	}
+	if r.Version != writerVersion || r.LibVersion != writerLibVersion || r.VersionNeeded != writerVersionNeeded {
+		t.Fatalf("unexpected versions: %#x %#x %#x", r.Version, r.LibVersion, r.VersionNeeded)
+	}
+	if out, err := io.ReadAll(r); err != nil || string(out) != "metadata" {
+		t.Fatalf("ReadAll: %q, %v", out, err)
+	}
+}
+
+// handcraftedFile builds a member field by field as described by the lzop format.
+// Versions before versionWithLevel have no version-needed, level or mtime-high
+// fields; extra is written as the extra field when flags has FlagExtraField.
+func handcraftedFile(t *testing.T, version uint16, flags uint32, extra []byte, blocks [][]byte) ([]byte, []byte) {
+	t.Helper()
+
+	sum := adler32.Checksum
+	if flags&FlagHeaderCRC32 != 0 {
+		sum = crc32.ChecksumIEEE
+	}
+
+	file := append([]byte(nil), magic[:]...)
+	hdr := binary.BigEndian.AppendUint16(nil, version)
+	hdr = binary.BigEndian.AppendUint16(hdr, 0x2080) // library version
+	if version >= versionWithLevel {
+		hdr = binary.BigEndian.AppendUint16(hdr, 0x0940) // version needed to extract
+	}
+	hdr = append(hdr, byte(MethodLZO1X1))
+	if version >= versionWithLevel {
+		hdr = append(hdr, 3)
+	}
+	hdr = binary.BigEndian.AppendUint32(hdr, flags)
+	hdr = binary.BigEndian.AppendUint32(hdr, 0o100644)   // mode
+	hdr = binary.BigEndian.AppendUint32(hdr, 1750000000) // mtime low
+	if version >= versionWithLevel {
+		hdr = binary.BigEndian.AppendUint32(hdr, 0) // mtime high
+	}
+	hdr = append(hdr, 5)
+	hdr = append(hdr, "a.txt"...)
+	file = append(file, hdr...)
+	file = binary.BigEndian.AppendUint32(file, sum(hdr))
+
+	if flags&FlagExtraField != 0 {
+		field := binary.BigEndian.AppendUint32(nil, uint32(len(extra)))
+		field = append(field, extra...)
+		file = append(file, field...)
+		file = binary.BigEndian.AppendUint32(file, sum(field))
+	}
+
+	var want []byte
+	for _, raw := range blocks {
+		want = append(want, raw...)
+		compressed, err := lzo.Compress(raw, nil)
+		if err != nil {
+			t.Fatalf("Compress failed: %v", err)
+		}
+		stored := len(compressed) >= len(raw)
+		if stored {
+			compressed = raw
+		}
+
+		file = binary.BigEndian.AppendUint32(file, uint32(len(raw)))
+		file = binary.BigEndian.AppendUint32(file, uint32(len(compressed)))
+		if flags&FlagAdler32D != 0 {
+			file = binary.BigEndian.AppendUint32(file, adler32.Checksum(raw))
+		}
+		if flags&FlagCRC32D != 0 {
+			file = binary.BigEndian.AppendUint32(file, crc32.ChecksumIEEE(raw))
+		}
+		// Stored blocks have no compressed-data checksums.
+		if flags&FlagAdler32C != 0 && !stored {
+			file = binary.BigEndian.AppendUint32(file, adler32.Checksum(compressed))
+		}
+		if flags&FlagCRC32C != 0 && !stored {
+			file = binary.BigEndian.AppendUint32(file, crc32.ChecksumIEEE(compressed))
+		}
+		file = append(file, compressed...)
+	}
+	file = binary.BigEndian.AppendUint32(file, 0)
+
+	return file, want
+}
+
+func TestReaderHandcraftedFile(t *testing.T) {
+	blocks := [][]byte{
+		bytes.Repeat([]byte("compressible "), 100),
+		{0x01, 0x02, 0x03},
+		bytes.Repeat([]byte("compressible again "), 50),
+	}
+	for _, flags := range []uint32{
+		FlagAdler32D | FlagCRC32D | FlagCRC32C,
+		FlagAdler32D | FlagAdler32C,
+		FlagAdler32D | FlagAdler32C | FlagCRC32D | FlagCRC32C,
+		FlagCRC32D | FlagCRC32C | FlagHeaderCRC32,
+	} {
+		file, want := handcraftedFile(t, 0x1030, flags|FlagOSUnix, nil, blocks)
+
+		r, err := NewReader(bytes.NewReader(file))
+		if err != nil {
+			t.Fatalf("flags %#x: NewReader failed: %v", flags, err)
+		}
+		if r.Name != "a.txt" || r.Version != 0x1030 || r.Method != MethodLZO1X1 || r.Level != 3 {
+			t.Fatalf("flags %#x: unexpected header: %+v", flags, r.Header)
+		}
+		if r.ModTime.Unix() != 1750000000 || r.Mode != 0o100644 {
+			t.Fatalf("flags %#x: unexpected mtime/mode: %v %o", flags, r.ModTime, r.Mode)
+		}
+
+		out, err := io.ReadAll(r)
+		if err != nil {
+			t.Fatalf("flags %#x: ReadAll failed: %v", flags, err)
+		}
+		if !bytes.Equal(out, want) {
+			t.Fatalf("flags %#x: decoded output mismatch: got=%d want=%d", flags, len(out), len(want))
+		}
+	}
+}
+
+func TestReaderVersionGatedFields(t *testing.T) {
+	// lzop before 0.94 wrote no version-needed, level or mtime-high fields.
+	file, want := handcraftedFile(t, 0x0900, FlagAdler32D|FlagOSUnix, nil, [][]byte{[]byte("old header")})
+	r, err := NewReader(bytes.NewReader(file))
+	if err != nil {
+		t.Fatalf("NewReader failed: %v", err)
+	}
+	if r.Version != 0x0900 || r.VersionNeeded != 0 || r.Level != 0 || r.Method != MethodLZO1X1 {
+		t.Fatalf("unexpected header: %+v", r.Header)
+	}
+	if r.ModTime.Unix() != 1750000000 || r.Name != "a.txt" {
+		t.Fatalf("unexpected mtime/name: %v %q", r.ModTime, r.Name)
+	}
+	if out, err := io.ReadAll(r); err != nil || !bytes.Equal(out, want) {
+		t.Fatalf("ReadAll: %q, %v", out, err)
+	}
+
+	// Members that need a newer lzop than this package writes are rejected.
+	file, _ = handcraftedFile(t, 0x1030, FlagAdler32D|FlagOSUnix, nil, [][]byte{[]byte("new header")})
+	binary.BigEndian.PutUint16(file[len(magic)+4:], writerVersion+1)
+	if _, err := NewReader(bytes.NewReader(file)); !errors.Is(err, ErrUnsupported) {
+		t.Fatalf("newer version needed: expected ErrUnsupported, got %v", err)
+	}
+}
+
+func TestReaderExtraFieldChecksum(t *testing.T) {
+	extra := []byte("extra field payload")
+	for _, flags := range []uint32{FlagAdler32D, FlagAdler32D | FlagHeaderCRC32} {
+		file, want := handcraftedFile(t, 0x1030, flags|FlagExtraField|FlagOSUnix, extra, [][]byte{[]byte("data")})
+
+		r, err := NewReader(bytes.NewReader(file))
+		if err != nil {
+			t.Fatalf("flags %#x: NewReader failed: %v", flags, err)
+		}
+		if !bytes.Equal(r.Extra, extra) {
+			t.Fatalf("flags %#x: Extra = %q, want %q", flags, r.Extra, extra)
+		}
+		if out, err := io.ReadAll(r); err != nil || !bytes.Equal(out, want) {
+			t.Fatalf("flags %#x: ReadAll: %q, %v", flags, out, err)
+		}
+
+		// The extra field has its own checksum after the header checksum.
+		extraAt := bytes.Index(file, extra)
+		for _, pos := range []int{extraAt + 5, extraAt + len(extra) + 1} {
+			bad := append([]byte(nil), file...)
+			bad[pos] ^= 0x10
+			if _, err := NewReader(bytes.NewReader(bad)); !errors.Is(err, ErrChecksum) {
+				t.Fatalf("flags %#x: corrupt byte %d: expected ErrChecksum, got %v", flags, pos, err)
+			}
+		}
+	}
+}
+
+func TestWriterHeaderLayout(t *testing.T) {
+	file := writeFile(t, nil, nil, func(h *Header) {
+		h.Name = "a.txt"
+		h.Mode = 0o100644
+		h.ModTime = time.Unix(1750000000, 0)
+	})
+
+	hdr := []byte{0x10, 0x40, 0x20, 0xa0, 0x09, 0x40, byte(MethodLZO1X1), 5}
+	hdr = binary.BigEndian.AppendUint32(hdr, FlagAdler32D|FlagOSUnix)
+	hdr = binary.BigEndian.AppendUint32(hdr, 0o100644)
+	hdr = binary.BigEndian.AppendUint32(hdr, 1750000000)
+	hdr = binary.BigEndian.AppendUint32(hdr, 0)
+	hdr = append(hdr, 5)
+	hdr = append(hdr, "a.txt"...)
+
+	want := append(append([]byte(nil), magic[:]...), hdr...)
+	want = binary.BigEndian.AppendUint32(want, adler32.Checksum(hdr))
+	want = binary.BigEndian.AppendUint32(want, 0)
+	if !bytes.Equal(file, want) {
+		t.Fatalf("header layout mismatch:\n got % x\nwant % x", file, want)
+	}
+}
+
+func TestWriterRecordsMethod(t *testing.T) {
+	data := mixedBytes(100 << 10)
+	tests := []struct {
+		opts   lzo.CompressOptions
+		method Method
+		level  uint8
+	}{
+		{opts: lzo.CompressOptions{Level: 1}, method: MethodLZO1X1, level: 5},
+		{opts: lzo.CompressOptions{Level: 8}, method: MethodLZO1X999, level: 8},
+		{opts: lzo.CompressOptions{Method: lzo.MethodLZO1X1_15}, method: MethodLZO1X1_15, level: 1},
+		{opts: lzo.CompressOptions{Method: lzo.MethodLZO1X1_11}, method: MethodLZO1X1, level: 5},
+		{opts: lzo.CompressOptions{Method: lzo.MethodLZO1X999}, method: MethodLZO1X999, level: 1},
+		{opts: lzo.CompressOptions{Level: 1, Optimal: true}, method: MethodLZO1X999, level: 1},
+	}
+	for _, tt := range tests {
+		file := writeFile(t, data, &tt.opts, nil)
+		r, err := NewReader(bytes.NewReader(file))
+		if err != nil {
+			t.Fatalf("method %d: NewReader failed: %v", tt.opts.Method, err)
+		}
+		if r.Method != tt.method || r.Level != tt.level {
+			t.Fatalf("method %d: recorded %d/%d, want %d/%d", tt.opts.Method, r.Method, r.Level, tt.method, tt.level)
+		}
+		if out, err := io.ReadAll(r); err != nil || !bytes.Equal(out, data) {
+			t.Fatalf("method %d: ReadAll failed: %v", tt.opts.Method, err)
+		}
+	}
+}
+
+func TestStoredBlocks(t *testing.T) {
+	data := randomBytes(1 << 10)
+	file := writeFile(t, data, nil, func(h *Header) {
+		h.Flags |= FlagAdler32C
+	})
+
+	// Stored blocks carry sizes and the raw checksum only, followed by the raw data.
+	block := file[len(file)-4-len(data)-3*4:]
+	if got := binary.BigEndian.Uint32(block); got != uint32(len(data)) {
+		t.Fatalf("raw size = %d, want %d", got, len(data))
+	}
+	if got := binary.BigEndian.Uint32(block[4:]); got != uint32(len(data)) {
+		t.Fatalf("stored size = %d, want %d", got, len(data))
+	}
+	if got := binary.BigEndian.Uint32(block[8:]); got != adler32.Checksum(data) {
+		t.Fatalf("raw checksum = %#x, want %#x", got, adler32.Checksum(data))
+	}
+	if !bytes.Equal(block[12:12+len(data)], data) {
+		t.Fatal("stored block data differs from input")
+	}
+
+	r, err := NewReader(bytes.NewReader(file))
+	if err != nil {
+		t.Fatalf("NewReader failed: %v", err)
+	}
+	if out, err := io.ReadAll(r); err != nil || !bytes.Equal(out, data) {
+		t.Fatalf("ReadAll: %d bytes, %v", len(out), err)
+	}
+}
+
+func TestMultiMemberFiles(t *testing.T) {
+	first := writeFile(t, []byte("first member;"), nil, func(h *Header) { h.Name = "one" })
+	second := writeFile(t, []byte("second member"), &lzo.CompressOptions{Level: 9}, func(h *Header) { h.Name = "two" })
+	file := append(append([]byte(nil), first...), second...)
+
+	r, err := NewReader(bytes.NewReader(file))
+	if err != nil {
+		t.Fatalf("NewReader failed: %v", err)
+	}
+	out, err := io.ReadAll(r)
+	if err != nil {
+		t.Fatalf("ReadAll failed: %v", err)
+	}
+	if string(out) != "first member;second member" {
+		t.Fatalf("multistream output = %q", out)
+	}
+	if r.Name != "two" {
+		t.Fatalf("Header after last member = %q, want %q", r.Name, "two")
+	}
+
+	src := bytes.NewReader(file)
+	r, err = NewReader(src)
+	if err != nil {
+		t.Fatalf("NewReader failed: %v", err)
+	}
+	r.Multistream(false)
+	if out, err := io.ReadAll(r); err != nil || string(out) != "first member;" {
+		t.Fatalf("single member: %q, %v", out, err)
+	}
+	if err := r.Reset(src); err != nil {
+		t.Fatalf("Reset to second member failed: %v", err)
+	}
+	if out, err := io.ReadAll(r); err != nil || string(out) != "second member" || r.Name != "two" {
+		t.Fatalf("second member: %q (%q), %v", out, r.Name, err)
+	}
+}
+
+func TestReaderErrors(t *testing.T) {
+	file := writeFile(t, bytes.Repeat([]byte("checksum "), 500), nil, func(h *Header) {
+		h.Name = "file"
+		h.Flags |= FlagCRC32C
+	})
+	headerEnd := len(magic) + 8 + 4*4 + 1 + len("file") + 4
+
+	corrupt := func(pos int) []byte {
+		b := append([]byte(nil), file...)
+		b[pos] ^= 0x40
+		return b
+	}
+	methodAt := len(magic) + 6
+	unsupported := append([]byte(nil), file...)
+	unsupported[methodAt] = 0x2b
+	hdr := unsupported[len(magic) : headerEnd-4]
+	binary.BigEndian.PutUint32(unsupported[headerEnd-4:], adler32.Checksum(hdr))
+
+	for _, tc := range []struct {
+		name string
+		file []byte
+		want error
+	}{
+		{name: "empty", file: nil, want: lzo.ErrEmptyInput},
+		{name: "not-lzop", file: []byte("plain text, not an lzop file"), want: ErrHeader},
+		{name: "header-checksum", file: corrupt(headerEnd - 6), want: ErrChecksum},
+		{name: "raw-checksum", file: corrupt(headerEnd + 9), want: ErrChecksum},
+		{name: "compressed-data", file: corrupt(len(file) - 10), want: ErrChecksum},
+		{name: "unsupported-method", file: unsupported, want: ErrUnsupported},
+		{name: "truncated", file: file[:len(file)-5], want: lzo.ErrUnexpectedEOF},
+		{name: "missing-end-marker", file: file[:len(file)-4], want: lzo.ErrUnexpectedEOF},
+	} {
+		r, err := NewReader(bytes.NewReader(tc.file))
+		if err == nil {
+			_, err = io.ReadAll(r)
+		}
+		if !errors.Is(err, tc.want) {
+			t.Fatalf("%s: expected %v, got %v", tc.name, tc.want, err)
+		}
+	}
+}
+
+func TestWriterRejectsLongName(t *testing.T) {
+	w := NewWriter(io.Discard, nil)
+	w.Name = string(bytes.Repeat([]byte("n"), 256))
+	if _, err := w.Write([]byte("x")); !errors.Is(err, ErrHeader) {
+		t.Fatalf("expected ErrHeader, got %v", err)
+	}
+}
@@ -0,0 +1,387 @@
+// SPDX-License-Identifier: MIT
+// Copyright (c) 2026 WoozyMasta
+// Source: github.com/woozymasta/lzo
+
+package lzop
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"hash/adler32"
+	"hash/crc32"
+	"io"
+	"time"
+
+	"github.com/woozymasta/lzo"
+)
+
+// Reader is an io.Reader that decompresses an lzop file.
+// Header holds the metadata of the member currently being read.
+// By default concatenated members are read as one stream; see Multistream.
+// A Reader must not be used concurrently.
+type Reader struct {
+	r   io.Reader
+	err error
+	src []byte // src is the reusable stored data of the current block.
+	buf []byte // buf is the reusable decoded block.
+	out []byte // out is the unread part of the current block.
+	hdr []byte // hdr collects raw header bytes for checksum verification.
+
+	Header
+
+	multistream bool
+}
+
+// NewReader reads the first member header from r and returns a Reader for its data.
+// It returns lzo.ErrEmptyInput when r is empty and ErrHeader when r is not an lzop file.
+func NewReader(r io.Reader) (*Reader, error) {
+	z := &Reader{}
+	if err := z.Reset(r); err != nil {
+		return nil, err
+	}
+
+	return z, nil
+}
+
+// Reset discards state, reads a new first member header from r and enables multistream mode.
+// Internal buffers are retained.
+func (z *Reader) Reset(r io.Reader) error {
+	z.r = r
+	z.out = nil
+	z.multistream = true
+
+	z.err = z.readHeader(true)
+	return z.err
+}
+
+// Multistream controls whether members following the first one are read.
+// When disabled, Read returns io.EOF at the end of the current member and
+// the underlying reader is left positioned right after it.
+func (z *Reader) Multistream(ok bool) {
+	z.multistream = ok
+}
+
+// Read reads decompressed data into p.
+func (z *Reader) Read(p []byte) (int, error) {
+	for len(z.out) == 0 {
+		if z.err != nil {
+			return 0, z.err
+		}
+		z.err = z.nextBlock()
+	}
+
+	n := copy(p, z.out)
+	z.out = z.out[n:]
+	return n, nil
+}
+
+// readHeader reads the magic and header of one member into z.Header.
+// It returns io.EOF when r is exhausted before a following member starts.
+func (z *Reader) readHeader(first bool) error {
+	var sig [len(magic)]byte
+	n, err := io.ReadFull(z.r, sig[:])
+	if n == 0 && errors.Is(err, io.EOF) {
+		if first {
+			return lzo.ErrEmptyInput
+		}
+		return io.EOF
+	}
+	if err != nil {
+		return readError(err)
+	}
+	if sig != magic {
+		return ErrHeader
+	}
+
+	z.hdr = z.hdr[:0]
+	h := Header{}
+	if h.Version, err = z.headerUint16(); err != nil {
+		return err
+	}
+	if h.LibVersion, err = z.headerUint16(); err != nil {
+		return err
+	}
+	if h.Version >= versionWithLevel {
+		if h.VersionNeeded, err = z.headerUint16(); err != nil {
+			return err
+		}
+		if h.VersionNeeded > writerVersion {
+			return ErrUnsupported
+		}
+	}
+
+	method, err := z.headerBytes(1)
+	if err != nil {
+		return err
+	}
+	h.Method = Method(method[0])
+	if h.Version >= versionWithLevel {
+		level, err := z.headerBytes(1)
+		if err != nil {
+			return err
+		}
+		h.Level = level[0]
+	}
+
+	if h.Flags, err = z.headerUint32(); err != nil {
+		return err
+	}
+	if h.Flags&FlagFilter != 0 {
+		if h.Filter, err = z.headerUint32(); err != nil {
+			return err
+		}
+	}
+	if h.Mode, err = z.headerUint32(); err != nil {
+		return err
+	}
+
+	mtimeLow, err := z.headerUint32()
+	if err != nil {
+		return err
+	}
+	var mtimeHigh uint32
+	if h.Version >= versionWithLevel {
+		if mtimeHigh, err = z.headerUint32(); err != nil {
+			return err
+		}
+	}
+	if mtime := int64(mtimeLow) | int64(mtimeHigh)<<32; mtime != 0 {
+		h.ModTime = time.Unix(mtime, 0)
+	}
+
+	nameLen, err := z.headerBytes(1)
+	if err != nil {
+		return err
+	}
+	name, err := z.headerBytes(int(nameLen[0]))
+	if err != nil {
+		return err
+	}
+	h.Name = string(name)
+
+	if err := z.verifyHeaderChecksum(h.Flags, 0); err != nil {
+		return err
+	}
+
+	if h.Flags&FlagExtraField != 0 {
+		start := len(z.hdr)
+		extraLen, err := z.headerUint32()
+		if err != nil {
+			return err
+		}
+		var extra bytes.Buffer
+		if _, err := io.CopyN(&extra, z.r, int64(extraLen)); err != nil {
+			return readError(err)
+		}
+		h.Extra = extra.Bytes()
+		z.hdr = append(z.hdr, h.Extra...)
+		if err := z.verifyHeaderChecksum(h.Flags, start); err != nil {
+			return err
+		}
+	}
+
+	switch h.Method {
+	case MethodLZO1X1, MethodLZO1X1_15, MethodLZO1X999:
+	default:
+		return ErrUnsupported
+	}
+	if h.Filter != 0 {
+		return ErrUnsupported
+	}
+
+	z.Header = h
+	return nil
+}
+
+// verifyHeaderChecksum reads the stored checksum of z.hdr[start:] and compares it.
+func (z *Reader) verifyHeaderChecksum(flags uint32, start int) error {
+	want := headerChecksum(flags, z.hdr[start:])
+	got, err := z.readUint32()
+	if err != nil {
+		return err
+	}
+	if got != want {
+		return ErrChecksum
+	}
+
+	return nil
+}
+
+// nextBlock reads and decodes the next block into z.out.
+// At the end of a member it continues with the next member in multistream mode.
+func (z *Reader) nextBlock() error {
+	rawLen, err := z.readUint32()
+	if err != nil {
+		return err
+	}
+	if rawLen == 0 {
+		if !z.multistream {
+			return io.EOF
+		}
+		return z.readHeader(false)
+	}
+	if rawLen > maxBlockSize {
+		return ErrInvalidBlock
+	}
+
+	storedLen, err := z.readUint32()
+	if err != nil {
+		return err
+	}
+	if storedLen == 0 || storedLen > rawLen {
+		return ErrInvalidBlock
+	}
+	compressed := storedLen < rawLen
+
+	var sums blockChecksums
+	if err := z.readBlockChecksums(&sums, compressed); err != nil {
+		return err
+	}
+
+	z.src = resizeBuffer(z.src, int(storedLen))
+	if _, err := io.ReadFull(z.r, z.src); err != nil {
+		return readError(err)
+	}
+
+	if !compressed {
+		z.out = z.src
+		return z.verifyBlock(&sums, z.out, false)
+	}
+
+	if err := z.verifyBlock(&sums, z.src, true); err != nil {
+		return err
+	}
+	z.buf = resizeBuffer(z.buf, int(rawLen))
+	out, nRead, err := lzo.DecompressNInto(z.src, z.buf)
+	if err != nil {
+		return err
+	}
+	if nRead != len(z.src) || len(out) != len(z.buf) {
+		return ErrInvalidBlock
+	}
+
+	z.out = out
+	return z.verifyBlock(&sums, z.out, false)
+}
+
+// blockChecksums holds the checksums stored in one block header.
+type blockChecksums struct {
+	adlerRaw, crcRaw               uint32
+	adlerCompressed, crcCompressed uint32
+}
+
+// readBlockChecksums reads the checksums selected by the member flags.
+// Compressed-data checksums are only stored for blocks that were compressed.
+func (z *Reader) readBlockChecksums(sums *blockChecksums, compressed bool) error {
+	fields := []struct {
+		dst  *uint32
+		flag uint32
+		skip bool
+	}{
+		{dst: &sums.adlerRaw, flag: FlagAdler32D},
+		{dst: &sums.crcRaw, flag: FlagCRC32D},
+		{dst: &sums.adlerCompressed, flag: FlagAdler32C, skip: !compressed},
+		{dst: &sums.crcCompressed, flag: FlagCRC32C, skip: !compressed},
+	}
+	for _, field := range fields {
+		if z.Flags&field.flag == 0 || field.skip {
+			continue
+		}
+
+		v, err := z.readUint32()
+		if err != nil {
+			return err
+		}
+		*field.dst = v
+	}
+
+	return nil
+}
+
+// verifyBlock checks data against the raw or compressed checksums selected by the member flags.
+func (z *Reader) verifyBlock(sums *blockChecksums, data []byte, compressed bool) error {
+	adlerFlag, crcFlag := uint32(FlagAdler32D), uint32(FlagCRC32D)
+	adlerWant, crcWant := sums.adlerRaw, sums.crcRaw
+	if compressed {
+		adlerFlag, crcFlag = FlagAdler32C, FlagCRC32C
+		adlerWant, crcWant = sums.adlerCompressed, sums.crcCompressed
+	}
+
+	if z.Flags&adlerFlag != 0 && adler32.Checksum(data) != adlerWant {
+		return ErrChecksum
+	}
+	if z.Flags&crcFlag != 0 && crc32.ChecksumIEEE(data) != crcWant {
+		return ErrChecksum
+	}
+
+	return nil
+}
+
+// headerBytes reads n header bytes and records them for the header checksum.
+func (z *Reader) headerBytes(n int) ([]byte, error) {
+	start := len(z.hdr)
+	z.hdr = append(z.hdr, make([]byte, n)...)
+	if _, err := io.ReadFull(z.r, z.hdr[start:]); err != nil {
+		return nil, readError(err)
+	}
+
+	return z.hdr[start:], nil
+}
+
+// headerUint16 reads one big-endian header uint16.
+func (z *Reader) headerUint16() (uint16, error) {
+	b, err := z.headerBytes(2)
+	if err != nil {
+		return 0, err
+	}
+
+	return binary.BigEndian.Uint16(b), nil
+}
+
+// headerUint32 reads one big-endian header uint32.
+func (z *Reader) headerUint32() (uint32, error) {
+	b, err := z.headerBytes(4)
+	if err != nil {
+		return 0, err
+	}
+
+	return binary.BigEndian.Uint32(b), nil
+}
+
+// readUint32 reads one big-endian uint32 that is not part of the header checksum.
+func (z *Reader) readUint32() (uint32, error) {
+	var b [4]byte
+	if _, err := io.ReadFull(z.r, b[:]); err != nil {
+		return 0, readError(err)
+	}
+
+	return binary.BigEndian.Uint32(b[:]), nil
+}
+
+// headerChecksum returns the Adler-32 or CRC-32 (FlagHeaderCRC32) of header bytes.
+func headerChecksum(flags uint32, data []byte) uint32 {
+	if flags&FlagHeaderCRC32 != 0 {
+		return crc32.ChecksumIEEE(data)
+	}
+
+	return adler32.Checksum(data)
+}
+
+// readError maps a short read inside a member to lzo.ErrUnexpectedEOF.
+func readError(err error) error {
+	if errors.Is(err, io.EOF) || errors.Is(err, io.ErrUnexpectedEOF) {
+		return lzo.ErrUnexpectedEOF
+	}
+
+	return err
+}
+
+// resizeBuffer returns buf resized to n bytes, reallocating only when capacity is insufficient.
+func resizeBuffer(buf []byte, n int) []byte {
+	if cap(buf) < n {
+		return make([]byte, n)
+	}
+
+	return buf[:n]
+}
@@ -0,0 +1,63 @@
+#!/bin/sh
+# SPDX-License-Identifier: MIT
+# Copyright (c) 2026 WoozyMasta
+# Source: github.com/woozymasta/lzo
+#
+# Regenerates the lzop 1.0x fixtures read by fixtures_test.go.
+# Needs the lzop program (make testdata-fixtures runs it in the compat image).
+# Every member is written from a file with mode 0644 and mtime 1700000000;
+# fixtures.json records the options each member was written with.
+
+set -eu
+
+cd "$(dirname "$0")"
+corpus=../../testdata/corpus
+
+command -v lzop >/dev/null || {
+	echo "lzop not found" >&2
+	exit 1
+}
+
+rm -f ./*.lzo text.txt random.bin fixtures.json
+
+cat "$corpus/lorem-ipsum.txt" "$corpus/long-repeated.txt" "$corpus/json.txt" "$corpus/mixed.txt" >text.txt
+# Random data does not compress, so lzop stores its block.
+head -c 16384 /dev/urandom >random.bin
+chmod 0644 text.txt random.bin
+touch -d @1700000000 text.txt random.bin
+
+# member <output> <input> <options...> compresses input into output.
+member() {
+	out=$1
+	in=$2
+	shift 2
+	lzop -q -f "$@" -o "$out" "$in"
+}
+
+member text-1.lzo text.txt -1
+member text-9.lzo text.txt -9
+member text-3-nochecksum.lzo text.txt -3 -F
+member text-5-crc32.lzo text.txt -5 --crc32
+member random-stored.lzo random.bin -3
+member random-stored-crc32.lzo random.bin -3 --crc32
+member multi-a.lzo text.txt -1
+member multi-b.lzo random.bin -9 --crc32
+cat multi-a.lzo multi-b.lzo >multi.lzo
+rm -f multi-a.lzo multi-b.lzo
+
+cat >fixtures.json <<'EOF'
+[
+  {"file": "text-1.lzo", "members": [{"input": "text.txt", "method": 2, "level": 1, "checksum": "adler32"}]},
+  {"file": "text-9.lzo", "members": [{"input": "text.txt", "method": 3, "level": 9, "checksum": "adler32"}]},
+  {"file": "text-3-nochecksum.lzo", "members": [{"input": "text.txt", "method": 1, "level": 3, "checksum": "none"}]},
+  {"file": "text-5-crc32.lzo", "members": [{"input": "text.txt", "method": 1, "level": 5, "checksum": "crc32"}]},
+  {"file": "random-stored.lzo", "stored": true, "members": [{"input": "random.bin", "method": 1, "level": 3, "checksum": "adler32"}]},
+  {"file": "random-stored-crc32.lzo", "stored": true, "members": [{"input": "random.bin", "method": 1, "level": 3, "checksum": "crc32"}]},
+  {"file": "multi.lzo", "members": [
+    {"input": "text.txt", "method": 2, "level": 1, "checksum": "adler32"},
+    {"input": "random.bin", "method": 3, "level": 9, "checksum": "crc32"}
+  ]}
+]
+EOF
+
+lzop -V 2>&1 | head -n 1
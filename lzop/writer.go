@@ -0,0 +1,275 @@
+// SPDX-License-Identifier: MIT
+// Copyright (c) 2026 WoozyMasta
+// Source: github.com/woozymasta/lzo
+
+package lzop
+
+import (
+	"encoding/binary"
+	"hash/adler32"
+	"hash/crc32"
+	"io"
+	"math"
+
+	"github.com/woozymasta/lzo"
+)
+
+// Writer is an io.WriteCloser that compresses data into an lzop file.
+// Header fields may be set before the first Write, Flush or Close.
+// Version fields are always written by Writer; Method and Level default to the
+// values matching the compression level. Block checksums are selected with the
+// FlagAdler32D, FlagCRC32D, FlagAdler32C and FlagCRC32C bits of Header.Flags
+// (FlagAdler32D by default, like lzop).
+// A Writer must not be used concurrently.
+type Writer struct {
+	w    io.Writer
+	err  error
+	buf  []byte // buf holds pending input that does not fill a whole block yet.
+	cmp  []byte // cmp is the reusable compressed block.
+	out  []byte // out is the reusable encoded block (sizes, checksums and data).
+	enc  lzo.Encoder
+	opts lzo.CompressOptions
+
+	Header
+
+	blockSize   int
+	wroteHeader bool
+	closed      bool
+}
+
+// NewWriter returns a Writer that compresses to w. opts may be nil (uses default level 1).
+// opts.BlockSize selects the block size (0 = lzo.DefaultBlockSize, lzop's default of 256 KiB);
+// opts.Dict is ignored.
+func NewWriter(w io.Writer, opts *lzo.CompressOptions) *Writer {
+	if opts == nil {
+		opts = lzo.DefaultCompressOptions()
+	}
+
+	z := &Writer{opts: *opts}
+	// lzop files have no preset dictionary.
+	z.opts.Dict = nil
+	z.blockSize = opts.BlockSize
+	if z.blockSize <= 0 {
+		z.blockSize = lzo.DefaultBlockSize
+	}
+	z.blockSize = min(z.blockSize, maxBlockSize)
+	z.Reset(w)
+	return z
+}
+
+// Reset discards pending data, resets Header to defaults and makes z write a new file to w.
+// Compression options and internal buffers are retained.
+func (z *Writer) Reset(w io.Writer) {
+	z.w = w
+	z.err = nil
+	z.buf = z.buf[:0]
+	z.wroteHeader = false
+	z.closed = false
+
+	z.Header = Header{Flags: FlagAdler32D | FlagOSUnix}
+	z.Method, z.Level = methodForOptions(&z.opts)
+}
+
+// Write buffers p and writes every completed block to the underlying writer.
+func (z *Writer) Write(p []byte) (int, error) {
+	if err := z.ensureHeader(); err != nil {
+		return 0, err
+	}
+
+	written := 0
+	for len(p) > 0 {
+		// Whole blocks are compressed straight from p without staging them in buf.
+		if len(z.buf) == 0 && len(p) >= z.blockSize {
+			if err := z.writeBlock(p[:z.blockSize]); err != nil {
+				return written, err
+			}
+			written += z.blockSize
+			p = p[z.blockSize:]
+			continue
+		}
+
+		if z.buf == nil {
+			z.buf = make([]byte, 0, z.blockSize)
+		}
+		n := min(len(p), z.blockSize-len(z.buf))
+		z.buf = append(z.buf, p[:n]...)
+		written += n
+		p = p[n:]
+
+		if len(z.buf) == z.blockSize {
+			if err := z.writeBlock(z.buf); err != nil {
+				return written, err
+			}
+			z.buf = z.buf[:0]
+		}
+	}
+
+	return written, nil
+}
+
+// Flush writes the header if needed and any pending data as a (possibly short) block.
+func (z *Writer) Flush() error {
+	if err := z.ensureHeader(); err != nil {
+		return err
+	}
+	if len(z.buf) == 0 {
+		return nil
+	}
+
+	if err := z.writeBlock(z.buf); err != nil {
+		return err
+	}
+	z.buf = z.buf[:0]
+	return nil
+}
+
+// Close flushes pending data and writes the end-of-file marker.
+// It does not close the underlying writer. Calling Close again is a no-op.
+func (z *Writer) Close() error {
+	if z.closed {
+		return z.err
+	}
+	if err := z.Flush(); err != nil {
+		return err
+	}
+
+	z.closed = true
+	var end [4]byte
+	z.err = z.writeAll(end[:])
+	return z.err
+}
+
+// ensureHeader reports sticky errors and writes the header before the first block.
+func (z *Writer) ensureHeader() error {
+	if z.err != nil {
+		return z.err
+	}
+	if z.closed {
+		return lzo.ErrWriterClosed
+	}
+	if z.wroteHeader {
+		return nil
+	}
+
+	z.wroteHeader = true
+	hdr, err := z.appendHeader(z.out[:0])
+	if err != nil {
+		z.err = err
+		return err
+	}
+	z.out = hdr
+
+	z.err = z.writeAll(hdr)
+	return z.err
+}
+
+// appendHeader appends the magic and the encoded Header to dst.
+func (z *Writer) appendHeader(dst []byte) ([]byte, error) {
+	if len(z.Name) > math.MaxUint8 || z.Filter != 0 {
+		return nil, ErrHeader
+	}
+
+	flags := z.Flags &^ (FlagFilter | FlagExtraField)
+	if z.Extra != nil {
+		flags |= FlagExtraField
+	}
+
+	var mtime int64
+	if !z.ModTime.IsZero() {
+		mtime = z.ModTime.Unix()
+	}
+
+	dst = append(dst, magic[:]...)
+	start := len(dst)
+	dst = binary.BigEndian.AppendUint16(dst, writerVersion)
+	dst = binary.BigEndian.AppendUint16(dst, writerLibVersion)
+	dst = binary.BigEndian.AppendUint16(dst, writerVersionNeeded)
+	dst = append(dst, byte(z.Method), z.Level)
+	dst = binary.BigEndian.AppendUint32(dst, flags)
+	dst = binary.BigEndian.AppendUint32(dst, z.Mode)
+	dst = binary.BigEndian.AppendUint32(dst, uint32(mtime))     //nolint:gosec // G115: low half of the 64-bit mtime
+	dst = binary.BigEndian.AppendUint32(dst, uint32(mtime>>32)) //nolint:gosec // G115: high half of the 64-bit mtime
+	dst = append(dst, byte(len(z.Name)))
+	dst = append(dst, z.Name...)
+	dst = binary.BigEndian.AppendUint32(dst, headerChecksum(flags, dst[start:]))
+
+	if z.Extra != nil {
+		if uint64(len(z.Extra)) > math.MaxUint32 {
+			return nil, ErrHeader
+		}
+		start = len(dst)
+		dst = binary.BigEndian.AppendUint32(dst, uint32(len(z.Extra))) //nolint:gosec // G115: checked above
+		dst = append(dst, z.Extra...)
+		dst = binary.BigEndian.AppendUint32(dst, headerChecksum(flags, dst[start:]))
+	}
+
+	return dst, nil
+}
+
+// writeBlock compresses one block and writes it with its sizes and checksums.
+// Blocks that do not shrink are stored raw without compressed-data checksums.
+func (z *Writer) writeBlock(block []byte) error {
+	compressed, err := z.enc.AppendCompress(z.cmp[:0], block, &z.opts)
+	if err != nil {
+		z.err = err
+		return err
+	}
+	z.cmp = compressed
+
+	stored := len(compressed) >= len(block)
+	if stored {
+		compressed = block
+	}
+
+	out := binary.BigEndian.AppendUint32(z.out[:0], uint32(len(block))) //nolint:gosec // G115: bounded by maxBlockSize
+	out = binary.BigEndian.AppendUint32(out, uint32(len(compressed)))   //nolint:gosec // G115: bounded by block size
+	if z.Flags&FlagAdler32D != 0 {
+		out = binary.BigEndian.AppendUint32(out, adler32.Checksum(block))
+	}
+	if z.Flags&FlagCRC32D != 0 {
+		out = binary.BigEndian.AppendUint32(out, crc32.ChecksumIEEE(block))
+	}
+	if !stored && z.Flags&FlagAdler32C != 0 {
+		out = binary.BigEndian.AppendUint32(out, adler32.Checksum(compressed))
+	}
+	if !stored && z.Flags&FlagCRC32C != 0 {
+		out = binary.BigEndian.AppendUint32(out, crc32.ChecksumIEEE(compressed))
+	}
+	out = append(out, compressed...)
+	z.out = out
+
+	z.err = z.writeAll(out)
+	return z.err
+}
+
+// writeAll writes p to the underlying writer and reports short writes as errors.
+func (z *Writer) writeAll(p []byte) error {
+	n, err := z.w.Write(p)
+	if err != nil {
+		return err
+	}
+	if n != len(p) {
+		return io.ErrShortWrite
+	}
+
+	return nil
+}
+
+// methodForOptions returns the lzop method and level recorded for package compression options.
+func methodForOptions(opts *lzo.CompressOptions) (Method, uint8) {
+	switch opts.Method {
+	case lzo.MethodLZO1X1_15:
+		// lzop -1 uses LZO1X-1(15) and records level 1.
+		return MethodLZO1X1_15, 1
+	case lzo.MethodLZO1X999:
+		return MethodLZO1X999, uint8(min(max(opts.Level, 1), 9)) //nolint:gosec // G115: clamped to 1..9
+	case lzo.MethodAuto:
+		if opts.Level > 1 || opts.Optimal {
+			return MethodLZO1X999, uint8(min(max(opts.Level, 1), 9)) //nolint:gosec // G115: clamped to 1..9
+		}
+	}
+
+	// lzop has no ids for LZO1X-1(11) and (12); they decode like LZO1X-1.
+	// lzop records its default LZO1X-1 mode as level 5.
+	return MethodLZO1X1, 5
+}
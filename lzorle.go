@@ -0,0 +1,46 @@
+// SPDX-License-Identifier: MIT
+// Copyright (c) 2026 WoozyMasta
+// Source: github.com/woozymasta/lzo
+
+package lzo
+
+// DecompressRLE decompresses a Linux lzo-rle stream (as stored by zram and zswap)
+// from src into caller-provided dst and returns dst[:n], like the kernel's
+// lzo1x_decompress_safe. A stream starting with the 0x11 header and a nonzero
+// version byte may contain zero-run instructions; without the header, or with
+// version 0, src is decoded as plain LZO1X. Errors are the same as for DecompressInto.
+func DecompressRLE(src, dst []byte) ([]byte, error) {
+	if len(src) == 0 {
+		return nil, ErrEmptyInput
+	}
+
+	f := formatLZO1X
+	if len(src) >= 5 && src[0] == rleHeader {
+		if src[1] != 0 {
+			f = formatLZORLE
+		}
+		src = src[2:]
+	}
+
+	outWritten, _, err := decompressCore(src, dst, nil, f)
+	if err != nil {
+		return nil, err
+	}
+
+	return dst[:outWritten], nil
+}
+
+// CompressRLE compresses src as a version 1 lzo-rle stream, the default zram
+// format, with the Linux kernel's lzorle1x_1_compress algorithm: LZO1X-1 with
+// a 1<<13-entry hash table over 0xbfff-byte chunks, plus zero-run instructions
+// for runs of 4 to 2051 zero bytes. The output is byte-identical to the kernel's.
+func CompressRLE(src []byte) ([]byte, error) {
+	required := MaxCompressedSize(len(src))
+	buf := acquireCompressBuffer(required)
+	tmp := compress1xExact(buf.data[:0], src, dictBitsLinux, formatLZORLE)
+	result := make([]byte, len(tmp))
+	copy(result, tmp)
+	releaseCompressBuffer(buf)
+
+	return result, nil
+}
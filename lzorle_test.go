@@ -0,0 +1,259 @@
+package lzo
+
+import (
+	"bytes"
+	"errors"
+	"math/rand"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// zramPageInput mixes data with zero runs around the LZO-RLE run length limits,
+// as memory pages usually do.
+func zramPageInput() []byte {
+	rng := rand.New(rand.NewSource(16))
+	var data []byte
+	for _, n := range []int{3, 4, 5, 11, 200, maxLenZeroRun - 1, maxLenZeroRun, maxLenZeroRun + 1, 5000} {
+		chunk := make([]byte, 1+rng.Intn(40))
+		rng.Read(chunk)
+		data = append(data, chunk...)
+		data = append(data, make([]byte, n)...)
+		data = append(data, "page header "...)
+	}
+
+	return data
+}
+
+func TestLZORLERoundTrip(t *testing.T) {
+	inputs := testInputSet(t)
+	inputs = append(inputs, []struct {
+		name string
+		data []byte
+	}{
+		{name: "far-matches", data: ringTestInput()},
+		{name: "zram-page", data: zramPageInput()},
+		{name: "zero-page", data: make([]byte, 4096)},
+	}...)
+
+	for _, in := range inputs {
+		cmp, err := CompressRLE(in.data)
+		if err != nil {
+			t.Fatalf("%s: CompressRLE failed: %v", in.name, err)
+		}
+		if len(cmp) < 2 || cmp[0] != rleHeader || cmp[1] != rleVersion {
+			t.Fatalf("%s: missing lzo-rle header in % x", in.name, cmp[:min(len(cmp), 2)])
+		}
+
+		out, err := DecompressRLE(cmp, make([]byte, len(in.data)))
+		if err != nil || !bytes.Equal(out, in.data) {
+			t.Fatalf("%s: DecompressRLE failed: %v (mismatch at %d)", in.name, err, firstMismatchBytes(out, in.data))
+		}
+	}
+
+	// Plain LZO1X streams decode unchanged.
+	data := zramPageInput()
+	cmp, err := Compress(data, nil)
+	if err != nil {
+		t.Fatalf("Compress failed: %v", err)
+	}
+	if out, err := DecompressRLE(cmp, make([]byte, len(data))); err != nil || !bytes.Equal(out, data) {
+		t.Fatalf("DecompressRLE of LZO1X failed: %v", err)
+	}
+}
+
+func TestCompressRLEZeroPage(t *testing.T) {
+	// lzorle1x_1_compress probes first at offset 5, then codes zero runs up to
+	// 20 bytes before the end of the page and leaves the rest as literals.
+	want := []byte{rleHeader, rleVersion, 5 - 3, 0, 0, 0, 0, 0} // literal run of 5
+	want = append(want, 0x1f, 0xfc, 0xff, 0xff)                 // zero run of 2051
+	want = append(want, 0x18, 0xfc, 0xff, 0xfc)                 // zero run of 2020
+	want = append(want, 0, 20-18)                               // literal run of 20
+	want = append(want, make([]byte, 20)...)
+	want = append(want, markerM4|1, 0, 0)
+
+	got, err := CompressRLE(make([]byte, 4096))
+	if err != nil || !bytes.Equal(got, want) {
+		t.Fatalf("got % x, %v\nwant % x", got, err, want)
+	}
+
+	got, err = CompressRLE(nil)
+	if err != nil || !bytes.Equal(got, []byte{rleHeader, rleVersion, markerM4 | 1, 0, 0}) {
+		t.Fatalf("empty input: got % x, %v", got, err)
+	}
+}
+
+func TestCompressRLEKernelVectors(t *testing.T) {
+	// Every testdata/lzorle/<name>.lzorle is <name>.bin compressed by the
+	// kernel's lzorle1x_1_compress (see testdata/lzorle/generate.sh).
+	streams, err := filepath.Glob(filepath.Join("testdata", "lzorle", "*.lzorle"))
+	if err != nil {
+		t.Fatalf("Glob failed: %v", err)
+	}
+	if len(streams) == 0 {
+		if os.Getenv("LZO_REQUIRE_FIXTURES") != "" {
+			t.Fatal("no lzo-rle vectors in testdata/lzorle")
+		}
+		t.Skip("no lzo-rle vectors in testdata/lzorle; run make testdata-fixtures")
+	}
+
+	for _, name := range streams {
+		want, err := os.ReadFile(name)
+		if err != nil {
+			t.Fatalf("read %s: %v", name, err)
+		}
+		page, err := os.ReadFile(strings.TrimSuffix(name, ".lzorle") + ".bin")
+		if err != nil {
+			t.Fatalf("read input of %s: %v", name, err)
+		}
+
+		got, err := CompressRLE(page)
+		if err != nil || !bytes.Equal(got, want) {
+			t.Fatalf("%s: CompressRLE differs from the kernel at %d (%v)", name, firstMismatchBytes(got, want), err)
+		}
+		out, err := DecompressRLE(want, make([]byte, len(page)))
+		if err != nil || !bytes.Equal(out, page) {
+			t.Fatalf("%s: DecompressRLE failed: %v", name, err)
+		}
+	}
+}
+
+// rleTokens returns the instructions of an lzo-rle stream without zero runs.
+func rleTokens(t *testing.T, cmp []byte) []Token {
+	t.Helper()
+
+	var toks []Token
+	for tok, err := range Tokens(cmp[2:]) {
+		if err != nil {
+			t.Fatalf("Tokens failed: %v", err)
+		}
+		toks = append(toks, tok)
+	}
+	return toks
+}
+
+func TestCompressRLEShortensAmbiguousM4(t *testing.T) {
+	// A 261–264-byte match at distance 0x803f would encode as an M4 whose length
+	// and distance bytes read as a zero run, so the kernel cuts it to 260.
+	const dist = 0x4000 + 0x403f
+	rng := rand.New(rand.NewSource(261))
+	head := make([]byte, 300)
+	for i := range head {
+		head[i] = byte(1 + rng.Intn(255))
+	}
+
+	for n := 261; n <= 264; n++ {
+		head := append([]byte(nil), head...)
+		head[n] = 0xfe
+
+		data := append([]byte(nil), head...)
+		for len(data) < dist {
+			data = append(data, "0123456789abcdef"[len(data)%16])
+		}
+		data = append(data, head[:n]...)
+		data = append(data, bytes.Repeat([]byte("end of page "), 8)...)
+
+		cmp, err := CompressRLE(data)
+		if err != nil {
+			t.Fatalf("%d: CompressRLE failed: %v", n, err)
+		}
+		found := false
+		for _, tok := range rleTokens(t, cmp) {
+			if tok.Kind == TokenM4 && tok.Distance == dist {
+				found = tok.MatchLen == 260
+			}
+		}
+		if !found {
+			t.Fatalf("%d: no M4 match of 260 bytes at distance 0x803f", n)
+		}
+		if out, err := DecompressRLE(cmp, make([]byte, len(data))); err != nil || !bytes.Equal(out, data) {
+			t.Fatalf("%d: DecompressRLE failed: %v", n, err)
+		}
+	}
+}
+
+func TestDecompressRLEZeroRun(t *testing.T) {
+	// Three literals, then a zero run of 10 followed by two literals.
+	src := []byte{rleHeader, rleVersion, 17 + 3, 'a', 'b', 'c', 0x18 | 6, 0xfc | 2, 0xff, 0, 'x', 'y', markerM4 | 1, 0, 0}
+	want := append(append([]byte("abc"), make([]byte, 10)...), "xy"...)
+
+	out, err := DecompressRLE(src, make([]byte, len(want)))
+	if err != nil || !bytes.Equal(out, want) {
+		t.Fatalf("got %q, %v", out, err)
+	}
+
+	if _, err := DecompressRLE(src, make([]byte, len(want)-3)); !errors.Is(err, ErrOutputOverrun) {
+		t.Fatalf("short dst: expected ErrOutputOverrun, got %v", err)
+	}
+	if _, err := DecompressRLE(src[:9], make([]byte, len(want))); !errors.Is(err, ErrInputOverrun) {
+		t.Fatalf("truncated run: expected ErrInputOverrun, got %v", err)
+	}
+	if _, err := DecompressRLE(nil, nil); !errors.Is(err, ErrEmptyInput) {
+		t.Fatalf("empty: expected ErrEmptyInput, got %v", err)
+	}
+
+	// Version 0 has no zero-run instruction: the same bytes are an M4 match.
+	src[1] = 0
+	if out, err := DecompressRLE(src, make([]byte, 64)); err == nil && bytes.Equal(out, want) {
+		t.Fatal("version 0 stream decoded a zero run")
+	}
+}
+
+func TestLZORLEMaxOffset(t *testing.T) {
+	// An M4 match at distance 0xbfff encodes like a zero run, so the kernel
+	// compresses 0xbfff-byte chunks and its matches stop one short.
+	rng := rand.New(rand.NewSource(17))
+	data := make([]byte, 0xbfff+100)
+	for i := range 0xbfff {
+		data[i] = byte(1 + rng.Intn(255))
+	}
+	copy(data[0xbfff:], data)
+
+	cmp, err := CompressRLE(data)
+	if err != nil {
+		t.Fatalf("CompressRLE failed: %v", err)
+	}
+	for _, tok := range rleTokens(t, cmp) {
+		if tok.Distance > maxOffsetM4RLE {
+			t.Fatalf("match at distance %#x", tok.Distance)
+		}
+	}
+	if out, err := DecompressRLE(cmp, make([]byte, len(data))); err != nil || !bytes.Equal(out, data) {
+		t.Fatalf("DecompressRLE failed: %v", err)
+	}
+}
+
+func FuzzLZORLERoundTrip(f *testing.F) {
+	f.Add([]byte(""))
+	f.Add(make([]byte, 4096))
+	f.Add(zramPageInput())
+
+	f.Fuzz(func(t *testing.T, data []byte) {
+		cmp, err := CompressRLE(data)
+		if err != nil {
+			t.Fatalf("CompressRLE failed: %v", err)
+		}
+
+		out, err := DecompressRLE(cmp, make([]byte, len(data)))
+		if err != nil || !bytes.Equal(out, data) {
+			t.Fatalf("round trip failed: %v", err)
+		}
+	})
+}
+
+func FuzzDecompressRLEMalformedInput(f *testing.F) {
+	f.Add([]byte{rleHeader, rleVersion})
+	f.Add([]byte{rleHeader, rleVersion, markerM4 | 1, 0, 0})
+	// One literal, then a zero run of the maximum length.
+	f.Add([]byte{rleHeader, rleVersion, 0x12, 'a', 0x1f, 0xfc, 0xff, 0xff, markerM4 | 1, 0, 0})
+	if cmp, err := CompressRLE(zramPageInput()); err == nil {
+		f.Add(cmp)
+	}
+
+	f.Fuzz(func(t *testing.T, src []byte) {
+		dst := make([]byte, 4096)
+		out, err := DecompressRLE(src, dst)
+		checkMalformedDecode(t, dst, out, err)
+	})
+}
@@ -5,13 +5,39 @@
 package lzo
 
 // DecompressOptions configures decompression.
-// OutLen is required (expected decompressed size); MaxInputSize limits reads when using reader APIs.
+// OutLen is required (expected decompressed size) unless GrowOutput is set;
+// MaxInputSize limits reads when using reader APIs.
 type DecompressOptions struct {
 	// OutLen is the expected decompressed size (required for buffer allocation and safety).
+	// With GrowOutput it is only the initial size estimate (0 = estimate from input size).
 	OutLen int
 
 	// MaxInputSize limits how many bytes reader APIs may read (0 = no limit).
 	MaxInputSize int
+
+	// MaxOutLen caps the decompressed size when GrowOutput is set and in
+	// DecompressFromReaderTo (0 = no limit).
+	MaxOutLen int
+
+	// GrowOutput lets Decompress, DecompressN and DecompressFromReader grow the
+	// destination beyond OutLen instead of failing with ErrOutputOverrun.
+	GrowOutput bool
+
+	// Concurrency is the number of blocks a Reader created by NewReaderOptions
+	// decodes in parallel (0 or 1 = one block at a time). Other APIs ignore it.
+	Concurrency int
+
+	// LinkedBlocks makes a Reader created by NewReaderOptions decode a linked
+	// stream (CompressOptions.LinkedBlocks), keeping the last 0xbfff decoded
+	// bytes for the next block. Linked blocks are decoded one at a time, so
+	// Concurrency is ignored. Other APIs ignore it.
+	LinkedBlocks bool
+
+	// Frame makes a Reader created by NewReaderOptions read the frame format
+	// (CompressOptions.Frame): skippable frames are collected, checksums and
+	// content sizes are verified and LinkedBlocks is taken from each frame
+	// header. Other APIs ignore it.
+	Frame bool
 }
 
 // DefaultDecompressOptions returns options with the given output length and no input limit.
@@ -19,10 +45,85 @@ func DefaultDecompressOptions(outLen int) *DecompressOptions {
 	return &DecompressOptions{OutLen: outLen}
 }
 
+// Method selects the LZO1X compression algorithm. Every method produces a
+// standard LZO1X stream; they differ only in speed, memory use and ratio.
+type Method uint8
+
+// Compression methods, named after their liblzo2 counterparts.
+const (
+	MethodAuto      Method = iota // MethodAuto selects LZO1X-1 or LZO1X-999 from CompressOptions.Level and Optimal.
+	MethodLZO1X1                  // MethodLZO1X1 is LZO1X-1 with a 16K-entry hash table (lzo1x_1_compress).
+	MethodLZO1X1_11               // MethodLZO1X1_11 is LZO1X-1(11) with a 2K-entry hash table (lzo1x_1_11_compress).
+	MethodLZO1X1_12               // MethodLZO1X1_12 is LZO1X-1(12) with a 4K-entry hash table (lzo1x_1_12_compress).
+	MethodLZO1X1_15               // MethodLZO1X1_15 is LZO1X-1(15) with a 32K-entry hash table (lzo1x_1_15_compress).
+	MethodLZO1X999                // MethodLZO1X999 is LZO1X-999 at CompressOptions.Level, clamped to 1–9.
+)
+
 // CompressOptions configures compression (LZO1X-1 fast vs LZO1X-999 levels).
 type CompressOptions struct {
 	// Level: 0 or 1 = fast LZO1X-1; 2–9 = LZO1X-999 (higher = better ratio, slower).
+	// Levels above 9 are clamped to 9. Level is ignored by the LZO1X-1 methods.
 	Level int
+
+	// Optimal replaces the LZO1X-999 lazy parser with a price-based optimal
+	// parse over every match candidate, for data compressed once and decoded
+	// often. It is 10–20x slower than level 9 and saves a few percent. Level
+	// sets its search effort: 1–3, 4–6 and 7–9 probe up to 128, 512 and 2048
+	// candidates, and 4–9 use a binary-tree match finder. With MethodAuto it
+	// selects LZO1X-999 at any level; the LZO1X-1 methods and Exact ignore it.
+	Optimal bool
+
+	// Method selects the algorithm (MethodAuto = choose by Level).
+	// Unknown values make compression fail with ErrUnknownMethod.
+	Method Method
+
+	// BlockSize is the uncompressed block size used by Writer
+	// (0 = DefaultBlockSize; values above MaxBlockSize are clamped).
+	BlockSize int
+
+	// Concurrency is the number of blocks Writer compresses in parallel on as
+	// many worker goroutines, each with its own Encoder (0 or 1 = one block at
+	// a time). Blocks are still written in order and the stream is identical.
+	// Each unit of Concurrency holds a copy of its input block, an output
+	// buffer of MaxCompressedSize(BlockSize) bytes, a 0xbfff-byte window copy
+	// with LinkedBlocks, and an Encoder dictionary of about 544 KiB at levels
+	// 2–9 or with LinkedBlocks; Optimal adds about 2 MiB of parse state and,
+	// at levels 4–9, a 720 KiB binary tree. With small blocks the Encoder
+	// dominates: at level 9 a 16 KiB block costs about 36 blocks per unit.
+	Concurrency int
+
+	// LinkedBlocks makes Writer compress each block with the last 0xbfff bytes
+	// of the preceding input as a preset dictionary, so back-references may
+	// reach into earlier blocks. This recovers most of the ratio small blocks
+	// lose; decode with DecompressOptions.LinkedBlocks. As with Dict, blocks
+	// after the first use LZO1X-999 at level 1 instead of LZO1X-1 for levels
+	// 0 and 1 and the LZO1X-1 methods.
+	// SeekableWriter ignores LinkedBlocks.
+	LinkedBlocks bool
+
+	// Frame, when set, makes Writer write a self-describing frame: a header
+	// with a magic number, flags and the optional content size, the blocks
+	// with optional checksums and an optional content checksum. Read it with
+	// DecompressOptions.Frame. SeekableWriter ignores Frame.
+	Frame *FrameOptions
+
+	// Dict is a preset dictionary that primes the LZO1X-999 window, so matches may
+	// reference it (lzo1x_999_compress_dict). Only its last 0xbfff bytes are used.
+	// Decode with DecompressWithDict and the same dictionary. Levels 0 and 1 have no
+	// dictionary support and use LZO1X-999 at level 1 when Dict is set; so do
+	// the LZO1X-1 methods.
+	// Writer ignores Dict.
+	Dict []byte
+
+	// Exact makes Compress and CompressLZO1Y emit the same bytes as liblzo2 on
+	// little-endian 64-bit builds, for reproducible output: the LZO1X-1 methods
+	// match the lzo1x_1_compress family and LZO1X-999 (MethodLZO1X999, levels
+	// 2–9 or Dict) matches lzo1x_999_compress_level at the same level; level 8
+	// is lzo1x_999_compress and Dict gives lzo1x_999_compress_dict. liblzo2 has
+	// no optimal parse, so Exact ignores Optimal.
+	// LZO1X-1 speed and ratio stay close to the default parser; exact LZO1X-999
+	// walks liblzo2's hash chains and runs about 1.5x slower.
+	Exact bool
 }
 
 // DefaultCompressOptions returns options for fast compression (level 1).
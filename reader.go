@@ -0,0 +1,446 @@
+// SPDX-License-Identifier: MIT
+// Copyright (c) 2026 WoozyMasta
+// Source: github.com/woozymasta/lzo
+
+package lzo
+
+import (
+	"encoding/binary"
+	"errors"
+	"io"
+	"math"
+)
+
+// Reader is an io.Reader that decompresses a block-framed stream written by Writer.
+// Blocks are decoded one at a time into a reused buffer, so memory stays at about
+// one block regardless of the stream length. Blocks larger than MaxBlockSize are rejected.
+// A Reader created with DecompressOptions.Concurrency above 1 reads up to that
+// many blocks ahead and decodes them in parallel; one created with
+// DecompressOptions.LinkedBlocks decodes a linked stream, and one created with
+// DecompressOptions.Frame reads frames, verifying their checksums and sizes.
+// A Reader must not be used concurrently.
+type Reader struct {
+	r     io.Reader
+	err   error
+	block readerBlock             // block is the reusable block of a sequential Reader.
+	out   []byte                  // out is the unread part of the current decoded block.
+	hdr   [frameHeaderMaxLen]byte // hdr holds a block, frame or checksum header.
+
+	linked bool          // linked reports whether blocks may reference earlier blocks.
+	hist   streamHistory // hist is the decoded tail of a linked stream.
+
+	framed    bool             // framed reports whether the stream is a sequence of frames.
+	frameOpen bool             // frameOpen reports whether a data frame header was read.
+	frames    int              // frames is the number of frames started since Reset.
+	header    FrameHeader      // header describes the current data frame.
+	sum       frameChecksum    // sum is the running content checksum.
+	produced  int64            // produced is the decoded size of the current frame.
+	skippable []SkippableFrame // skippable holds the skippable frames read so far.
+
+	// blocks is the ring of parallel block slots; read-ahead blocks occupy
+	// blocks[next], blocks[next+1], ... in stream order, wrapping around.
+	blocks  []*readerBlock
+	next    int   // next is the index of the oldest read-ahead block.
+	queued  int   // queued is the number of read-ahead blocks, including the one out refers to.
+	held    bool  // held reports whether out refers to blocks[next].
+	readErr error // readErr is the error that ended read-ahead, reported after the queued blocks.
+}
+
+// readerBlock is one stored block and its decoded form.
+type readerBlock struct {
+	src    []byte        // src is the reusable stored payload.
+	buf    []byte        // buf is the reusable decoded block.
+	out    []byte        // out is the decoded block; it aliases src for stored blocks.
+	rawLen int           // rawLen is the uncompressed block size.
+	check  Checksum      // check is the payload checksum algorithm, if any.
+	want   uint64        // want is the stored payload checksum.
+	err    error         // err is the decoding error, if any.
+	done   chan struct{} // done receives a value when a parallel decode finishes.
+}
+
+// NewReader returns a Reader that decompresses the block-framed stream from r.
+func NewReader(r io.Reader) *Reader {
+	z := &Reader{}
+	z.Reset(r)
+	return z
+}
+
+// NewReaderOptions returns a Reader that decompresses the block-framed stream
+// from r, decoding opts.Concurrency blocks in parallel, a linked stream when
+// opts.LinkedBlocks is set or a sequence of frames when opts.Frame is set.
+// opts may be nil; its other fields are ignored.
+func NewReaderOptions(r io.Reader, opts *DecompressOptions) *Reader {
+	z := &Reader{}
+	if opts != nil {
+		z.framed = opts.Frame
+		z.linked = opts.LinkedBlocks && !opts.Frame
+	}
+	// Each linked block needs the previous one decoded, so they are not parallel;
+	// linked frames fall back to sequential decoding when they are read.
+	if opts != nil && opts.Concurrency > 1 && !z.linked {
+		z.blocks = make([]*readerBlock, opts.Concurrency)
+		for i := range z.blocks {
+			z.blocks[i] = &readerBlock{done: make(chan struct{}, 1)}
+		}
+	}
+	z.Reset(r)
+	return z
+}
+
+// Reset discards buffered data and state and makes z read a new stream from r.
+// Internal buffers are retained.
+func (z *Reader) Reset(r io.Reader) {
+	// Blocks still being decoded own their slots until they finish.
+	for ; z.queued > 0; z.queued-- {
+		if !z.held {
+			<-z.blocks[z.next].done
+		}
+		z.held = false
+		z.next = (z.next + 1) % len(z.blocks)
+	}
+
+	z.r = r
+	z.err = nil
+	z.out = nil
+	z.readErr = nil
+	z.hist.reset()
+	z.frameOpen = false
+	z.frames = 0
+	z.skippable = nil
+}
+
+// Read reads decompressed data into p.
+// It returns io.EOF after the end-of-stream marker (after the last frame in
+// frame mode) and ErrUnexpectedEOF when the stream ends inside a block or
+// before the marker.
+func (z *Reader) Read(p []byte) (int, error) {
+	for len(z.out) == 0 {
+		if z.err != nil {
+			return 0, z.err
+		}
+		z.err = z.nextBlock()
+	}
+
+	n := copy(p, z.out)
+	z.out = z.out[n:]
+	return n, nil
+}
+
+// WriteTo writes the remaining decompressed stream to w without an intermediate copy.
+// It implements io.WriterTo, so io.Copy uses it automatically.
+func (z *Reader) WriteTo(w io.Writer) (int64, error) {
+	var total int64
+	for {
+		if len(z.out) > 0 {
+			n, err := w.Write(z.out)
+			total += int64(n)
+			z.out = z.out[n:]
+			if err != nil {
+				return total, err
+			}
+			if len(z.out) > 0 {
+				return total, io.ErrShortWrite
+			}
+		}
+
+		if z.err != nil {
+			if z.err == io.EOF {
+				return total, nil
+			}
+			return total, z.err
+		}
+		z.err = z.nextBlock()
+	}
+}
+
+// Header returns the header of the current data frame, first reading it and
+// any skippable frames before it when no frame is open. It returns
+// ErrInvalidFrame for a Reader without DecompressOptions.Frame and io.EOF
+// when no data frame follows.
+func (z *Reader) Header() (FrameHeader, error) {
+	if !z.framed {
+		return FrameHeader{}, ErrInvalidFrame
+	}
+	if !z.frameOpen && z.err == nil {
+		z.err = z.readFrameHeader()
+	}
+	if !z.frameOpen {
+		return FrameHeader{}, z.err
+	}
+
+	return z.header, nil
+}
+
+// SkippableFrames returns the skippable frames read so far, in stream order.
+// Frames before a data frame are available once Header or Read reaches it;
+// frames after the last data frame once Read returns io.EOF.
+func (z *Reader) SkippableFrames() []SkippableFrame {
+	return z.skippable
+}
+
+// nextBlock reads and decodes the next block into z.out, crossing frame
+// boundaries in frame mode. It returns io.EOF at the end of the stream.
+func (z *Reader) nextBlock() error {
+	if !z.framed {
+		return z.nextStreamBlock()
+	}
+
+	for {
+		if !z.frameOpen {
+			if err := z.readFrameHeader(); err != nil {
+				return err
+			}
+		}
+
+		err := z.nextStreamBlock()
+		if err == nil {
+			z.sum.write(z.out)
+			z.produced += int64(len(z.out))
+			return nil
+		}
+		if err != io.EOF {
+			return err
+		}
+		if err := z.readFrameEnd(); err != nil {
+			return err
+		}
+	}
+}
+
+// readFrameHeader reads frames up to the next data frame header, collecting
+// skippable frames. It returns io.EOF at the end of the input after a frame.
+func (z *Reader) readFrameHeader() error {
+	for {
+		if _, err := io.ReadFull(z.r, z.hdr[:frameMagicLen]); err != nil {
+			if err == io.EOF && z.frames > 0 {
+				return io.EOF
+			}
+			return streamReadError(err)
+		}
+		z.frames++
+
+		switch [frameMagicLen]byte(z.hdr[:frameMagicLen]) {
+		case skippableMagic:
+			if err := z.readSkippableFrame(); err != nil {
+				return err
+			}
+		case frameMagic:
+			return z.readFrameFlags()
+		default:
+			return ErrInvalidFrame
+		}
+	}
+}
+
+// readSkippableFrame reads the rest of a skippable frame after its magic.
+func (z *Reader) readSkippableFrame() error {
+	if _, err := io.ReadFull(z.r, z.hdr[:skippableHeaderLen-frameMagicLen]); err != nil {
+		return streamReadError(err)
+	}
+	n := binary.LittleEndian.Uint32(z.hdr[1:])
+	if n > MaxBlockSize {
+		return ErrInvalidFrame
+	}
+
+	frame := SkippableFrame{Kind: z.hdr[0], Data: make([]byte, n)}
+	if _, err := io.ReadFull(z.r, frame.Data); err != nil {
+		return streamReadError(err)
+	}
+	z.skippable = append(z.skippable, frame)
+
+	return nil
+}
+
+// readFrameFlags reads the rest of a data frame header after its magic and
+// opens the frame.
+func (z *Reader) readFrameFlags() error {
+	if _, err := io.ReadFull(z.r, z.hdr[:1]); err != nil {
+		return streamReadError(err)
+	}
+	h, rest, err := parseFrameFlags(z.hdr[0])
+	if err != nil {
+		return err
+	}
+	if _, err := io.ReadFull(z.r, z.hdr[1:1+rest]); err != nil {
+		return streamReadError(err)
+	}
+	if frameHeaderCheck(z.hdr[:rest]) != z.hdr[rest] {
+		return ErrInvalidFrame
+	}
+	if rest > 1 {
+		size := binary.LittleEndian.Uint64(z.hdr[1:])
+		if size > math.MaxInt64 {
+			return ErrInvalidFrame
+		}
+		h.ContentSize = int64(size)
+	}
+
+	z.header = h
+	z.frameOpen = true
+	z.linked = h.LinkedBlocks
+	z.hist.reset()
+	z.sum.reset(h.Checksum)
+	z.produced = 0
+
+	return nil
+}
+
+// readFrameEnd reads the content checksum after the end-of-stream marker of a
+// data frame, verifies the frame and closes it.
+func (z *Reader) readFrameEnd() error {
+	if z.header.ContentSize >= 0 && z.produced != z.header.ContentSize {
+		return ErrContentSize
+	}
+	if z.header.ContentChecksum {
+		n := z.header.Checksum.size()
+		if _, err := io.ReadFull(z.r, z.hdr[:n]); err != nil {
+			return streamReadError(err)
+		}
+		if loadChecksum(z.header.Checksum, z.hdr[:n]) != z.sum.sum() {
+			return ErrChecksum
+		}
+	}
+
+	z.frameOpen = false
+	z.readErr = nil
+	return nil
+}
+
+// nextStreamBlock reads and decodes the next block of a block stream into z.out.
+// It returns io.EOF at the end-of-stream marker.
+func (z *Reader) nextStreamBlock() error {
+	if z.blocks != nil && !z.linked {
+		return z.nextBlockParallel()
+	}
+
+	if err := z.readBlock(&z.block); err != nil {
+		return err
+	}
+
+	if !z.linked {
+		z.block.decode(nil)
+	} else {
+		z.block.decode(z.hist.window())
+		if z.block.err == nil {
+			z.hist.add(z.block.out)
+		}
+	}
+	z.out = z.block.out
+	return z.block.err
+}
+
+// nextBlockParallel releases the consumed block, reads ahead until every slot
+// is busy and returns the oldest block once it is decoded.
+func (z *Reader) nextBlockParallel() error {
+	if z.held {
+		z.held = false
+		z.next = (z.next + 1) % len(z.blocks)
+		z.queued--
+	}
+
+	for z.readErr == nil && z.queued < len(z.blocks) {
+		b := z.blocks[(z.next+z.queued)%len(z.blocks)]
+		if z.readErr = z.readBlock(b); z.readErr != nil {
+			break
+		}
+		z.queued++
+		go func() {
+			b.decode(nil)
+			b.done <- struct{}{}
+		}()
+	}
+	if z.queued == 0 {
+		return z.readErr
+	}
+
+	b := z.blocks[z.next]
+	<-b.done
+	z.held = true
+	z.out = b.out
+	return b.err
+}
+
+// readBlock reads the next block header and stored payload into b.
+// It returns io.EOF at the end-of-stream marker.
+func (z *Reader) readBlock(b *readerBlock) error {
+	if _, err := io.ReadFull(z.r, z.hdr[:blockSizeFieldLen]); err != nil {
+		return streamReadError(err)
+	}
+	rawLen := binary.LittleEndian.Uint32(z.hdr[:blockSizeFieldLen])
+	if rawLen == 0 {
+		return io.EOF
+	}
+
+	if _, err := io.ReadFull(z.r, z.hdr[blockSizeFieldLen:blockHeaderLen]); err != nil {
+		return streamReadError(err)
+	}
+	payloadLen := binary.LittleEndian.Uint32(z.hdr[blockSizeFieldLen:blockHeaderLen])
+	if !validBlockSizes(rawLen, payloadLen) {
+		return ErrInvalidBlock
+	}
+
+	b.src = resizeBuffer(b.src, int(payloadLen))
+	if _, err := io.ReadFull(z.r, b.src); err != nil {
+		return streamReadError(err)
+	}
+	b.rawLen = int(rawLen)
+
+	b.check = ChecksumNone
+	if z.framed && z.header.BlockChecksums {
+		b.check = z.header.Checksum
+		sum := z.hdr[:b.check.size()]
+		if _, err := io.ReadFull(z.r, sum); err != nil {
+			return streamReadError(err)
+		}
+		b.want = loadChecksum(b.check, sum)
+	}
+
+	return nil
+}
+
+// decode decodes the stored payload of b into b.out; back-references may
+// reach into dict, the window of a linked stream.
+func (b *readerBlock) decode(dict []byte) {
+	b.out, b.err = nil, nil
+	if b.check != ChecksumNone && checksumOf(b.check, b.src) != b.want {
+		b.err = ErrChecksum
+		return
+	}
+
+	// Stored blocks carry the raw bytes; no decoding is needed.
+	if len(b.src) == b.rawLen {
+		b.out = b.src
+		return
+	}
+
+	b.buf = resizeBuffer(b.buf, b.rawLen)
+	outLen, nRead, err := decompressCore(b.src, b.buf, dict, formatLZO1X)
+	if err != nil {
+		b.err = err
+		return
+	}
+	if nRead != len(b.src) || outLen != len(b.buf) {
+		b.err = ErrInvalidBlock
+		return
+	}
+
+	b.out = b.buf
+}
+
+// streamReadError maps a short read inside a stream to ErrUnexpectedEOF.
+func streamReadError(err error) error {
+	if errors.Is(err, io.EOF) || errors.Is(err, io.ErrUnexpectedEOF) {
+		return ErrUnexpectedEOF
+	}
+
+	return err
+}
+
+// resizeBuffer returns buf resized to n bytes, reallocating only when capacity is insufficient.
+func resizeBuffer(buf []byte, n int) []byte {
+	if cap(buf) < n {
+		return make([]byte, n)
+	}
+
+	return buf[:n]
+}
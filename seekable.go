@@ -0,0 +1,219 @@
+// SPDX-License-Identifier: MIT
+// Copyright (c) 2026 WoozyMasta
+// Source: github.com/woozymasta/lzo
+
+package lzo
+
+import (
+	"encoding/binary"
+	"io"
+)
+
+// Seekable container layout used by SeekableWriter and SeekableReader.
+//
+// The container is a sequence of block payloads followed by a block index and
+// a footer. Each payload is one complete LZO1X stream, or the raw block when
+// compression does not shrink it (its payload size then equals its size).
+// The index holds one entry per block: the little-endian uint64 uncompressed
+// offset and payload offset of the block, then its uint32 uncompressed and
+// payload sizes. The footer is the little-endian uint64 offset of the index,
+// the uint64 block count and seekableMagic.
+
+const (
+	// seekableEntryLen is the length of one block index entry.
+	seekableEntryLen = 24
+
+	// seekableFooterLen is the length of the container footer.
+	seekableFooterLen = 24
+
+	// seekableCacheBlocks is the number of decoded blocks SeekableReader keeps.
+	seekableCacheBlocks = 8
+)
+
+// seekableMagic ends every seekable container.
+var seekableMagic = [8]byte{'L', 'Z', 'O', 'S', 'E', 'E', 'K', 1}
+
+// seekableEntry describes one block of a seekable container.
+type seekableEntry struct {
+	rawOff     int64 // rawOff is the uncompressed offset of the block.
+	payloadOff int64 // payloadOff is the container offset of the block payload.
+	rawLen     int   // rawLen is the uncompressed block size.
+	payloadLen int   // payloadLen is the stored payload size.
+}
+
+// putSeekableEntry appends the index entry of e to dst.
+func putSeekableEntry(dst []byte, e seekableEntry) []byte {
+	dst = binary.LittleEndian.AppendUint64(dst, uint64(e.rawOff))      //nolint:gosec // G115: offsets are non-negative
+	dst = binary.LittleEndian.AppendUint64(dst, uint64(e.payloadOff))  //nolint:gosec // G115: offsets are non-negative
+	dst = binary.LittleEndian.AppendUint32(dst, uint32(e.rawLen))      //nolint:gosec // G115: bounded by MaxBlockSize
+	return binary.LittleEndian.AppendUint32(dst, uint32(e.payloadLen)) //nolint:gosec // G115: bounded by MaxBlockSize
+}
+
+// SeekableWriter is an io.WriteCloser that compresses written data into a
+// seekable container: blocks of CompressOptions.BlockSize bytes compressed
+// independently, followed by a block index that lets SeekableReader decode
+// any byte range from the blocks it touches.
+// Close must be called to write the index and footer.
+// A SeekableWriter must not be used concurrently.
+type SeekableWriter struct {
+	w     io.Writer
+	err   error
+	buf   []byte // buf holds pending input that does not fill a whole block yet.
+	out   []byte // out is the reusable encoded block.
+	index []byte // index is the encoded index of the blocks written so far.
+	enc   Encoder
+	opts  CompressOptions
+
+	rawOff     int64 // rawOff is the uncompressed offset of the next block.
+	payloadOff int64 // payloadOff is the container offset of the next payload.
+
+	blockSize int
+	closed    bool
+}
+
+// NewSeekableWriter returns a SeekableWriter that compresses to w.
+// opts may be nil (uses default level 1); opts.Dict, opts.Concurrency,
+// opts.LinkedBlocks and opts.Frame are ignored.
+// Smaller blocks make random reads cheaper at some cost in ratio.
+func NewSeekableWriter(w io.Writer, opts *CompressOptions) *SeekableWriter {
+	if opts == nil {
+		opts = DefaultCompressOptions()
+	}
+
+	z := &SeekableWriter{opts: *opts}
+	// Blocks are decoded independently, so they cannot reference a preset dictionary.
+	z.opts.Dict = nil
+	z.opts.LinkedBlocks = false
+	z.blockSize = streamBlockSize(&z.opts)
+	z.Reset(w)
+	return z
+}
+
+// Reset discards pending data and state and makes z write a new container to w.
+// Compression options and internal buffers are retained.
+func (z *SeekableWriter) Reset(w io.Writer) {
+	z.w = w
+	z.err = nil
+	z.buf = z.buf[:0]
+	z.index = z.index[:0]
+	z.rawOff = 0
+	z.payloadOff = 0
+	z.closed = false
+}
+
+// Write buffers p and writes every completed block to the underlying writer.
+func (z *SeekableWriter) Write(p []byte) (int, error) {
+	if z.err != nil {
+		return 0, z.err
+	}
+	if z.closed {
+		return 0, ErrWriterClosed
+	}
+
+	written := 0
+	for len(p) > 0 {
+		// Whole blocks are compressed straight from p without staging them in buf.
+		if len(z.buf) == 0 && len(p) >= z.blockSize {
+			if err := z.writeBlock(p[:z.blockSize]); err != nil {
+				return written, err
+			}
+			written += z.blockSize
+			p = p[z.blockSize:]
+			continue
+		}
+
+		if z.buf == nil {
+			z.buf = make([]byte, 0, z.blockSize)
+		}
+		n := min(len(p), z.blockSize-len(z.buf))
+		z.buf = append(z.buf, p[:n]...)
+		written += n
+		p = p[n:]
+
+		if len(z.buf) == z.blockSize {
+			if err := z.writeBlock(z.buf); err != nil {
+				return written, err
+			}
+			z.buf = z.buf[:0]
+		}
+	}
+
+	return written, nil
+}
+
+// Flush compresses any pending data as a (possibly short) block and writes it.
+// It does not write the index.
+func (z *SeekableWriter) Flush() error {
+	if z.err != nil {
+		return z.err
+	}
+	if z.closed {
+		return ErrWriterClosed
+	}
+	if len(z.buf) == 0 {
+		return nil
+	}
+
+	if err := z.writeBlock(z.buf); err != nil {
+		return err
+	}
+	z.buf = z.buf[:0]
+	return nil
+}
+
+// Close flushes pending data and writes the block index and footer.
+// It does not close the underlying writer. Calling Close again is a no-op.
+func (z *SeekableWriter) Close() error {
+	if z.closed {
+		return z.err
+	}
+	if err := z.Flush(); err != nil {
+		return err
+	}
+
+	z.closed = true
+	blocks := len(z.index) / seekableEntryLen
+	z.index = binary.LittleEndian.AppendUint64(z.index, uint64(z.payloadOff)) //nolint:gosec // G115: offsets are non-negative
+	z.index = binary.LittleEndian.AppendUint64(z.index, uint64(blocks))       //nolint:gosec // G115: count is non-negative
+	z.index = append(z.index, seekableMagic[:]...)
+	z.err = z.writeAll(z.index)
+	return z.err
+}
+
+// writeBlock compresses one block, writes its payload and records it in the index.
+func (z *SeekableWriter) writeBlock(block []byte) error {
+	out, err := encodeBlock(&z.enc, z.out, block, z.blockSize, &z.opts)
+	if err != nil {
+		z.err = err
+		return err
+	}
+	z.out = out
+
+	payload := out[blockHeaderLen:]
+	if z.err = z.writeAll(payload); z.err != nil {
+		return z.err
+	}
+
+	z.index = putSeekableEntry(z.index, seekableEntry{
+		rawOff:     z.rawOff,
+		payloadOff: z.payloadOff,
+		rawLen:     len(block),
+		payloadLen: len(payload),
+	})
+	z.rawOff += int64(len(block))
+	z.payloadOff += int64(len(payload))
+	return nil
+}
+
+// writeAll writes p to the underlying writer and reports short writes as errors.
+func (z *SeekableWriter) writeAll(p []byte) error {
+	n, err := z.w.Write(p)
+	if err != nil {
+		return err
+	}
+	if n != len(p) {
+		return io.ErrShortWrite
+	}
+
+	return nil
+}
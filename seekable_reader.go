@@ -0,0 +1,253 @@
+// SPDX-License-Identifier: MIT
+// Copyright (c) 2026 WoozyMasta
+// Source: github.com/woozymasta/lzo
+
+package lzo
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"io"
+	"math"
+	"sort"
+	"sync"
+)
+
+// SeekableReader gives random access to the uncompressed data of a seekable
+// container written by SeekableWriter. It implements io.ReaderAt and
+// io.ReadSeeker; each read decodes only the blocks it touches and keeps the
+// most recently used decoded blocks in a small cache.
+// ReadAt may be called concurrently; Read and Seek must not.
+type SeekableReader struct {
+	r      io.ReaderAt
+	blocks []seekableEntry
+	size   int64 // size is the uncompressed size of the container.
+	pos    int64 // pos is the offset of the next Read.
+
+	mu    sync.Mutex // mu guards the cache fields below.
+	cache []seekableCacheEntry
+	tick  uint64 // tick orders cache entries by last use.
+}
+
+// seekableCacheEntry is one decoded block in the SeekableReader cache.
+type seekableCacheEntry struct {
+	block int    // block is the index of the decoded block.
+	data  []byte // data is the decoded block; it is never modified once cached.
+	used  uint64 // used is the tick of the last read from this block.
+}
+
+// NewSeekableReader reads the block index of the seekable container of size
+// bytes in r. It returns ErrInvalidIndex if the footer or index is malformed.
+func NewSeekableReader(r io.ReaderAt, size int64) (*SeekableReader, error) {
+	if size < seekableFooterLen {
+		return nil, ErrInvalidIndex
+	}
+
+	var footer [seekableFooterLen]byte
+	if err := readFullAt(r, footer[:], size-seekableFooterLen); err != nil {
+		return nil, err
+	}
+	if !bytes.Equal(footer[16:], seekableMagic[:]) {
+		return nil, ErrInvalidIndex
+	}
+
+	indexOff := binary.LittleEndian.Uint64(footer[:8])
+	count := binary.LittleEndian.Uint64(footer[8:16])
+	indexLen := uint64(size) - seekableFooterLen - indexOff //nolint:gosec // G115: size is at least seekableFooterLen
+	if indexOff > uint64(size)-seekableFooterLen || count > math.MaxInt/seekableEntryLen || indexLen != count*seekableEntryLen {
+		return nil, ErrInvalidIndex
+	}
+
+	index := make([]byte, indexLen)
+	if err := readFullAt(r, index, int64(indexOff)); err != nil { //nolint:gosec // G115: below size
+		return nil, err
+	}
+
+	z := &SeekableReader{r: r, blocks: make([]seekableEntry, count)}
+	var payloadOff int64
+	for i := range z.blocks {
+		entry := index[i*seekableEntryLen:]
+		e := seekableEntry{
+			rawOff:     int64(binary.LittleEndian.Uint64(entry)),     //nolint:gosec // G115: checked against the running size
+			payloadOff: int64(binary.LittleEndian.Uint64(entry[8:])), //nolint:gosec // G115: checked against the running offset
+			rawLen:     int(binary.LittleEndian.Uint32(entry[16:])),
+			payloadLen: int(binary.LittleEndian.Uint32(entry[20:])),
+		}
+
+		// Blocks must tile both the data and the payload area in order.
+		if e.rawOff != z.size || e.payloadOff != payloadOff ||
+			!validBlockSizes(uint32(e.rawLen), uint32(e.payloadLen)) { //nolint:gosec // G115: read from uint32 fields
+			return nil, ErrInvalidIndex
+		}
+		z.blocks[i] = e
+		z.size += int64(e.rawLen)
+		payloadOff += int64(e.payloadLen)
+	}
+	if payloadOff != int64(indexOff) { //nolint:gosec // G115: below size
+		return nil, ErrInvalidIndex
+	}
+
+	return z, nil
+}
+
+// Size returns the uncompressed size of the container.
+func (z *SeekableReader) Size() int64 {
+	return z.size
+}
+
+// ReadAt reads len(p) uncompressed bytes starting at off. It returns io.EOF
+// when fewer bytes remain.
+func (z *SeekableReader) ReadAt(p []byte, off int64) (int, error) {
+	if off < 0 {
+		return 0, ErrInvalidOffset
+	}
+
+	n := 0
+	for n < len(p) && off < z.size {
+		i := sort.Search(len(z.blocks), func(i int) bool {
+			return z.blocks[i].rawOff+int64(z.blocks[i].rawLen) > off
+		})
+
+		copied, err := z.copyBlock(p[n:], i, int(off-z.blocks[i].rawOff))
+		if err != nil {
+			return n, err
+		}
+		n += copied
+		off += int64(copied)
+	}
+
+	if n < len(p) {
+		return n, io.EOF
+	}
+	return n, nil
+}
+
+// Read reads uncompressed data from the current offset into p.
+func (z *SeekableReader) Read(p []byte) (int, error) {
+	if z.pos >= z.size {
+		return 0, io.EOF
+	}
+
+	n, err := z.ReadAt(p, z.pos)
+	z.pos += int64(n)
+	if err == io.EOF && n > 0 {
+		err = nil
+	}
+	return n, err
+}
+
+// Seek sets the offset of the next Read. Offsets past the end are allowed
+// and make Read return io.EOF; negative offsets and an unknown whence return
+// ErrInvalidOffset.
+func (z *SeekableReader) Seek(offset int64, whence int) (int64, error) {
+	switch whence {
+	case io.SeekStart:
+	case io.SeekCurrent:
+		offset += z.pos
+	case io.SeekEnd:
+		offset += z.size
+	default:
+		return 0, ErrInvalidOffset
+	}
+	if offset < 0 {
+		return 0, ErrInvalidOffset
+	}
+
+	z.pos = offset
+	return offset, nil
+}
+
+// copyBlock copies the decoded block i from offset off into p.
+// The block is read and decoded without holding z.mu, so concurrent ReadAt
+// calls only serialize on the cache lookup and insert.
+func (z *SeekableReader) copyBlock(p []byte, i, off int) (int, error) {
+	data := z.cachedBlock(i)
+	if data == nil {
+		var err error
+		if data, err = z.decodeBlock(i); err != nil {
+			return 0, err
+		}
+		z.cacheBlock(i, data)
+	}
+	return copy(p, data[off:]), nil
+}
+
+// cachedBlock returns decoded block i from the cache, or nil on a miss.
+func (z *SeekableReader) cachedBlock(i int) []byte {
+	z.mu.Lock()
+	defer z.mu.Unlock()
+
+	z.tick++
+	for j := range z.cache {
+		if entry := &z.cache[j]; entry.block == i {
+			entry.used = z.tick
+			return entry.data
+		}
+	}
+	return nil
+}
+
+// cacheBlock stores decoded block i in the cache, replacing the least recently
+// used entry when the cache is full. Readers may still hold the data of a
+// replaced entry, so it gets a new slice rather than being decoded over.
+func (z *SeekableReader) cacheBlock(i int, data []byte) {
+	z.mu.Lock()
+	defer z.mu.Unlock()
+
+	z.tick++
+	victim := -1
+	for j := range z.cache {
+		entry := &z.cache[j]
+		if entry.block == i {
+			// Another ReadAt decoded the same block meanwhile.
+			entry.used = z.tick
+			return
+		}
+		if victim < 0 || entry.used < z.cache[victim].used {
+			victim = j
+		}
+	}
+	if len(z.cache) < seekableCacheBlocks {
+		z.cache = append(z.cache, seekableCacheEntry{})
+		victim = len(z.cache) - 1
+	}
+
+	z.cache[victim] = seekableCacheEntry{block: i, data: data, used: z.tick}
+}
+
+// decodeBlock reads and decodes block i into a new slice.
+func (z *SeekableReader) decodeBlock(i int) ([]byte, error) {
+	b := z.blocks[i]
+
+	src := make([]byte, b.payloadLen)
+	if err := readFullAt(z.r, src, b.payloadOff); err != nil {
+		return nil, err
+	}
+	if b.payloadLen == b.rawLen {
+		// Stored blocks carry the raw bytes.
+		return src, nil
+	}
+
+	out, nRead, err := DecompressNInto(src, make([]byte, b.rawLen))
+	if err != nil {
+		return nil, err
+	}
+	if nRead != len(src) || len(out) != b.rawLen {
+		return nil, ErrInvalidBlock
+	}
+	return out, nil
+}
+
+// readFullAt fills p from r at off and maps a short read to ErrUnexpectedEOF.
+func readFullAt(r io.ReaderAt, p []byte, off int64) error {
+	n, err := r.ReadAt(p, off)
+	if n == len(p) {
+		return nil
+	}
+	if err == nil || errors.Is(err, io.EOF) {
+		return ErrUnexpectedEOF
+	}
+
+	return err
+}
@@ -0,0 +1,372 @@
+package lzo
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"math/rand"
+	"sync"
+	"testing"
+	"testing/iotest"
+)
+
+// writeSeekable compresses data into a seekable container.
+func writeSeekable(t testing.TB, data []byte, opts *CompressOptions) []byte {
+	t.Helper()
+
+	var out bytes.Buffer
+	w := NewSeekableWriter(&out, opts)
+	if _, err := w.Write(data); err != nil {
+		t.Fatalf("Write failed: %v", err)
+	}
+	if err := w.Close(); err != nil {
+		t.Fatalf("Close failed: %v", err)
+	}
+	return out.Bytes()
+}
+
+// openSeekable opens a SeekableReader over container.
+func openSeekable(t testing.TB, container []byte) *SeekableReader {
+	t.Helper()
+
+	r, err := NewSeekableReader(bytes.NewReader(container), int64(len(container)))
+	if err != nil {
+		t.Fatalf("NewSeekableReader failed: %v", err)
+	}
+	return r
+}
+
+func TestSeekableRoundTrip(t *testing.T) {
+	for _, in := range testInputSet(t) {
+		for _, level := range []int{1, 9} {
+			t.Run(fmt.Sprintf("%s/level-%d", in.name, level), func(t *testing.T) {
+				container := writeSeekable(t, in.data, &CompressOptions{Level: level, BlockSize: 1000})
+				r := openSeekable(t, container)
+				if r.Size() != int64(len(in.data)) {
+					t.Fatalf("Size = %d, want %d", r.Size(), len(in.data))
+				}
+
+				out, err := io.ReadAll(r)
+				if err != nil {
+					t.Fatalf("ReadAll failed: %v", err)
+				}
+				if !bytes.Equal(out, in.data) {
+					t.Fatalf("round-trip mismatch: got=%d want=%d", len(out), len(in.data))
+				}
+			})
+		}
+	}
+}
+
+func TestSeekableIndexDescribesBlocks(t *testing.T) {
+	// Compressible and random blocks alternate, so the index holds both
+	// compressed and stored payloads.
+	var data []byte
+	for i := range 6 {
+		if i%2 == 0 {
+			data = append(data, bytes.Repeat([]byte("seekable"), 128)...)
+		} else {
+			data = append(data, benchmarkRandomBytes(1024)...)
+		}
+	}
+	data = append(data, "tail"...)
+
+	container := writeSeekable(t, data, &CompressOptions{BlockSize: 1024})
+	r := openSeekable(t, container)
+	if len(r.blocks) != 7 {
+		t.Fatalf("index has %d blocks, want 7", len(r.blocks))
+	}
+
+	stored := 0
+	for i, b := range r.blocks {
+		if b.rawOff != int64(i*1024) {
+			t.Fatalf("block %d: raw offset %d, want %d", i, b.rawOff, i*1024)
+		}
+		if b.payloadLen == b.rawLen {
+			stored++
+		}
+	}
+	if stored != 4 {
+		t.Fatalf("%d stored blocks, want 4", stored)
+	}
+}
+
+func TestSeekableReadAtRandomRanges(t *testing.T) {
+	data := benchmarkMixedBytes(200 << 10)
+	r := openSeekable(t, writeSeekable(t, data, &CompressOptions{Level: 5, BlockSize: 4096}))
+	rng := rand.New(rand.NewSource(1))
+
+	for range 500 {
+		off := rng.Intn(len(data))
+		n := rng.Intn(3*4096 + 1)
+		buf := make([]byte, n)
+
+		got, err := r.ReadAt(buf, int64(off))
+		want := min(n, len(data)-off)
+		if got != want || !bytes.Equal(buf[:got], data[off:off+want]) {
+			t.Fatalf("ReadAt(%d, %d) = %d bytes, want %d", n, off, got, want)
+		}
+		if want < n && err != io.EOF {
+			t.Fatalf("ReadAt(%d, %d) short read error = %v, want io.EOF", n, off, err)
+		}
+		if want == n && err != nil {
+			t.Fatalf("ReadAt(%d, %d) failed: %v", n, off, err)
+		}
+	}
+
+	if n, err := r.ReadAt(make([]byte, 1), int64(len(data))); n != 0 || err != io.EOF {
+		t.Fatalf("ReadAt at end = %d, %v; want 0, io.EOF", n, err)
+	}
+	if _, err := r.ReadAt(make([]byte, 1), -1); !errors.Is(err, ErrInvalidOffset) {
+		t.Fatalf("ReadAt(-1) error = %v, want ErrInvalidOffset", err)
+	}
+}
+
+func TestSeekableSeek(t *testing.T) {
+	data := benchmarkMixedBytes(50 << 10)
+	r := openSeekable(t, writeSeekable(t, data, &CompressOptions{BlockSize: 4096}))
+
+	cases := []struct {
+		offset int64
+		whence int
+		want   int64
+	}{
+		{offset: 10000, whence: io.SeekStart, want: 10000},
+		{offset: -5000, whence: io.SeekCurrent, want: 5000},
+		{offset: -100, whence: io.SeekEnd, want: int64(len(data)) - 100},
+	}
+	for _, tc := range cases {
+		pos, err := r.Seek(tc.offset, tc.whence)
+		if err != nil || pos != tc.want {
+			t.Fatalf("Seek(%d, %d) = %d, %v; want %d", tc.offset, tc.whence, pos, err, tc.want)
+		}
+
+		buf := make([]byte, 50)
+		n, err := io.ReadFull(r, buf)
+		if err != nil || !bytes.Equal(buf[:n], data[pos:pos+50]) {
+			t.Fatalf("Read after Seek(%d, %d) mismatch: %v", tc.offset, tc.whence, err)
+		}
+		if _, err := r.Seek(pos, io.SeekStart); err != nil {
+			t.Fatalf("Seek back failed: %v", err)
+		}
+	}
+
+	if _, err := r.Seek(10, io.SeekEnd); err != nil {
+		t.Fatalf("Seek past end failed: %v", err)
+	}
+	if n, err := r.Read(make([]byte, 1)); n != 0 || err != io.EOF {
+		t.Fatalf("Read past end = %d, %v; want 0, io.EOF", n, err)
+	}
+	if _, err := r.Seek(-1, io.SeekStart); !errors.Is(err, ErrInvalidOffset) {
+		t.Fatalf("negative Seek error = %v, want ErrInvalidOffset", err)
+	}
+	if _, err := r.Seek(0, 42); !errors.Is(err, ErrInvalidOffset) {
+		t.Fatalf("invalid whence error = %v, want ErrInvalidOffset", err)
+	}
+}
+
+func TestSeekableConformsToIOReader(t *testing.T) {
+	data := benchmarkMixedBytes(40 << 10)
+	container := writeSeekable(t, data, &CompressOptions{Level: 5, BlockSize: 4096})
+
+	// TestReader also exercises ReadAt and Seek.
+	if err := iotest.TestReader(openSeekable(t, container), data); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestSeekableCacheEvictsLeastRecentlyUsed(t *testing.T) {
+	data := benchmarkMixedBytes(64 << 10)
+	r := openSeekable(t, writeSeekable(t, data, &CompressOptions{BlockSize: 1024}))
+
+	buf := make([]byte, 1)
+	for i := range seekableCacheBlocks + 1 {
+		if _, err := r.ReadAt(buf, int64(i*1024)); err != nil {
+			t.Fatalf("ReadAt block %d failed: %v", i, err)
+		}
+		// Keep block 0 recently used so block 1 is evicted instead.
+		if _, err := r.ReadAt(buf, 0); err != nil {
+			t.Fatalf("ReadAt block 0 failed: %v", err)
+		}
+	}
+
+	if len(r.cache) != seekableCacheBlocks {
+		t.Fatalf("cache holds %d blocks, want %d", len(r.cache), seekableCacheBlocks)
+	}
+	cached := make(map[int]bool)
+	for _, entry := range r.cache {
+		cached[entry.block] = true
+	}
+	if !cached[0] || cached[1] || !cached[seekableCacheBlocks] {
+		t.Fatalf("cached blocks %v, want 0 and %d but not 1", cached, seekableCacheBlocks)
+	}
+}
+
+func TestSeekableConcurrentReadAt(t *testing.T) {
+	data := benchmarkMixedBytes(256 << 10)
+	r := openSeekable(t, writeSeekable(t, data, &CompressOptions{BlockSize: 4096}))
+
+	var wg sync.WaitGroup
+	errs := make(chan error, 8)
+	for worker := range 8 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+
+			rng := rand.New(rand.NewSource(int64(worker)))
+			buf := make([]byte, 6000)
+			for range 200 {
+				off := rng.Intn(len(data) - len(buf))
+				if _, err := r.ReadAt(buf, int64(off)); err != nil {
+					errs <- err
+					return
+				}
+				if !bytes.Equal(buf, data[off:off+len(buf)]) {
+					errs <- fmt.Errorf("ReadAt(%d) mismatch", off)
+					return
+				}
+			}
+		}()
+	}
+	wg.Wait()
+	close(errs)
+
+	for err := range errs {
+		t.Fatal(err)
+	}
+}
+
+func TestSeekableWriterCloseAndReset(t *testing.T) {
+	var out bytes.Buffer
+	w := NewSeekableWriter(&out, &CompressOptions{BlockSize: 64})
+	if _, err := w.Write([]byte("first container")); err != nil {
+		t.Fatalf("Write failed: %v", err)
+	}
+	if err := w.Flush(); err != nil {
+		t.Fatalf("Flush failed: %v", err)
+	}
+	if err := w.Close(); err != nil {
+		t.Fatalf("Close failed: %v", err)
+	}
+	if err := w.Close(); err != nil {
+		t.Fatalf("second Close failed: %v", err)
+	}
+	if _, err := w.Write([]byte("x")); !errors.Is(err, ErrWriterClosed) {
+		t.Fatalf("Write after Close error = %v, want ErrWriterClosed", err)
+	}
+
+	var second bytes.Buffer
+	w.Reset(&second)
+	if err := w.Close(); err != nil {
+		t.Fatalf("Close of empty container failed: %v", err)
+	}
+	r := openSeekable(t, second.Bytes())
+	if r.Size() != 0 || len(second.Bytes()) != seekableFooterLen {
+		t.Fatalf("empty container: size %d, %d bytes", r.Size(), len(second.Bytes()))
+	}
+	if n, err := r.Read(make([]byte, 1)); n != 0 || err != io.EOF {
+		t.Fatalf("Read of empty container = %d, %v; want 0, io.EOF", n, err)
+	}
+
+	wantErr := errors.New("sink failed")
+	w.Reset(failingWriter{err: wantErr})
+	if _, err := w.Write(make([]byte, 100)); !errors.Is(err, wantErr) {
+		t.Fatalf("Write error = %v, want %v", err, wantErr)
+	}
+	if err := w.Close(); !errors.Is(err, wantErr) {
+		t.Fatalf("Close error = %v, want %v", err, wantErr)
+	}
+}
+
+func TestSeekableReaderRejectsInvalidIndex(t *testing.T) {
+	data := bytes.Repeat([]byte("seekable index "), 400)
+	container := writeSeekable(t, data, &CompressOptions{BlockSize: 1024})
+	indexOff := int(binary.LittleEndian.Uint64(container[len(container)-seekableFooterLen:]))
+
+	corrupt := func(edit func(b []byte)) []byte {
+		b := bytes.Clone(container)
+		edit(b)
+		return b
+	}
+	footer := len(container) - seekableFooterLen
+	cases := []struct {
+		name      string
+		container []byte
+		want      error
+	}{
+		{name: "short", container: container[:seekableFooterLen-1], want: ErrInvalidIndex},
+		{name: "magic", container: corrupt(func(b []byte) { b[len(b)-1] ^= 0xff }), want: ErrInvalidIndex},
+		{name: "truncated", container: container[1:], want: ErrInvalidIndex},
+		{name: "count", container: corrupt(func(b []byte) { b[footer+8]++ }), want: ErrInvalidIndex},
+		{name: "index-offset", container: corrupt(func(b []byte) { b[footer] += seekableEntryLen }), want: ErrInvalidIndex},
+		{name: "raw-offset", container: corrupt(func(b []byte) { b[indexOff+seekableEntryLen]++ }), want: ErrInvalidIndex},
+		{name: "payload-offset", container: corrupt(func(b []byte) { b[indexOff+seekableEntryLen+8]++ }), want: ErrInvalidIndex},
+		{name: "raw-size", container: corrupt(func(b []byte) { binary.LittleEndian.PutUint32(b[indexOff+16:], 0) }), want: ErrInvalidIndex},
+		{name: "payload-size", container: corrupt(func(b []byte) { b[indexOff+20]++ }), want: ErrInvalidIndex},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			_, err := NewSeekableReader(bytes.NewReader(tc.container), int64(len(tc.container)))
+			if !errors.Is(err, tc.want) {
+				t.Fatalf("NewSeekableReader error = %v, want %v", err, tc.want)
+			}
+		})
+	}
+
+	// A corrupt payload is only detected when its block is read.
+	broken := corrupt(func(b []byte) { b[0] = 0xff })
+	r := openSeekable(t, broken)
+	if _, err := r.ReadAt(make([]byte, 10), 0); err == nil {
+		t.Fatal("ReadAt of corrupt block succeeded")
+	}
+	if _, err := r.ReadAt(make([]byte, 10), 2000); err != nil {
+		t.Fatalf("ReadAt of intact block failed: %v", err)
+	}
+
+	if _, err := NewSeekableReader(bytes.NewReader(container), int64(len(container))+10); !errors.Is(err, ErrUnexpectedEOF) {
+		t.Fatalf("oversized size error = %v, want ErrUnexpectedEOF", err)
+	}
+}
+
+func TestSeekableReaderRejectsTrailingPayloadBytes(t *testing.T) {
+	data := bytes.Repeat([]byte("trailing payload "), 100)
+	compressed, err := Compress(data, nil)
+	if err != nil {
+		t.Fatalf("Compress failed: %v", err)
+	}
+
+	// The payload decodes to the right size but does not end at its terminator.
+	container := append(compressed, 0xaa, 0xbb)
+	indexOff := len(container)
+	container = putSeekableEntry(container, seekableEntry{rawLen: len(data), payloadLen: indexOff})
+	container = binary.LittleEndian.AppendUint64(container, uint64(indexOff))
+	container = binary.LittleEndian.AppendUint64(container, 1)
+	container = append(container, seekableMagic[:]...)
+
+	r := openSeekable(t, container)
+	if _, err := r.ReadAt(make([]byte, 10), 0); !errors.Is(err, ErrInvalidBlock) {
+		t.Fatalf("ReadAt error = %v, want ErrInvalidBlock", err)
+	}
+}
+
+func FuzzSeekableReader(f *testing.F) {
+	f.Add(writeSeekable(f, bytes.Repeat([]byte("fuzz seekable "), 200), &CompressOptions{BlockSize: 512}), int64(100), uint16(700))
+	f.Add(writeSeekable(f, nil, nil), int64(0), uint16(1))
+
+	f.Fuzz(func(t *testing.T, container []byte, off int64, n uint16) {
+		r, err := NewSeekableReader(bytes.NewReader(container), int64(len(container)))
+		if err != nil {
+			return
+		}
+
+		// Malformed payloads may fail to decode but must not panic.
+		buf := make([]byte, n)
+		got, err := r.ReadAt(buf, off)
+		if err == nil && got != len(buf) {
+			t.Fatalf("ReadAt returned %d bytes without error, want %d", got, len(buf))
+		}
+	})
+}
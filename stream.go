@@ -0,0 +1,96 @@
+// SPDX-License-Identifier: MIT
+// Copyright (c) 2026 WoozyMasta
+// Source: github.com/woozymasta/lzo
+
+package lzo
+
+import "encoding/binary"
+
+// Block-framed stream layout used by Writer and Reader.
+//
+// The stream is a sequence of blocks. Each block starts with a header of two
+// little-endian uint32 values: the uncompressed block size followed by the
+// stored payload size. When both sizes are equal the payload is the raw block
+// (used for incompressible input); otherwise it is one complete LZO1X stream.
+// A single zero uncompressed size marks the end of the stream.
+//
+// In a linked stream (CompressOptions.LinkedBlocks) each block is compressed
+// with the last 0xbfff bytes of the preceding uncompressed data as a preset
+// dictionary, so its payload may reference them; the layout is unchanged.
+
+const (
+	// DefaultBlockSize is the uncompressed block size used when CompressOptions.BlockSize is zero.
+	DefaultBlockSize = 256 << 10
+
+	// MaxBlockSize is the largest uncompressed block size written by Writer and accepted by Reader.
+	MaxBlockSize = 64 << 20
+
+	// blockSizeFieldLen is the length of one size field in the block header.
+	blockSizeFieldLen = 4
+
+	// blockHeaderLen is the length of a data block header (uncompressed and payload sizes).
+	blockHeaderLen = 2 * blockSizeFieldLen
+)
+
+// streamBlockSize returns the effective block size for opts.
+func streamBlockSize(opts *CompressOptions) int {
+	switch {
+	case opts.BlockSize <= 0:
+		return DefaultBlockSize
+	case opts.BlockSize > MaxBlockSize:
+		return MaxBlockSize
+	default:
+		return opts.BlockSize
+	}
+}
+
+// putBlockHeader stores the block sizes into hdr[:blockHeaderLen].
+func putBlockHeader(hdr []byte, rawLen, payloadLen int) {
+	binary.LittleEndian.PutUint32(hdr[:blockSizeFieldLen], uint32(rawLen))     //nolint:gosec // G115: bounded by MaxBlockSize
+	binary.LittleEndian.PutUint32(hdr[blockSizeFieldLen:], uint32(payloadLen)) //nolint:gosec // G115: bounded by MaxCompressedSize(MaxBlockSize)
+}
+
+// validBlockSizes reports whether a block header describes a block this package can produce.
+func validBlockSizes(rawLen, payloadLen uint32) bool {
+	if rawLen == 0 || rawLen > MaxBlockSize || payloadLen == 0 || payloadLen > rawLen {
+		return false
+	}
+
+	return true
+}
+
+// streamHistory keeps the tail of the uncompressed data of a linked stream,
+// the window the next block may reference.
+type streamHistory struct {
+	buf []byte // buf ends with the window; it holds up to twice hcMaxDist bytes.
+}
+
+// window returns the last hcMaxDist bytes of the stream so far.
+func (h *streamHistory) window() []byte {
+	return h.buf[len(h.buf)-min(len(h.buf), hcMaxDist):]
+}
+
+// add appends block to the history, sliding the window down only when the
+// buffer is full so most blocks cost a single append.
+func (h *streamHistory) add(block []byte) {
+	if len(block) >= hcMaxDist {
+		h.buf = append(h.buf[:0], block[len(block)-hcMaxDist:]...)
+		return
+	}
+
+	if len(h.buf)+len(block) > cap(h.buf) {
+		keep := h.window()
+		keep = keep[len(keep)-min(len(keep), hcMaxDist-len(block)):]
+		if cap(h.buf) < 2*hcMaxDist {
+			h.buf = append(make([]byte, 0, 2*hcMaxDist), keep...)
+		} else {
+			h.buf = h.buf[:copy(h.buf[:cap(h.buf)], keep)]
+		}
+	}
+	h.buf = append(h.buf, block...)
+}
+
+// reset empties the history and keeps its buffer.
+func (h *streamHistory) reset() {
+	h.buf = h.buf[:0]
+}
@@ -0,0 +1,580 @@
+package lzo
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"runtime"
+	"testing"
+	"testing/iotest"
+	"time"
+)
+
+// parseBlockStream decodes a block-framed stream without using Reader.
+func parseBlockStream(t *testing.T, stream []byte) (out []byte, blocks int) {
+	t.Helper()
+
+	for {
+		if len(stream) < blockSizeFieldLen {
+			t.Fatalf("stream truncated before end marker")
+		}
+		rawLen := int(binary.LittleEndian.Uint32(stream))
+		if rawLen == 0 {
+			if len(stream) != blockSizeFieldLen {
+				t.Fatalf("%d trailing bytes after end marker", len(stream)-blockSizeFieldLen)
+			}
+			return out, blocks
+		}
+
+		payloadLen := int(binary.LittleEndian.Uint32(stream[blockSizeFieldLen:]))
+		payload := stream[blockHeaderLen : blockHeaderLen+payloadLen]
+		if payloadLen == rawLen {
+			out = append(out, payload...)
+		} else {
+			decoded, err := DecompressInto(payload, make([]byte, rawLen))
+			if err != nil {
+				t.Fatalf("block %d: DecompressInto failed: %v", blocks, err)
+			}
+			if len(decoded) != rawLen {
+				t.Fatalf("block %d: decoded %d bytes, want %d", blocks, len(decoded), rawLen)
+			}
+			out = append(out, decoded...)
+		}
+
+		stream = stream[blockHeaderLen+payloadLen:]
+		blocks++
+	}
+}
+
+func TestWriterRoundTripAcrossLevelsAndBlockSizes(t *testing.T) {
+	for _, in := range testInputSet(t) {
+		for _, level := range []int{1, 9} {
+			for _, blockSize := range []int{0, 1, 1000, 64 << 10} {
+				name := fmt.Sprintf("%s/level-%d/block-%d", in.name, level, blockSize)
+				t.Run(name, func(t *testing.T) {
+					var stream bytes.Buffer
+					w := NewWriter(&stream, &CompressOptions{Level: level, BlockSize: blockSize})
+					if _, err := w.Write(in.data); err != nil {
+						t.Fatalf("Write failed: %v", err)
+					}
+					if err := w.Close(); err != nil {
+						t.Fatalf("Close failed: %v", err)
+					}
+
+					out, blocks := parseBlockStream(t, stream.Bytes())
+					if !bytes.Equal(out, in.data) {
+						t.Fatalf("round-trip mismatch: got=%d want=%d", len(out), len(in.data))
+					}
+
+					effective := blockSize
+					if effective == 0 {
+						effective = DefaultBlockSize
+					}
+					if want := (len(in.data) + effective - 1) / effective; blocks != want {
+						t.Fatalf("blocks = %d, want %d", blocks, want)
+					}
+				})
+			}
+		}
+	}
+}
+
+func TestWriterSmallWritesMatchSingleWrite(t *testing.T) {
+	data := benchmarkMixedBytes(100 << 10)
+	opts := &CompressOptions{Level: 5, BlockSize: 16 << 10}
+
+	var single bytes.Buffer
+	w := NewWriter(&single, opts)
+	if _, err := w.Write(data); err != nil {
+		t.Fatalf("Write failed: %v", err)
+	}
+	if err := w.Close(); err != nil {
+		t.Fatalf("Close failed: %v", err)
+	}
+
+	var chunked bytes.Buffer
+	w = NewWriter(&chunked, opts)
+	for rest := data; len(rest) > 0; {
+		n := min(777, len(rest))
+		written, err := w.Write(rest[:n])
+		if err != nil {
+			t.Fatalf("Write failed: %v", err)
+		}
+		if written != n {
+			t.Fatalf("Write returned %d, want %d", written, n)
+		}
+		rest = rest[n:]
+	}
+	if err := w.Close(); err != nil {
+		t.Fatalf("Close failed: %v", err)
+	}
+
+	if !bytes.Equal(single.Bytes(), chunked.Bytes()) {
+		t.Fatal("chunked writes produced a different stream")
+	}
+}
+
+func TestWriterStoresIncompressibleBlocks(t *testing.T) {
+	data := benchmarkRandomBytes(4096)
+
+	var stream bytes.Buffer
+	w := NewWriter(&stream, nil)
+	if _, err := w.Write(data); err != nil {
+		t.Fatalf("Write failed: %v", err)
+	}
+	if err := w.Close(); err != nil {
+		t.Fatalf("Close failed: %v", err)
+	}
+
+	if want := blockHeaderLen + len(data) + blockSizeFieldLen; stream.Len() != want {
+		t.Fatalf("stream length = %d, want %d", stream.Len(), want)
+	}
+	if !bytes.Equal(stream.Bytes()[blockHeaderLen:blockHeaderLen+len(data)], data) {
+		t.Fatal("stored block payload differs from input")
+	}
+}
+
+func TestWriterFlushEmitsPendingBlock(t *testing.T) {
+	var stream bytes.Buffer
+	w := NewWriter(&stream, nil)
+	if _, err := w.Write([]byte("flush-me")); err != nil {
+		t.Fatalf("Write failed: %v", err)
+	}
+	if stream.Len() != 0 {
+		t.Fatalf("Write emitted %d bytes before block was full", stream.Len())
+	}
+	if err := w.Flush(); err != nil {
+		t.Fatalf("Flush failed: %v", err)
+	}
+	if stream.Len() == 0 {
+		t.Fatal("Flush did not emit pending block")
+	}
+	if err := w.Flush(); err != nil {
+		t.Fatalf("empty Flush failed: %v", err)
+	}
+	if err := w.Close(); err != nil {
+		t.Fatalf("Close failed: %v", err)
+	}
+
+	out, blocks := parseBlockStream(t, stream.Bytes())
+	if string(out) != "flush-me" || blocks != 1 {
+		t.Fatalf("decoded %q in %d blocks", out, blocks)
+	}
+}
+
+func TestWriterCloseAndReset(t *testing.T) {
+	var first bytes.Buffer
+	w := NewWriter(&first, &CompressOptions{Level: 9})
+	if _, err := w.Write([]byte("first stream")); err != nil {
+		t.Fatalf("Write failed: %v", err)
+	}
+	if err := w.Close(); err != nil {
+		t.Fatalf("Close failed: %v", err)
+	}
+	if err := w.Close(); err != nil {
+		t.Fatalf("second Close failed: %v", err)
+	}
+	if _, err := w.Write([]byte("x")); !errors.Is(err, ErrWriterClosed) {
+		t.Fatalf("expected ErrWriterClosed, got %v", err)
+	}
+
+	var second bytes.Buffer
+	w.Reset(&second)
+	if _, err := io.Copy(w, bytes.NewReader([]byte("second stream"))); err != nil {
+		t.Fatalf("io.Copy failed: %v", err)
+	}
+	if err := w.Close(); err != nil {
+		t.Fatalf("Close after Reset failed: %v", err)
+	}
+
+	if out, _ := parseBlockStream(t, second.Bytes()); string(out) != "second stream" {
+		t.Fatalf("decoded %q after Reset", out)
+	}
+}
+
+type failingWriter struct {
+	err error
+}
+
+func (w failingWriter) Write([]byte) (int, error) {
+	return 0, w.err
+}
+
+func TestWriterReportsUnderlyingErrors(t *testing.T) {
+	wantErr := errors.New("sink failed")
+	w := NewWriter(failingWriter{err: wantErr}, &CompressOptions{BlockSize: 8})
+
+	if _, err := w.Write([]byte("0123456789")); !errors.Is(err, wantErr) {
+		t.Fatalf("Write error = %v, want %v", err, wantErr)
+	}
+	if err := w.Close(); !errors.Is(err, wantErr) {
+		t.Fatalf("Close error = %v, want %v", err, wantErr)
+	}
+}
+
+// writeBlockStream compresses data into a block-framed stream.
+func writeBlockStream(t *testing.T, data []byte, opts *CompressOptions) []byte {
+	t.Helper()
+
+	var stream bytes.Buffer
+	w := NewWriter(&stream, opts)
+	if _, err := w.Write(data); err != nil {
+		t.Fatalf("Write failed: %v", err)
+	}
+	if err := w.Close(); err != nil {
+		t.Fatalf("Close failed: %v", err)
+	}
+	return stream.Bytes()
+}
+
+func TestReaderRoundTrip(t *testing.T) {
+	for _, in := range testInputSet(t) {
+		for _, level := range []int{1, 9} {
+			t.Run(fmt.Sprintf("%s/level-%d", in.name, level), func(t *testing.T) {
+				stream := writeBlockStream(t, in.data, &CompressOptions{Level: level, BlockSize: 1000})
+
+				out, err := io.ReadAll(NewReader(bytes.NewReader(stream)))
+				if err != nil {
+					t.Fatalf("ReadAll failed: %v", err)
+				}
+				if !bytes.Equal(out, in.data) {
+					t.Fatalf("Read round-trip mismatch: got=%d want=%d", len(out), len(in.data))
+				}
+
+				var copied bytes.Buffer
+				n, err := io.Copy(&copied, NewReader(bytes.NewReader(stream)))
+				if err != nil {
+					t.Fatalf("io.Copy failed: %v", err)
+				}
+				if n != int64(len(in.data)) || !bytes.Equal(copied.Bytes(), in.data) {
+					t.Fatalf("WriteTo round-trip mismatch: got=%d want=%d", n, len(in.data))
+				}
+			})
+		}
+	}
+}
+
+func TestReaderConformsToIOReader(t *testing.T) {
+	data := benchmarkMixedBytes(40 << 10)
+	stream := writeBlockStream(t, data, &CompressOptions{Level: 5, BlockSize: 4096})
+
+	if err := iotest.TestReader(NewReader(bytes.NewReader(stream)), data); err != nil {
+		t.Fatal(err)
+	}
+	if err := iotest.TestReader(NewReader(iotest.OneByteReader(bytes.NewReader(stream))), data); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestReaderTruncatedStream(t *testing.T) {
+	data := bytes.Repeat([]byte("truncated-frame"), 512)
+	stream := writeBlockStream(t, data, &CompressOptions{BlockSize: 2048})
+
+	for cut := 1; cut < len(stream); cut++ {
+		_, err := io.ReadAll(NewReader(bytes.NewReader(stream[:len(stream)-cut])))
+		if !errors.Is(err, ErrUnexpectedEOF) {
+			t.Fatalf("cut=%d: expected ErrUnexpectedEOF, got %v", cut, err)
+		}
+	}
+}
+
+func TestReaderRejectsInvalidBlocks(t *testing.T) {
+	valid := writeBlockStream(t, bytes.Repeat([]byte("block"), 100), nil)
+	corrupt := func(field int, value uint32) []byte {
+		stream := append([]byte(nil), valid...)
+		binary.LittleEndian.PutUint32(stream[field:], value)
+		return stream
+	}
+
+	for _, tc := range []struct {
+		name   string
+		stream []byte
+		want   error
+	}{
+		{name: "oversized-block", stream: corrupt(0, MaxBlockSize+1), want: ErrInvalidBlock},
+		{name: "payload-larger-than-block", stream: corrupt(blockSizeFieldLen, 501), want: ErrInvalidBlock},
+		{name: "empty-payload", stream: corrupt(blockSizeFieldLen, 0), want: ErrInvalidBlock},
+		{name: "decodes-past-block", stream: corrupt(0, 499), want: ErrOutputOverrun},
+		{name: "decodes-short-of-block", stream: corrupt(0, 501), want: ErrInvalidBlock},
+	} {
+		_, err := io.ReadAll(NewReader(bytes.NewReader(tc.stream)))
+		if !errors.Is(err, tc.want) {
+			t.Fatalf("%s: expected %v, got %v", tc.name, tc.want, err)
+		}
+	}
+}
+
+func TestReaderReset(t *testing.T) {
+	first := writeBlockStream(t, []byte("first stream"), nil)
+	second := writeBlockStream(t, []byte("second stream"), &CompressOptions{Level: 9})
+
+	r := NewReader(bytes.NewReader(first))
+	if out, err := io.ReadAll(r); err != nil || string(out) != "first stream" {
+		t.Fatalf("first stream: %q, %v", out, err)
+	}
+
+	r.Reset(bytes.NewReader(second))
+	if out, err := io.ReadAll(r); err != nil || string(out) != "second stream" {
+		t.Fatalf("second stream: %q, %v", out, err)
+	}
+}
+
+func TestReaderStopsAtEndMarker(t *testing.T) {
+	stream := writeBlockStream(t, []byte("payload"), nil)
+	src := bytes.NewReader(append(append([]byte(nil), stream...), "trailer"...))
+
+	if out, err := io.ReadAll(NewReader(src)); err != nil || string(out) != "payload" {
+		t.Fatalf("ReadAll: %q, %v", out, err)
+	}
+	if rest, _ := io.ReadAll(src); string(rest) != "trailer" {
+		t.Fatalf("bytes after end marker were consumed: %q", rest)
+	}
+}
+
+func TestWriterConcurrencyMatchesSequential(t *testing.T) {
+	data := benchmarkMixedBytes(300 << 10)
+	data = append(data, benchmarkRandomBytes(20<<10)...)
+	want := writeBlockStream(t, data, &CompressOptions{Level: 5, BlockSize: 16 << 10})
+
+	for _, concurrency := range []int{2, 3, 8} {
+		opts := &CompressOptions{Level: 5, BlockSize: 16 << 10, Concurrency: concurrency}
+		if got := writeBlockStream(t, data, opts); !bytes.Equal(got, want) {
+			t.Fatalf("concurrency=%d: stream differs from sequential Writer", concurrency)
+		}
+
+		// Odd-sized writes with flushes in between.
+		var stream bytes.Buffer
+		w := NewWriter(&stream, opts)
+		var seq bytes.Buffer
+		ws := NewWriter(&seq, &CompressOptions{Level: 5, BlockSize: 16 << 10})
+		for i, rest := 0, data; len(rest) > 0; i++ {
+			n := min(5000+i*977, len(rest))
+			if _, err := w.Write(rest[:n]); err != nil {
+				t.Fatalf("Write failed: %v", err)
+			}
+			if _, err := ws.Write(rest[:n]); err != nil {
+				t.Fatalf("sequential Write failed: %v", err)
+			}
+			if i%7 == 0 {
+				if err := w.Flush(); err != nil {
+					t.Fatalf("Flush failed: %v", err)
+				}
+				if err := ws.Flush(); err != nil {
+					t.Fatalf("sequential Flush failed: %v", err)
+				}
+				if !bytes.Equal(stream.Bytes(), seq.Bytes()) {
+					t.Fatalf("concurrency=%d: Flush left %d bytes written, want %d", concurrency, stream.Len(), seq.Len())
+				}
+			}
+			rest = rest[n:]
+		}
+		if err := w.Close(); err != nil {
+			t.Fatalf("Close failed: %v", err)
+		}
+		if err := ws.Close(); err != nil {
+			t.Fatalf("sequential Close failed: %v", err)
+		}
+		if !bytes.Equal(stream.Bytes(), seq.Bytes()) {
+			t.Fatalf("concurrency=%d: chunked stream differs from sequential Writer", concurrency)
+		}
+	}
+}
+
+func TestWriterConcurrencyWorkers(t *testing.T) {
+	// Concurrency bounds the goroutines, however many blocks are written,
+	// and Close stops them.
+	before := runtime.NumGoroutine()
+	var stream bytes.Buffer
+	w := NewWriter(&stream, &CompressOptions{Level: 5, BlockSize: 4 << 10, Concurrency: 4})
+	data := logTestInput(256 << 10)
+	for len(data) > 0 {
+		n := min(10000, len(data))
+		if _, err := w.Write(data[:n]); err != nil {
+			t.Fatalf("Write failed: %v", err)
+		}
+		data = data[n:]
+		if n := runtime.NumGoroutine(); n > before+4 {
+			t.Fatalf("%d goroutines while writing, want at most %d", n, before+4)
+		}
+	}
+	if err := w.Close(); err != nil {
+		t.Fatalf("Close failed: %v", err)
+	}
+
+	for deadline := time.Now().Add(5 * time.Second); runtime.NumGoroutine() > before; time.Sleep(time.Millisecond) {
+		if time.Now().After(deadline) {
+			t.Fatalf("%d goroutines after Close, want %d", runtime.NumGoroutine(), before)
+		}
+	}
+}
+
+func TestWriterConcurrencyErrorsAndReset(t *testing.T) {
+	wantErr := errors.New("sink failed")
+	w := NewWriter(failingWriter{err: wantErr}, &CompressOptions{BlockSize: 8, Concurrency: 4})
+
+	if _, err := w.Write(bytes.Repeat([]byte("0123456789"), 10)); !errors.Is(err, wantErr) {
+		t.Fatalf("Write error = %v, want %v", err, wantErr)
+	}
+	if err := w.Close(); !errors.Is(err, wantErr) {
+		t.Fatalf("Close error = %v, want %v", err, wantErr)
+	}
+
+	var stream bytes.Buffer
+	w.Reset(&stream)
+	if _, err := w.Write([]byte("after reset")); err != nil {
+		t.Fatalf("Write after Reset failed: %v", err)
+	}
+	if err := w.Close(); err != nil {
+		t.Fatalf("Close after Reset failed: %v", err)
+	}
+	if out, _ := parseBlockStream(t, stream.Bytes()); string(out) != "after reset" {
+		t.Fatalf("decoded %q after Reset", out)
+	}
+}
+
+func TestReaderConcurrency(t *testing.T) {
+	data := benchmarkMixedBytes(200 << 10)
+	data = append(data, benchmarkRandomBytes(10<<10)...)
+	stream := writeBlockStream(t, data, &CompressOptions{Level: 5, BlockSize: 4096})
+
+	for _, concurrency := range []int{0, 2, 5} {
+		opts := &DecompressOptions{Concurrency: concurrency}
+		if err := iotest.TestReader(NewReaderOptions(bytes.NewReader(stream), opts), data); err != nil {
+			t.Fatalf("concurrency=%d: %v", concurrency, err)
+		}
+
+		var copied bytes.Buffer
+		if _, err := io.Copy(&copied, NewReaderOptions(bytes.NewReader(stream), opts)); err != nil {
+			t.Fatalf("concurrency=%d: io.Copy failed: %v", concurrency, err)
+		}
+		if !bytes.Equal(copied.Bytes(), data) {
+			t.Fatalf("concurrency=%d: WriteTo round-trip mismatch", concurrency)
+		}
+
+		// Errors surface only after every block before them.
+		truncated := stream[:len(stream)-100]
+		out, err := io.ReadAll(NewReaderOptions(bytes.NewReader(truncated), opts))
+		if !errors.Is(err, ErrUnexpectedEOF) {
+			t.Fatalf("concurrency=%d: truncated stream error = %v", concurrency, err)
+		}
+		if want := len(data) / 4096 * 4096; len(out) != want || !bytes.Equal(out, data[:want]) {
+			t.Fatalf("concurrency=%d: read %d bytes before the error, want %d", concurrency, len(out), want)
+		}
+
+		r := NewReaderOptions(bytes.NewReader(stream), opts)
+		if _, err := r.Read(make([]byte, 10)); err != nil {
+			t.Fatalf("concurrency=%d: Read failed: %v", concurrency, err)
+		}
+		second := writeBlockStream(t, []byte("second stream"), nil)
+		r.Reset(bytes.NewReader(second))
+		if out, err := io.ReadAll(r); err != nil || string(out) != "second stream" {
+			t.Fatalf("concurrency=%d: after Reset: %q, %v", concurrency, out, err)
+		}
+	}
+}
+
+func TestLinkedBlocksRoundTrip(t *testing.T) {
+	data := benchmarkMixedBytes(300 << 10)
+	data = append(data, benchmarkRandomBytes(20<<10)...)
+	data = append(data, ringTestInput()...)
+
+	for _, blockSize := range []int{4 << 10, 16 << 10, 64 << 10} {
+		for _, level := range []int{1, 5, 9} {
+			opts := &CompressOptions{Level: level, BlockSize: blockSize, LinkedBlocks: true}
+			stream := writeBlockStream(t, data, opts)
+
+			r := NewReaderOptions(bytes.NewReader(stream), &DecompressOptions{LinkedBlocks: true})
+			if err := iotest.TestReader(r, data); err != nil {
+				t.Fatalf("block=%d level=%d: %v", blockSize, level, err)
+			}
+
+			// Concurrency does not change the stream, and the Reader ignores it.
+			opts.Concurrency = 3
+			if got := writeBlockStream(t, data, opts); !bytes.Equal(got, stream) {
+				t.Fatalf("block=%d level=%d: concurrent stream differs from sequential", blockSize, level)
+			}
+			r = NewReaderOptions(bytes.NewReader(stream), &DecompressOptions{LinkedBlocks: true, Concurrency: 4})
+			var copied bytes.Buffer
+			if _, err := io.Copy(&copied, r); err != nil || !bytes.Equal(copied.Bytes(), data) {
+				t.Fatalf("block=%d level=%d: WriteTo round trip failed: %v", blockSize, level, err)
+			}
+		}
+	}
+}
+
+// logTestInput returns size bytes of log lines whose repetition spans blocks.
+func logTestInput(size int) []byte {
+	messages := []string{"request served", "cache miss for key", "upstream timeout", "user logged in", "payload accepted"}
+	var data []byte
+	for i := 0; len(data) < size; i++ {
+		data = fmt.Appendf(data, "ts=%d level=info component=api-%d msg=%q id=%08x\n",
+			1700000000+i*7, i%13, messages[i*7%len(messages)], uint32(i)*2654435761)
+	}
+	return data[:size]
+}
+
+func TestLinkedBlocksImproveSmallBlockRatio(t *testing.T) {
+	data := logTestInput(1 << 20)
+	opts := &CompressOptions{Level: 5, BlockSize: 4 << 10}
+
+	independent := writeBlockStream(t, data, opts)
+	opts.LinkedBlocks = true
+	linked := writeBlockStream(t, data, opts)
+	if len(linked) >= len(independent)*85/100 {
+		t.Fatalf("linked stream has %d bytes, independent %d", len(linked), len(independent))
+	}
+
+	// Without LinkedBlocks the references into earlier blocks cannot be resolved.
+	if _, err := io.ReadAll(NewReader(bytes.NewReader(linked))); !errors.Is(err, ErrLookBehindUnderrun) {
+		t.Fatalf("unlinked Reader error = %v, want ErrLookBehindUnderrun", err)
+	}
+}
+
+func TestLinkedBlocksReset(t *testing.T) {
+	opts := &CompressOptions{BlockSize: 1024, LinkedBlocks: true}
+	first := bytes.Repeat([]byte("history must not leak "), 200)
+
+	var stream bytes.Buffer
+	w := NewWriter(&stream, opts)
+	if _, err := w.Write(first); err != nil {
+		t.Fatalf("Write failed: %v", err)
+	}
+	w.Reset(&stream)
+	stream.Reset()
+	if _, err := w.Write(first[:2000]); err != nil {
+		t.Fatalf("Write after Reset failed: %v", err)
+	}
+	if err := w.Close(); err != nil {
+		t.Fatalf("Close failed: %v", err)
+	}
+	if want := writeBlockStream(t, first[:2000], opts); !bytes.Equal(stream.Bytes(), want) {
+		t.Fatal("Writer kept linked history across Reset")
+	}
+
+	r := NewReaderOptions(bytes.NewReader(writeBlockStream(t, first, opts)), &DecompressOptions{LinkedBlocks: true})
+	if _, err := r.Read(make([]byte, 3000)); err != nil {
+		t.Fatalf("Read failed: %v", err)
+	}
+	r.Reset(bytes.NewReader(stream.Bytes()))
+	if out, err := io.ReadAll(r); err != nil || !bytes.Equal(out, first[:2000]) {
+		t.Fatalf("after Reset: %d bytes, %v", len(out), err)
+	}
+}
+
+func TestStreamHistoryWindow(t *testing.T) {
+	var h streamHistory
+	var all []byte
+	for i, n := range []int{100, 5000, hcMaxDist - 10, 30000, hcMaxDist + 7, 1, 40000, 40000, 40000} {
+		block := benchmarkRandomBytes(n)
+		block[0] = byte(i)
+		h.add(block)
+		all = append(all, block...)
+
+		want := all[len(all)-min(len(all), hcMaxDist):]
+		if !bytes.Equal(h.window(), want) {
+			t.Fatalf("step %d: window has %d bytes, want the last %d", i, len(h.window()), len(want))
+		}
+	}
+}
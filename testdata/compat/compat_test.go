@@ -47,17 +47,31 @@ func TestLibLZO2Compatibility(t *testing.T) {
 				})
 			}
 
-			for _, highCompression := range []bool{false, true} {
-				name := "fast"
-				if highCompression {
-					name = "high"
-				}
-				t.Run("liblzo2-to-ours/"+name, func(t *testing.T) {
-					operation := "compress-fast"
-					if highCompression {
-						operation = "compress-high"
+			for _, method := range compatMethods {
+				t.Run("ours-to-liblzo2/"+method.name, func(t *testing.T) {
+					compressed, err := lzo.Compress(input.data, &lzo.CompressOptions{Method: method.method})
+					if err != nil {
+						t.Fatalf("Compress: %v", err)
+					}
+
+					compressedPath := filepath.Join(t.TempDir(), "input.lzo")
+					if err := os.WriteFile(compressedPath, compressed, 0o600); err != nil {
+						t.Fatalf("WriteFile: %v", err)
+					}
+
+					decoded, err := runLZO2Helper(helper, "decompress", compressedPath, strconv.Itoa(len(input.data)))
+					if err != nil {
+						t.Fatalf("liblzo2 decompress: %v", err)
+					}
+					if !bytes.Equal(decoded, input.data) {
+						t.Fatalf("decoded output mismatch at byte %d", firstMismatch(decoded, input.data))
 					}
-					compressed, err := runLZO2Helper(helper, operation, inputPath)
+				})
+			}
+
+			for _, method := range append([]compatMethod{{name: "high", operation: "compress-high"}}, compatMethods...) {
+				t.Run("liblzo2-to-ours/"+method.name, func(t *testing.T) {
+					compressed, err := runLZO2Helper(helper, method.operation, inputPath)
 					if err != nil {
 						t.Fatalf("liblzo2 compress: %v", err)
 					}
@@ -71,10 +85,292 @@ func TestLibLZO2Compatibility(t *testing.T) {
 					}
 				})
 			}
+
+			for _, method := range compatMethods {
+				t.Run("exact/"+method.name, func(t *testing.T) {
+					want, err := runLZO2Helper(helper, method.operation, inputPath)
+					if err != nil {
+						t.Fatalf("liblzo2 compress: %v", err)
+					}
+
+					got, err := lzo.Compress(input.data, &lzo.CompressOptions{Method: method.method, Exact: true})
+					if err != nil {
+						t.Fatalf("Compress: %v", err)
+					}
+					if !bytes.Equal(got, want) {
+						t.Fatalf("output differs from liblzo2 at byte %d (%d vs %d bytes)", firstMismatch(got, want), len(got), len(want))
+					}
+				})
+			}
+
+			t.Run("exact/1y-fast", func(t *testing.T) {
+				want, err := runLZO2Helper(helper, "compress-1y-fast", inputPath)
+				if err != nil {
+					t.Fatalf("liblzo2 compress: %v", err)
+				}
+
+				got, err := lzo.CompressLZO1Y(input.data, &lzo.CompressOptions{Level: 1, Exact: true})
+				if err != nil {
+					t.Fatalf("CompressLZO1Y: %v", err)
+				}
+				if !bytes.Equal(got, want) {
+					t.Fatalf("output differs from liblzo2 at byte %d (%d vs %d bytes)", firstMismatch(got, want), len(got), len(want))
+				}
+			})
+
+			for level := 1; level <= 9; level++ {
+				t.Run(fmt.Sprintf("exact/999-level-%d", level), func(t *testing.T) {
+					want, err := runLZO2Helper(helper, "compress-level", inputPath, strconv.Itoa(level))
+					if err != nil {
+						t.Fatalf("liblzo2 compress: %v", err)
+					}
+
+					got, err := lzo.Compress(input.data, &lzo.CompressOptions{Method: lzo.MethodLZO1X999, Level: level, Exact: true})
+					if err != nil {
+						t.Fatalf("Compress: %v", err)
+					}
+					if !bytes.Equal(got, want) {
+						t.Fatalf("output differs from liblzo2 at byte %d (%d vs %d bytes)", firstMismatch(got, want), len(got), len(want))
+					}
+				})
+
+				if level < 2 {
+					continue
+				}
+				t.Run(fmt.Sprintf("exact/1y-999-level-%d", level), func(t *testing.T) {
+					want, err := runLZO2Helper(helper, "compress-1y-level", inputPath, strconv.Itoa(level))
+					if err != nil {
+						t.Fatalf("liblzo2 compress: %v", err)
+					}
+
+					got, err := lzo.CompressLZO1Y(input.data, &lzo.CompressOptions{Level: level, Exact: true})
+					if err != nil {
+						t.Fatalf("CompressLZO1Y: %v", err)
+					}
+					if !bytes.Equal(got, want) {
+						t.Fatalf("output differs from liblzo2 at byte %d (%d vs %d bytes)", firstMismatch(got, want), len(got), len(want))
+					}
+				})
+			}
+
+			t.Run("exact/high", func(t *testing.T) {
+				want, err := runLZO2Helper(helper, "compress-high", inputPath)
+				if err != nil {
+					t.Fatalf("liblzo2 compress: %v", err)
+				}
+
+				got, err := lzo.Compress(input.data, &lzo.CompressOptions{Level: 8, Exact: true})
+				if err != nil {
+					t.Fatalf("Compress: %v", err)
+				}
+				if !bytes.Equal(got, want) {
+					t.Fatalf("output differs from liblzo2 at byte %d (%d vs %d bytes)", firstMismatch(got, want), len(got), len(want))
+				}
+			})
+
+			for _, level := range []int{1, 9} {
+				t.Run(fmt.Sprintf("ours-to-liblzo2/1y-level-%d", level), func(t *testing.T) {
+					compressed, err := lzo.CompressLZO1Y(input.data, &lzo.CompressOptions{Level: level})
+					if err != nil {
+						t.Fatalf("CompressLZO1Y: %v", err)
+					}
+
+					compressedPath := filepath.Join(t.TempDir(), "input.lzo")
+					if err := os.WriteFile(compressedPath, compressed, 0o600); err != nil {
+						t.Fatalf("WriteFile: %v", err)
+					}
+
+					decoded, err := runLZO2Helper(helper, "decompress-1y", compressedPath, strconv.Itoa(len(input.data)))
+					if err != nil {
+						t.Fatalf("liblzo2 decompress: %v", err)
+					}
+					if !bytes.Equal(decoded, input.data) {
+						t.Fatalf("decoded output mismatch at byte %d", firstMismatch(decoded, input.data))
+					}
+				})
+			}
+
+			for _, name := range []string{"1y-fast", "1y-high"} {
+				t.Run("liblzo2-to-ours/"+name, func(t *testing.T) {
+					compressed, err := runLZO2Helper(helper, "compress-"+name, inputPath)
+					if err != nil {
+						t.Fatalf("liblzo2 compress: %v", err)
+					}
+
+					decoded, err := lzo.DecompressLZO1Y(compressed, make([]byte, len(input.data)))
+					if err != nil {
+						t.Fatalf("DecompressLZO1Y: %v", err)
+					}
+					if !bytes.Equal(decoded, input.data) {
+						t.Fatalf("decoded output mismatch at byte %d", firstMismatch(decoded, input.data))
+					}
+				})
+			}
+
+			for _, level := range []int{1, 9} {
+				t.Run(fmt.Sprintf("ours-to-liblzo2/1z-level-%d", level), func(t *testing.T) {
+					compressed, err := lzo.CompressLZO1Z(input.data, &lzo.CompressOptions{Level: level})
+					if err != nil {
+						t.Fatalf("CompressLZO1Z: %v", err)
+					}
+
+					compressedPath := filepath.Join(t.TempDir(), "input.lzo")
+					if err := os.WriteFile(compressedPath, compressed, 0o600); err != nil {
+						t.Fatalf("WriteFile: %v", err)
+					}
+
+					decoded, err := runLZO2Helper(helper, "decompress-1z", compressedPath, strconv.Itoa(len(input.data)))
+					if err != nil {
+						t.Fatalf("liblzo2 decompress: %v", err)
+					}
+					if !bytes.Equal(decoded, input.data) {
+						t.Fatalf("decoded output mismatch at byte %d", firstMismatch(decoded, input.data))
+					}
+				})
+			}
+
+			t.Run("liblzo2-to-ours/1z-high", func(t *testing.T) {
+				compressed, err := runLZO2Helper(helper, "compress-1z-high", inputPath)
+				if err != nil {
+					t.Fatalf("liblzo2 compress: %v", err)
+				}
+
+				decoded, err := lzo.DecompressLZO1Z(compressed, make([]byte, len(input.data)))
+				if err != nil {
+					t.Fatalf("DecompressLZO1Z: %v", err)
+				}
+				if !bytes.Equal(decoded, input.data) {
+					t.Fatalf("decoded output mismatch at byte %d", firstMismatch(decoded, input.data))
+				}
+			})
+
+			t.Run("ours-to-liblzo2/2a", func(t *testing.T) {
+				compressed, err := lzo.CompressLZO2A(input.data, nil)
+				if err != nil {
+					t.Fatalf("CompressLZO2A: %v", err)
+				}
+
+				compressedPath := filepath.Join(t.TempDir(), "input.lzo")
+				if err := os.WriteFile(compressedPath, compressed, 0o600); err != nil {
+					t.Fatalf("WriteFile: %v", err)
+				}
+
+				decoded, err := runLZO2Helper(helper, "decompress-2a", compressedPath, strconv.Itoa(len(input.data)))
+				if err != nil {
+					t.Fatalf("liblzo2 decompress: %v", err)
+				}
+				if !bytes.Equal(decoded, input.data) {
+					t.Fatalf("decoded output mismatch at byte %d", firstMismatch(decoded, input.data))
+				}
+			})
+
+			legacyDecoders := map[string]func(src, dst []byte) ([]byte, int, error){
+				"1b": lzo.DecompressLZO1B,
+				"1c": lzo.DecompressLZO1C,
+				"1f": lzo.DecompressLZO1F,
+				"2a": lzo.DecompressLZO2A,
+			}
+			for _, family := range []string{"1b", "1c", "1f", "2a"} {
+				for _, engine := range []string{"fast", "high"} {
+					if family == "2a" && engine == "fast" {
+						continue // liblzo2 has only lzo2a_999_compress
+					}
+					name := family + "-" + engine
+					t.Run("liblzo2-to-ours/"+name, func(t *testing.T) {
+						compressed, err := runLZO2Helper(helper, "compress-"+name, inputPath)
+						if err != nil {
+							t.Fatalf("liblzo2 compress: %v", err)
+						}
+
+						decoded, nRead, err := legacyDecoders[family](compressed, make([]byte, len(input.data)))
+						if err != nil {
+							t.Fatalf("decompress: %v", err)
+						}
+						if nRead != len(compressed) {
+							t.Fatalf("consumed %d of %d input bytes", nRead, len(compressed))
+						}
+						if !bytes.Equal(decoded, input.data) {
+							t.Fatalf("decoded output mismatch at byte %d", firstMismatch(decoded, input.data))
+						}
+					})
+				}
+			}
+
+			// The dictionary is longer than the 0xbfff-byte window, so both sides
+			// must agree on using only its tail.
+			dict := mixedBytes(0xbfff + 1000)
+			dictPath := filepath.Join(t.TempDir(), "dict")
+			if err := os.WriteFile(dictPath, dict, 0o600); err != nil {
+				t.Fatalf("WriteFile: %v", err)
+			}
+
+			t.Run("ours-to-liblzo2/dict", func(t *testing.T) {
+				compressed, err := lzo.Compress(input.data, &lzo.CompressOptions{Level: 9, Dict: dict})
+				if err != nil {
+					t.Fatalf("Compress: %v", err)
+				}
+
+				compressedPath := filepath.Join(t.TempDir(), "input.lzo")
+				if err := os.WriteFile(compressedPath, compressed, 0o600); err != nil {
+					t.Fatalf("WriteFile: %v", err)
+				}
+
+				decoded, err := runLZO2Helper(helper, "decompress-dict", compressedPath, strconv.Itoa(len(input.data)), dictPath)
+				if err != nil {
+					t.Fatalf("liblzo2 decompress dict: %v", err)
+				}
+				if !bytes.Equal(decoded, input.data) {
+					t.Fatalf("decoded output mismatch at byte %d", firstMismatch(decoded, input.data))
+				}
+			})
+
+			t.Run("liblzo2-to-ours/dict", func(t *testing.T) {
+				compressed, err := runLZO2Helper(helper, "compress-dict", inputPath, dictPath)
+				if err != nil {
+					t.Fatalf("liblzo2 compress dict: %v", err)
+				}
+
+				decoded, err := lzo.DecompressWithDict(compressed, make([]byte, len(input.data)), dict)
+				if err != nil {
+					t.Fatalf("DecompressWithDict: %v", err)
+				}
+				if !bytes.Equal(decoded, input.data) {
+					t.Fatalf("decoded output mismatch at byte %d", firstMismatch(decoded, input.data))
+				}
+			})
+
+			t.Run("exact/dict", func(t *testing.T) {
+				want, err := runLZO2Helper(helper, "compress-dict", inputPath, dictPath)
+				if err != nil {
+					t.Fatalf("liblzo2 compress dict: %v", err)
+				}
+
+				got, err := lzo.Compress(input.data, &lzo.CompressOptions{Level: 8, Dict: dict, Exact: true})
+				if err != nil {
+					t.Fatalf("Compress: %v", err)
+				}
+				if !bytes.Equal(got, want) {
+					t.Fatalf("output differs from liblzo2 at byte %d (%d vs %d bytes)", firstMismatch(got, want), len(got), len(want))
+				}
+			})
 		})
 	}
 }
 
+// compatMethod pairs an LZO1X-1 method with the helper operation running its liblzo2 counterpart.
+type compatMethod struct {
+	name      string
+	operation string
+	method    lzo.Method
+}
+
+var compatMethods = []compatMethod{
+	{name: "fast", operation: "compress-fast", method: lzo.MethodLZO1X1},
+	{name: "fast-11", operation: "compress-fast-11", method: lzo.MethodLZO1X1_11},
+	{name: "fast-12", operation: "compress-fast-12", method: lzo.MethodLZO1X1_12},
+	{name: "fast-15", operation: "compress-fast-15", method: lzo.MethodLZO1X1_15},
+}
+
 func runLZO2Helper(helper string, args ...string) ([]byte, error) {
 	cmd := exec.Command(helper, args...)
 	output, err := cmd.Output()
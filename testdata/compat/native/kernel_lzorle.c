@@ -0,0 +1,141 @@
+/*
+ * Userspace build of the Linux kernel's lzo-rle compressor, used to generate
+ * the vectors in testdata/lzorle. It compiles lib/lzo/lzo1x_compress.c from a
+ * kernel tree unchanged, with just enough of the kernel headers stubbed here.
+ *
+ * cc -O2 -I<linux>/lib/lzo -I<stub include dir> kernel_lzorle.c
+ * where the stub include dir holds empty linux/module.h, linux/kernel.h,
+ * linux/lzo.h, linux/unaligned.h and asm/unaligned.h.
+ *
+ * Usage: kernel_lzorle <input> > <output>
+ */
+
+#include <errno.h>
+#include <stdint.h>
+#include <stdio.h>
+#include <stdlib.h>
+#include <string.h>
+
+/* glibc defines both byte order names; the kernel defines only its own. */
+#undef __BIG_ENDIAN
+#undef __LITTLE_ENDIAN
+#define __LITTLE_ENDIAN 1234
+
+#define CONFIG_X86_64 1
+#define CONFIG_64BIT 1
+#define CONFIG_HAVE_EFFICIENT_UNALIGNED_ACCESS 1
+
+typedef uint8_t u8;
+typedef uint16_t u16;
+typedef uint32_t u32;
+typedef uint64_t u64;
+
+#define likely(x) __builtin_expect(!!(x), 1)
+#define unlikely(x) __builtin_expect(!!(x), 0)
+#define noinline __attribute__((noinline))
+#ifndef __always_inline
+#define __always_inline inline __attribute__((always_inline))
+#endif
+#define min(a, b) ((a) < (b) ? (a) : (b))
+#define min_t(type, a, b) ((type)(a) < (type)(b) ? (type)(a) : (type)(b))
+#define BUILD_BUG_ON(cond) ((void)sizeof(char[1 - 2 * !!(cond)]))
+#define EXPORT_SYMBOL_GPL(sym)
+#define EXPORT_SYMBOL(sym)
+#define MODULE_LICENSE(text)
+#define MODULE_DESCRIPTION(text)
+
+#define get_unaligned(ptr) \
+    ({ \
+        __typeof__(*(ptr) + 0) v_; \
+        memcpy(&v_, (ptr), sizeof(v_)); \
+        v_; \
+    })
+#define put_unaligned(val, ptr) \
+    do { \
+        __typeof__(*(ptr)) v_ = (val); \
+        memcpy((ptr), &v_, sizeof(v_)); \
+    } while (0)
+
+static inline u16 get_unaligned_le16(const void *p) {
+    u16 v;
+    memcpy(&v, p, sizeof(v));
+    return v;
+}
+
+static inline u32 get_unaligned_le32(const void *p) {
+    u32 v;
+    memcpy(&v, p, sizeof(v));
+    return v;
+}
+
+static inline void put_unaligned_le32(u32 v, void *p) {
+    memcpy(p, &v, sizeof(v));
+}
+
+/* include/linux/lzo.h */
+#define LZO1X_1_MEM_COMPRESS (8192 * sizeof(unsigned short))
+#define LZO1X_MEM_COMPRESS LZO1X_1_MEM_COMPRESS
+#define lzo1x_worst_compress(x) ((x) + ((x) / 16) + 64 + 3 + 2)
+#define LZO_E_OK 0
+#define LZO_E_ERROR (-1)
+#define LZO_E_OUT_OF_MEMORY (-2)
+#define LZO_E_NOT_COMPRESSIBLE (-3)
+#define LZO_E_INPUT_OVERRUN (-4)
+#define LZO_E_OUTPUT_OVERRUN (-5)
+#define LZO_E_LOOKBEHIND_OVERRUN (-6)
+#define LZO_E_EOF_NOT_FOUND (-7)
+#define LZO_E_INPUT_NOT_CONSUMED (-8)
+#define LZO_E_NOT_YET_IMPLEMENTED (-9)
+#define LZO_E_INVALID_ARGUMENT (-10)
+
+#include "lzo1x_compress.c"
+
+int main(int argc, char **argv) {
+    if (argc != 2) {
+        fprintf(stderr, "usage: %s <input>\n", argv[0]);
+        return 2;
+    }
+
+    FILE *file = fopen(argv[1], "rb");
+    if (file == NULL) {
+        fprintf(stderr, "open %s: %s\n", argv[1], strerror(errno));
+        return 1;
+    }
+    size_t cap = 1 << 16, size = 0;
+    unsigned char *input = malloc(cap);
+    for (size_t n; input != NULL && (n = fread(input + size, 1, cap - size, file)) > 0;) {
+        size += n;
+        if (size == cap) {
+            cap *= 2;
+            input = realloc(input, cap);
+        }
+    }
+    fclose(file);
+    if (input == NULL) {
+        fprintf(stderr, "allocate input\n");
+        return 1;
+    }
+
+    size_t out_len = lzo1x_worst_compress(size);
+    unsigned char *output = malloc(out_len);
+    void *wrkmem = malloc(LZO1X_1_MEM_COMPRESS);
+    if (output == NULL || wrkmem == NULL) {
+        fprintf(stderr, "allocate output\n");
+        return 1;
+    }
+
+    int result = lzorle1x_1_compress(input, size, output, &out_len, wrkmem);
+    if (result != LZO_E_OK) {
+        fprintf(stderr, "lzorle1x_1_compress: error %d\n", result);
+        return 1;
+    }
+    if (out_len > 0 && fwrite(output, 1, out_len, stdout) != out_len) {
+        fprintf(stderr, "write output: %s\n", strerror(errno));
+        return 1;
+    }
+
+    free(wrkmem);
+    free(output);
+    free(input);
+    return 0;
+}
@@ -4,7 +4,13 @@
 #include <stdlib.h>
 #include <string.h>
 
+#include <lzo/lzo1b.h>
+#include <lzo/lzo1c.h>
+#include <lzo/lzo1f.h>
 #include <lzo/lzo1x.h>
+#include <lzo/lzo1y.h>
+#include <lzo/lzo1z.h>
+#include <lzo/lzo2a.h>
 
 static unsigned char *read_input(const char *path, lzo_uint *size) {
     FILE *file = fopen(path, "rb");
@@ -52,11 +58,44 @@ static int write_output(const unsigned char *data, lzo_uint size) {
     return 0;
 }
 
-static int compress_input(const unsigned char *input, lzo_uint input_size, int high) {
+/* Compressors selectable by operation name. */
+static const struct {
+    const char *operation;
+    lzo_compress_t compress;
+    size_t work_size;
+} compressors[] = {
+    {"compress-fast", lzo1x_1_compress, LZO1X_1_MEM_COMPRESS},
+    {"compress-fast-11", lzo1x_1_11_compress, LZO1X_1_11_MEM_COMPRESS},
+    {"compress-fast-12", lzo1x_1_12_compress, LZO1X_1_12_MEM_COMPRESS},
+    {"compress-fast-15", lzo1x_1_15_compress, LZO1X_1_15_MEM_COMPRESS},
+    {"compress-high", lzo1x_999_compress, LZO1X_999_MEM_COMPRESS},
+    {"compress-1y-fast", lzo1y_1_compress, LZO1Y_MEM_COMPRESS},
+    {"compress-1y-high", lzo1y_999_compress, LZO1Y_999_MEM_COMPRESS},
+    {"compress-1z-high", lzo1z_999_compress, LZO1Z_999_MEM_COMPRESS},
+    {"compress-1b-fast", lzo1b_1_compress, LZO1B_MEM_COMPRESS},
+    {"compress-1b-high", lzo1b_999_compress, LZO1B_999_MEM_COMPRESS},
+    {"compress-1c-fast", lzo1c_1_compress, LZO1C_MEM_COMPRESS},
+    {"compress-1c-high", lzo1c_999_compress, LZO1C_999_MEM_COMPRESS},
+    {"compress-1f-fast", lzo1f_1_compress, LZO1F_MEM_COMPRESS},
+    {"compress-1f-high", lzo1f_999_compress, LZO1F_999_MEM_COMPRESS},
+    {"compress-2a-high", lzo2a_999_compress, LZO2A_999_MEM_COMPRESS},
+};
+
+/* Safe decompressors selectable by operation name. */
+static const struct {
+    const char *operation;
+    lzo_decompress_t decompress;
+} decompressors[] = {
+    {"decompress", lzo1x_decompress_safe},
+    {"decompress-1y", lzo1y_decompress_safe},
+    {"decompress-1z", lzo1z_decompress_safe},
+    {"decompress-2a", lzo2a_decompress_safe},
+};
+
+static int compress_input(const unsigned char *input, lzo_uint input_size, size_t compressor) {
     lzo_uint output_size = input_size + input_size / 16 + 64 + 3;
     unsigned char *output = malloc(output_size > 0 ? output_size : 1);
-    size_t work_size = high ? LZO1X_999_MEM_COMPRESS : LZO1X_1_MEM_COMPRESS;
-    void *work = malloc(work_size);
+    void *work = malloc(compressors[compressor].work_size);
     if (output == NULL || work == NULL) {
         fprintf(stderr, "allocate compression buffers: %s\n", strerror(errno));
         free(output);
@@ -64,9 +103,7 @@ static int compress_input(const unsigned char *input, lzo_uint input_size, int h
         return 1;
     }
 
-    int result = high
-        ? lzo1x_999_compress(input, input_size, output, &output_size, work)
-        : lzo1x_1_compress(input, input_size, output, &output_size, work);
+    int result = compressors[compressor].compress(input, input_size, output, &output_size, work);
     free(work);
     if (result != LZO_E_OK) {
         fprintf(stderr, "compress: liblzo2 error %d\n", result);
@@ -79,7 +116,91 @@ static int compress_input(const unsigned char *input, lzo_uint input_size, int h
     return result;
 }
 
-static int decompress_input(const unsigned char *input, lzo_uint input_size, lzo_uint output_size) {
+static int find_compressor(const char *operation, size_t *compressor) {
+    for (size_t i = 0; i < sizeof(compressors) / sizeof(compressors[0]); i++) {
+        if (strcmp(operation, compressors[i].operation) == 0) {
+            *compressor = i;
+            return 1;
+        }
+    }
+    return 0;
+}
+
+static int find_decompressor(const char *operation, size_t *decompressor) {
+    for (size_t i = 0; i < sizeof(decompressors) / sizeof(decompressors[0]); i++) {
+        if (strcmp(operation, decompressors[i].operation) == 0) {
+            *decompressor = i;
+            return 1;
+        }
+    }
+    return 0;
+}
+
+static int compress_dict_input(const unsigned char *input, lzo_uint input_size,
+                               const unsigned char *dict, lzo_uint dict_size) {
+    lzo_uint output_size = input_size + input_size / 16 + 64 + 3;
+    unsigned char *output = malloc(output_size > 0 ? output_size : 1);
+    void *work = malloc(LZO1X_999_MEM_COMPRESS);
+    if (output == NULL || work == NULL) {
+        fprintf(stderr, "allocate compression buffers: %s\n", strerror(errno));
+        free(output);
+        free(work);
+        return 1;
+    }
+
+    int result = lzo1x_999_compress_dict(input, input_size, output, &output_size, work, dict, dict_size);
+    free(work);
+    if (result != LZO_E_OK) {
+        fprintf(stderr, "compress dict: liblzo2 error %d\n", result);
+        free(output);
+        return 1;
+    }
+
+    result = write_output(output, output_size);
+    free(output);
+    return result;
+}
+
+static int compress_level_input(const unsigned char *input, lzo_uint input_size, const char *level_text,
+                                int lzo1y) {
+    char *end = NULL;
+    errno = 0;
+    long level = strtol(level_text, &end, 10);
+    if (errno != 0 || end == level_text || *end != '\0' || level < 1 || level > 9) {
+        fprintf(stderr, "invalid level: %s\n", level_text);
+        return 2;
+    }
+
+    lzo_uint output_size = input_size + input_size / 16 + 64 + 3;
+    unsigned char *output = malloc(output_size > 0 ? output_size : 1);
+    void *work = malloc(lzo1y ? LZO1Y_999_MEM_COMPRESS : LZO1X_999_MEM_COMPRESS);
+    if (output == NULL || work == NULL) {
+        fprintf(stderr, "allocate compression buffers: %s\n", strerror(errno));
+        free(output);
+        free(work);
+        return 1;
+    }
+
+    int result;
+    if (lzo1y) {
+        result = lzo1y_999_compress_level(input, input_size, output, &output_size, work, NULL, 0, NULL, (int)level);
+    } else {
+        result = lzo1x_999_compress_level(input, input_size, output, &output_size, work, NULL, 0, NULL, (int)level);
+    }
+    free(work);
+    if (result != LZO_E_OK) {
+        fprintf(stderr, "compress level: liblzo2 error %d\n", result);
+        free(output);
+        return 1;
+    }
+
+    result = write_output(output, output_size);
+    free(output);
+    return result;
+}
+
+static int decompress_dict_input(const unsigned char *input, lzo_uint input_size, lzo_uint output_size,
+                                 const unsigned char *dict, lzo_uint dict_size) {
     lzo_uint decoded_size = output_size;
     unsigned char *output = malloc(output_size > 0 ? output_size : 1);
     if (output == NULL) {
@@ -87,7 +208,40 @@ static int decompress_input(const unsigned char *input, lzo_uint input_size, lzo
         return 1;
     }
 
-    int result = lzo1x_decompress_safe(input, input_size, output, &decoded_size, NULL);
+    int result = lzo1x_decompress_dict_safe(input, input_size, output, &decoded_size, NULL, dict, dict_size);
+    if (result != LZO_E_OK) {
+        fprintf(stderr, "decompress dict: liblzo2 error %d\n", result);
+        free(output);
+        return 1;
+    }
+
+    result = write_output(output, decoded_size);
+    free(output);
+    return result;
+}
+
+static int parse_size(const char *text, lzo_uint *size) {
+    char *end = NULL;
+    errno = 0;
+    unsigned long value = strtoul(text, &end, 10);
+    if (errno != 0 || end == text || *end != '\0' || value > LZO_UINT_MAX) {
+        fprintf(stderr, "invalid output size: %s\n", text);
+        return 2;
+    }
+    *size = (lzo_uint)value;
+    return 0;
+}
+
+static int decompress_input(const unsigned char *input, lzo_uint input_size, lzo_uint output_size,
+                            size_t decompressor) {
+    lzo_uint decoded_size = output_size;
+    unsigned char *output = malloc(output_size > 0 ? output_size : 1);
+    if (output == NULL) {
+        fprintf(stderr, "allocate output: %s\n", strerror(errno));
+        return 1;
+    }
+
+    int result = decompressors[decompressor].decompress(input, input_size, output, &decoded_size, NULL);
     if (result != LZO_E_OK) {
         fprintf(stderr, "decompress: liblzo2 error %d\n", result);
         free(output);
@@ -100,8 +254,15 @@ static int decompress_input(const unsigned char *input, lzo_uint input_size, lzo
 }
 
 int main(int argc, char **argv) {
-    if (argc < 3 || argc > 4) {
-        fprintf(stderr, "usage: %s <compress-fast|compress-high|decompress> <input> [output-size]\n", argv[0]);
+    if (argc < 3 || argc > 5) {
+        fprintf(stderr,
+                "usage: %s <compress-fast[-11|-12|-15]|compress-high|compress-1y-fast|compress-1y-high|compress-1z-high|\n"
+                "              compress-1[bcf]-fast|compress-1[bcf]-high|compress-2a-high> <input>\n"
+                "       %s <decompress|decompress-1y|decompress-1z|decompress-2a> <input> <output-size>\n"
+                "       %s <compress-level|compress-1y-level> <input> <level>\n"
+                "       %s compress-dict <input> <dict>\n"
+                "       %s decompress-dict <input> <output-size> <dict>\n",
+                argv[0], argv[0], argv[0], argv[0], argv[0]);
         return 2;
     }
     if (lzo_init() != LZO_E_OK) {
@@ -116,20 +277,36 @@ int main(int argc, char **argv) {
     }
 
     int result;
-    if (strcmp(argv[1], "compress-fast") == 0 && argc == 3) {
-        result = compress_input(input, input_size, 0);
-    } else if (strcmp(argv[1], "compress-high") == 0 && argc == 3) {
-        result = compress_input(input, input_size, 1);
-    } else if (strcmp(argv[1], "decompress") == 0 && argc == 4) {
-        char *end = NULL;
-        errno = 0;
-        unsigned long output_size = strtoul(argv[3], &end, 10);
-        if (errno != 0 || end == argv[3] || *end != '\0' || output_size > LZO_UINT_MAX) {
-            fprintf(stderr, "invalid output size: %s\n", argv[3]);
+    size_t compressor = 0;
+    size_t decompressor = 0;
+    if (argc == 3 && find_compressor(argv[1], &compressor)) {
+        result = compress_input(input, input_size, compressor);
+    } else if (argc == 4 && find_decompressor(argv[1], &decompressor)) {
+        lzo_uint output_size = 0;
+        result = parse_size(argv[3], &output_size);
+        if (result == 0) {
+            result = decompress_input(input, input_size, output_size, decompressor);
+        }
+    } else if (argc == 4 && (strcmp(argv[1], "compress-level") == 0 || strcmp(argv[1], "compress-1y-level") == 0)) {
+        result = compress_level_input(input, input_size, argv[3], strcmp(argv[1], "compress-1y-level") == 0);
+    } else if ((strcmp(argv[1], "compress-dict") == 0 && argc == 4) ||
+               (strcmp(argv[1], "decompress-dict") == 0 && argc == 5)) {
+        lzo_uint dict_size = 0;
+        unsigned char *dict = read_input(argv[argc - 1], &dict_size);
+        if (dict == NULL) {
             free(input);
-            return 2;
+            return 1;
+        }
+        if (argc == 4) {
+            result = compress_dict_input(input, input_size, dict, dict_size);
+        } else {
+            lzo_uint output_size = 0;
+            result = parse_size(argv[3], &output_size);
+            if (result == 0) {
+                result = decompress_dict_input(input, input_size, output_size, dict, dict_size);
+            }
         }
-        result = decompress_input(input, input_size, (lzo_uint)output_size);
+        free(dict);
     } else {
         fprintf(stderr, "invalid operation\n");
         result = 2;
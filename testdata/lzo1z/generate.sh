@@ -0,0 +1,28 @@
+#!/bin/sh
+# SPDX-License-Identifier: MIT
+# Copyright (c) 2026 WoozyMasta
+# Source: github.com/woozymasta/lzo
+#
+# Regenerates the LZO1Z vectors decoded by lzo1z_test.go: every <name>.txt is
+# compressed with liblzo2's lzo1z_999_compress into <name>.lzo1z.
+# Usage: generate.sh <lzo2_compat helper> (make testdata-fixtures builds it).
+
+set -eu
+
+helper=$(realpath "$1")
+cd "$(dirname "$0")"
+corpus=../corpus
+
+rm -f ./*.txt ./*.lzo1z
+
+cp "$corpus/lorem-ipsum.txt" "$corpus/long-repeated.txt" "$corpus/json.txt" "$corpus/binary.txt" .
+# Fixed-width records make lzo1z_999_compress repeat match distances.
+i=0
+while [ $i -lt 600 ]; do
+	printf 'id=%05d name=item-%03d status=%s\n' $i $((i % 97)) "$([ $((i % 3)) -eq 0 ] && echo ok || echo fail)"
+	i=$((i + 1))
+done >records.txt
+
+for f in ./*.txt; do
+	"$helper" compress-1z-high "$f" >"${f%.txt}.lzo1z"
+done
@@ -0,0 +1,101 @@
+#!/bin/sh
+# SPDX-License-Identifier: MIT
+# Copyright (c) 2026 WoozyMasta
+# Source: github.com/woozymasta/lzo
+#
+# Regenerates the lzo-rle vectors read by lzorle_test.go: every <name>.bin is
+# compressed by the Linux kernel's lzorle1x_1_compress into <name>.lzorle.
+# Usage: generate.sh <linux source tree>
+# The compressor is lib/lzo/lzo1x_compress.c from that tree, built in userspace
+# by testdata/compat/native/kernel_lzorle.c.
+
+set -eu
+
+[ $# -eq 1 ] || {
+	echo "usage: $0 <linux source tree>" >&2
+	exit 2
+}
+linux=$(cd "$1" && pwd)
+[ -f "$linux/lib/lzo/lzo1x_compress.c" ] || {
+	echo "$linux/lib/lzo/lzo1x_compress.c not found" >&2
+	exit 1
+}
+
+cd "$(dirname "$0")"
+corpus=../corpus
+tmp=$(mktemp -d)
+trap 'rm -rf "$tmp"' EXIT
+
+# The kernel headers the compressor includes are replaced by the shim.
+mkdir -p "$tmp/include/linux" "$tmp/include/asm"
+for h in linux/module.h linux/kernel.h linux/lzo.h linux/unaligned.h asm/unaligned.h; do
+	: >"$tmp/include/$h"
+done
+${CC:-cc} -O2 -I"$linux/lib/lzo" -I"$tmp/include" -o "$tmp/kernel_lzorle" ../compat/native/kernel_lzorle.c
+
+rm -f ./*.bin ./*.lzorle
+
+# zeros <n> writes n zero bytes.
+zeros() {
+	head -c "$1" /dev/zero
+}
+
+# nonzero <n> writes n random bytes without zeros.
+nonzero() {
+	head -c "$1" /dev/urandom | tr '\000' '\001'
+}
+
+# text <n> writes n bytes of corpus text without zeros.
+for i in $(seq 200); do
+	cat "$corpus/lorem-ipsum.txt" "$corpus/json.txt"
+done >"$tmp/text"
+text() {
+	head -c "$1" "$tmp/text"
+}
+
+zeros 4096 >zero.bin
+text 4096 >text.bin
+head -c 4096 /dev/urandom >random.bin
+
+# Zero runs around the 4-byte minimum and the 2051-byte maximum.
+{
+	for n in 1 3 4 5 31 32 33 2050 2051 2052 4103; do
+		head -c 97 "$corpus/json.txt"
+		zeros "$n"
+	done
+	head -c 97 "$corpus/mixed.txt"
+} >mixed.bin
+
+# A mostly empty page, as swapped out by zram.
+{
+	zeros 1000
+	text 300
+	zeros 2000
+	nonzero 24
+	zeros 772
+} >sparse.bin
+
+# Several 0xbfff-byte chunks, each compressed with a cleared hash table.
+{
+	for i in $(seq 16); do
+		text 7000
+		cat "$corpus/binary.txt"
+		zeros 3000
+	done
+} >large.bin
+
+# M4 matches of 261-264 bytes at distance 0x803f: the kernel shortens them to 260.
+nonzero 300 >"$tmp/head"
+for n in 261 262 263 264; do
+	{
+		cat "$tmp/head"
+		text $((0x803f - 300))
+		head -c "$n" "$tmp/head"
+		printf 'x'
+		text 100
+	} >"clamp-$n.bin"
+done
+
+for f in ./*.bin; do
+	"$tmp/kernel_lzorle" "$f" >"${f%.bin}.lzorle"
+done
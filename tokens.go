@@ -0,0 +1,90 @@
+// SPDX-License-Identifier: MIT
+// Copyright (c) 2026 WoozyMasta
+// Source: github.com/woozymasta/lzo
+
+package lzo
+
+import (
+	"iter"
+	"strconv"
+)
+
+// TokenKind is the type of one LZO1X instruction.
+type TokenKind uint8
+
+const (
+	TokenInitialLiteral TokenKind = iota // TokenInitialLiteral is the literal run encoded by the first stream byte.
+	TokenLiteral                         // TokenLiteral is a literal run encoded in literal state 0.
+	TokenM1                              // TokenM1 is a 2-byte short match within 1 KiB (literal state 1–3).
+	TokenM1Long                          // TokenM1Long is a 3-byte short match after a 4-literal tail.
+	TokenM2                              // TokenM2 is a 3–8 byte match within 2 KiB.
+	TokenM3                              // TokenM3 is a match within 16 KiB.
+	TokenM4                              // TokenM4 is a match within 48 KiB.
+	TokenEnd                             // TokenEnd is the stream terminator.
+)
+
+// tokenKindNames holds the String names of TokenKind values.
+var tokenKindNames = [...]string{
+	TokenInitialLiteral: "initial-literal",
+	TokenLiteral:        "literal",
+	TokenM1:             "M1",
+	TokenM1Long:         "M1-after-4-literals",
+	TokenM2:             "M2",
+	TokenM3:             "M3",
+	TokenM4:             "M4",
+	TokenEnd:            "end",
+}
+
+// String returns a short name of the kind.
+func (k TokenKind) String() string {
+	if int(k) < len(tokenKindNames) {
+		return tokenKindNames[k]
+	}
+
+	return "TokenKind(" + strconv.Itoa(int(k)) + ")"
+}
+
+// Token describes one instruction of an LZO1X stream.
+// A match token includes the 0–3 literal bytes packed after it (LiteralLen);
+// a literal token has MatchLen and Distance of 0.
+type Token struct {
+	InOffset   int       // InOffset is the input offset of the instruction's first byte.
+	OutOffset  int       // OutOffset is the output offset the instruction starts writing at.
+	LiteralLen int       // LiteralLen is the number of literal bytes the instruction copies.
+	MatchLen   int       // MatchLen is the back-reference length (3 for TokenEnd).
+	Distance   int       // Distance is the back-reference distance.
+	Size       int       // Size is the encoded size in bytes, including literal bytes.
+	Kind       TokenKind // Kind is the instruction type.
+}
+
+// Tokens returns an iterator over the instructions of the LZO1X stream in src.
+// It uses the same parser as the decompressor and stops after TokenEnd.
+// On a malformed stream it yields one final Token holding only the offsets of
+// the failing instruction, together with the error the decompressor would return.
+func Tokens(src []byte) iter.Seq2[Token, error] {
+	return func(yield func(Token, error) bool) {
+		if len(src) == 0 {
+			yield(Token{}, ErrEmptyInput)
+			return
+		}
+
+		var s streamScan
+		for {
+			tok := Token{InOffset: s.inPos, OutOffset: s.outPos}
+			ins, err := s.next(src)
+			if err != nil {
+				yield(tok, err)
+				return
+			}
+
+			tok.Kind = ins.kind
+			tok.LiteralLen = ins.litLen
+			tok.MatchLen = ins.matchLen
+			tok.Distance = ins.matchDist
+			tok.Size = s.inPos - tok.InOffset
+			if !yield(tok, nil) || ins.kind == TokenEnd {
+				return
+			}
+		}
+	}
+}
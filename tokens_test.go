@@ -0,0 +1,124 @@
+package lzo
+
+import (
+	"bytes"
+	"errors"
+	"testing"
+)
+
+// replayTokens rebuilds the decompressed output of src from its tokens.
+func replayTokens(t *testing.T, src []byte) (out []byte, nRead int, kinds map[TokenKind]int) {
+	t.Helper()
+
+	kinds = make(map[TokenKind]int)
+	for tok, err := range Tokens(src) {
+		if err != nil {
+			t.Fatalf("Tokens failed at in=%d: %v", tok.InOffset, err)
+		}
+		if tok.InOffset != nRead || tok.OutOffset != len(out) {
+			t.Fatalf("%v: offsets (%d, %d), want (%d, %d)", tok.Kind, tok.InOffset, tok.OutOffset, nRead, len(out))
+		}
+		kinds[tok.Kind]++
+		nRead += tok.Size
+
+		for range tok.MatchLen {
+			if tok.Kind == TokenEnd {
+				break
+			}
+			out = append(out, out[len(out)-tok.Distance])
+		}
+		out = append(out, src[nRead-tok.LiteralLen:nRead]...)
+	}
+
+	return out, nRead, kinds
+}
+
+func TestTokensReplayMatchesDecompress(t *testing.T) {
+	inputs := testInputSet(t)
+	inputs = append(inputs, struct {
+		name string
+		data []byte
+	}{name: "far-matches", data: ringTestInput()})
+
+	seen := make(map[TokenKind]int)
+	for _, in := range inputs {
+		if len(in.data) == 0 {
+			continue
+		}
+		for _, level := range []int{1, 9} {
+			cmp, err := Compress(in.data, &CompressOptions{Level: level})
+			if err != nil {
+				t.Fatalf("%s level=%d: Compress failed: %v", in.name, level, err)
+			}
+
+			out, nRead, kinds := replayTokens(t, cmp)
+			if nRead != len(cmp) || !bytes.Equal(out, in.data) {
+				t.Fatalf("%s level=%d: replay mismatch (nRead=%d want=%d)", in.name, level, nRead, len(cmp))
+			}
+			if kinds[TokenEnd] != 1 {
+				t.Fatalf("%s level=%d: expected one terminator, got %d", in.name, level, kinds[TokenEnd])
+			}
+			for k, n := range kinds {
+				seen[k] += n
+			}
+		}
+	}
+
+	for _, k := range []TokenKind{TokenInitialLiteral, TokenM1, TokenM2, TokenM3, TokenM4} {
+		if seen[k] == 0 {
+			t.Errorf("corpus produced no %v tokens", k)
+		}
+	}
+}
+
+func TestTokensCanonicalStream(t *testing.T) {
+	compressed := []byte{0x12, 0x00, 0x20, 0x00, 0xdf, 0x00, 0x00, 0x11, 0x00, 0x00}
+	want := []Token{
+		{Kind: TokenInitialLiteral, LiteralLen: 1, Size: 2},
+		{Kind: TokenM3, InOffset: 2, OutOffset: 1, MatchLen: 511, Distance: 1, Size: 5},
+		{Kind: TokenEnd, InOffset: 7, OutOffset: 512, MatchLen: 3, Size: 3},
+	}
+
+	var got []Token
+	for tok, err := range Tokens(compressed) {
+		if err != nil {
+			t.Fatalf("Tokens failed: %v", err)
+		}
+		got = append(got, tok)
+	}
+	if len(got) != len(want) {
+		t.Fatalf("got %d tokens, want %d: %+v", len(got), len(want), got)
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Fatalf("token %d: got %+v, want %+v", i, got[i], want[i])
+		}
+	}
+}
+
+func TestTokensErrors(t *testing.T) {
+	for tok, err := range Tokens(nil) {
+		if !errors.Is(err, ErrEmptyInput) || tok != (Token{}) {
+			t.Fatalf("empty: got %+v, %v", tok, err)
+		}
+	}
+
+	var last Token
+	var lastErr error
+	for tok, err := range Tokens([]byte{0x12, 'a', markerM3 | 1, 1 << 2, 0}) {
+		last, lastErr = tok, err
+	}
+	if !errors.Is(lastErr, ErrLookBehindUnderrun) || last.InOffset != 2 || last.OutOffset != 1 {
+		t.Fatalf("underrun: got %+v, %v", last, lastErr)
+	}
+
+	// Breaking out of the loop early must stop the iterator.
+	n := 0
+	for range Tokens([]byte{0x12, 0x00, 0x20, 0x00, 0xdf, 0x00, 0x00, 0x11, 0x00, 0x00}) {
+		n++
+		break
+	}
+	if n != 1 {
+		t.Fatalf("expected 1 token before break, got %d", n)
+	}
+}
@@ -0,0 +1,417 @@
+// SPDX-License-Identifier: MIT
+// Copyright (c) 2026 WoozyMasta
+// Source: github.com/woozymasta/lzo
+
+package lzo
+
+import "io"
+
+// Writer is an io.WriteCloser that compresses written data into a block-framed stream.
+// Input is cut into CompressOptions.BlockSize blocks; each block is compressed independently,
+// or with the preceding input as its dictionary when CompressOptions.LinkedBlocks is set.
+// With CompressOptions.Concurrency above 1, up to that many blocks are compressed
+// in parallel by as many worker goroutines and written in order; the stream is
+// the same as without it. Close stops the workers.
+// With CompressOptions.Frame the stream is wrapped in a frame with a header
+// and optional checksums, and skippable metadata frames may be written around it.
+// Close must be called to write the end-of-stream marker.
+// A Writer must not be used concurrently.
+type Writer struct {
+	w    io.Writer
+	err  error
+	buf  []byte // buf holds pending input that does not fill a whole block yet.
+	out  []byte // out is the reusable encoded block (header plus payload).
+	enc  Encoder
+	opts CompressOptions
+	hist streamHistory // hist is the preceding input of a linked stream.
+
+	framed    bool          // framed reports whether the stream is wrapped in a frame.
+	badFrame  bool          // badFrame reports invalid FrameOptions, rejected by openFrame.
+	frameOpen bool          // frameOpen reports whether the frame header was written.
+	header    FrameHeader   // header describes the frame to write.
+	sum       frameChecksum // sum is the running content checksum.
+	written   int64         // written is the uncompressed size of the frame so far.
+
+	// blocks is the ring of parallel block slots; queued blocks occupy
+	// blocks[next], blocks[next+1], ... in stream order, wrapping around.
+	blocks []*writerBlock
+	next   int // next is the index of the oldest queued block.
+	queued int // queued is the number of blocks being compressed or not yet written.
+
+	// jobs feeds queued blocks to the worker goroutines, each of which owns
+	// an Encoder; it is nil while no workers run.
+	jobs chan *writerBlock
+
+	blockSize int
+	closed    bool
+}
+
+// writerBlock is one slot of a parallel Writer: a block queued for or being
+// compressed by a worker goroutine.
+type writerBlock struct {
+	in   []byte        // in is a copy of the uncompressed block.
+	dict []byte        // dict is a copy of the linked-stream window before the block.
+	out  []byte        // out is the encoded block (header plus payload).
+	err  error         // err is the compression error, if any.
+	done chan struct{} // done receives a value when out and err are ready.
+}
+
+// NewWriter returns a Writer that compresses to w. opts may be nil (uses default level 1).
+// opts.Dict is ignored.
+func NewWriter(w io.Writer, opts *CompressOptions) *Writer {
+	if opts == nil {
+		opts = DefaultCompressOptions()
+	}
+
+	z := &Writer{opts: *opts}
+	// Blocks may only reference the stream itself, never a caller dictionary.
+	z.opts.Dict = nil
+	z.blockSize = streamBlockSize(&z.opts)
+	if frame := z.opts.Frame; frame != nil {
+		z.framed = true
+		z.badFrame = frame.Checksum > ChecksumXXH64 || frame.HasContentSize && frame.ContentSize < 0
+		z.header = FrameHeader{
+			ContentSize:     -1,
+			Checksum:        frame.Checksum,
+			ContentChecksum: frame.Checksum != ChecksumNone,
+			BlockChecksums:  frame.Checksum != ChecksumNone && frame.BlockChecksums,
+			LinkedBlocks:    z.opts.LinkedBlocks,
+		}
+		if frame.HasContentSize {
+			z.header.ContentSize = frame.ContentSize
+		}
+		z.opts.Frame = nil
+	}
+	if z.opts.Concurrency > 1 {
+		z.blocks = make([]*writerBlock, z.opts.Concurrency)
+		for i := range z.blocks {
+			z.blocks[i] = &writerBlock{done: make(chan struct{}, 1)}
+		}
+	}
+	z.Reset(w)
+	return z
+}
+
+// Reset discards pending data and state and makes z write a new stream to w.
+// Compression options and internal buffers are retained.
+func (z *Writer) Reset(w io.Writer) {
+	z.discardQueued()
+
+	z.w = w
+	z.err = nil
+	z.buf = z.buf[:0]
+	z.hist.reset()
+	z.frameOpen = false
+	z.closed = false
+}
+
+// Write buffers p and writes every completed block to the underlying writer.
+func (z *Writer) Write(p []byte) (int, error) {
+	if z.err != nil {
+		return 0, z.err
+	}
+	if z.closed {
+		return 0, ErrWriterClosed
+	}
+	// Accepted data belongs to the frame even while it is still buffered.
+	if len(p) > 0 {
+		if err := z.openFrame(); err != nil {
+			return 0, err
+		}
+	}
+
+	written := 0
+	for len(p) > 0 {
+		// Whole blocks are compressed straight from p without staging them in buf.
+		if len(z.buf) == 0 && len(p) >= z.blockSize {
+			if err := z.writeBlock(p[:z.blockSize]); err != nil {
+				return written, err
+			}
+			written += z.blockSize
+			p = p[z.blockSize:]
+			continue
+		}
+
+		if z.buf == nil {
+			z.buf = make([]byte, 0, z.blockSize)
+		}
+		n := min(len(p), z.blockSize-len(z.buf))
+		z.buf = append(z.buf, p[:n]...)
+		written += n
+		p = p[n:]
+
+		if len(z.buf) == z.blockSize {
+			if err := z.writeBlock(z.buf); err != nil {
+				return written, err
+			}
+			z.buf = z.buf[:0]
+		}
+	}
+
+	return written, nil
+}
+
+// Flush compresses any pending data as a (possibly short) block and writes it,
+// after all blocks still being compressed.
+// It does not write the end-of-stream marker.
+func (z *Writer) Flush() error {
+	if z.err != nil {
+		return z.err
+	}
+	if z.closed {
+		return ErrWriterClosed
+	}
+
+	if len(z.buf) > 0 {
+		if err := z.writeBlock(z.buf); err != nil {
+			return err
+		}
+		z.buf = z.buf[:0]
+	}
+	for z.queued > 0 {
+		if err := z.writeOldest(); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Close flushes pending data, writes the end-of-stream marker and stops the
+// worker goroutines of a parallel Writer; Write after Reset starts them again.
+// It does not close the underlying writer. Calling Close again is a no-op.
+//
+// If a framed stream holds a different number of bytes than
+// FrameOptions.ContentSize, Close still ends the frame and then returns
+// ErrContentSize. Readers reject such a frame, so the output must be discarded.
+func (z *Writer) Close() error {
+	if z.closed {
+		return z.err
+	}
+	defer z.stopWorkers()
+	if err := z.Flush(); err != nil {
+		return err
+	}
+
+	if err := z.openFrame(); err != nil {
+		return err
+	}
+
+	z.closed = true
+	var end [blockSizeFieldLen + 8]byte
+	trailer := end[:blockSizeFieldLen]
+	var sizeErr error
+	if z.framed {
+		z.frameOpen = false
+		if z.header.ContentSize >= 0 && z.written != z.header.ContentSize {
+			sizeErr = ErrContentSize
+		}
+		if z.header.ContentChecksum {
+			trailer = appendChecksum(trailer, z.header.Checksum, z.sum.sum())
+		}
+	}
+	if z.err = z.writeAll(trailer); z.err == nil {
+		z.err = sizeErr
+	}
+	return z.err
+}
+
+// WriteSkippableFrame writes a skippable frame of the user-defined kind holding
+// data, at most MaxBlockSize bytes. It is allowed before the first Write and
+// after Close, so the frame precedes or follows the data frame; a following
+// data frame is started by Reset. It returns ErrInvalidFrame without
+// CompressOptions.Frame and ErrFrameOpen while a data frame is in progress.
+func (z *Writer) WriteSkippableFrame(kind uint8, data []byte) error {
+	if z.err != nil {
+		return z.err
+	}
+	if !z.framed || len(data) > MaxBlockSize {
+		return ErrInvalidFrame
+	}
+	if z.frameOpen {
+		return ErrFrameOpen
+	}
+
+	z.err = z.writeAll(appendSkippableFrame(nil, kind, data))
+	return z.err
+}
+
+// openFrame writes the frame header before the first block of a framed stream.
+func (z *Writer) openFrame() error {
+	if !z.framed || z.frameOpen {
+		return nil
+	}
+	if z.badFrame {
+		z.err = ErrInvalidFrame
+		return z.err
+	}
+
+	z.frameOpen = true
+	z.sum.reset(z.header.Checksum)
+	z.written = 0
+
+	var hdr [frameHeaderMaxLen]byte
+	z.err = z.writeAll(appendFrameHeader(hdr[:0], &z.header))
+	return z.err
+}
+
+// writeBlock compresses one block and writes it with its header, or queues it
+// for a parallel slot when Concurrency is above 1.
+func (z *Writer) writeBlock(block []byte) error {
+	if err := z.openFrame(); err != nil {
+		return err
+	}
+	if z.framed {
+		z.sum.write(block)
+		z.written += int64(len(block))
+	}
+
+	if z.blocks != nil {
+		return z.queueBlock(block)
+	}
+
+	opts := &z.opts
+	if z.opts.LinkedBlocks {
+		linked := z.opts
+		linked.Dict = z.hist.window()
+		opts = &linked
+	}
+
+	out, err := z.encode(&z.enc, z.out, block, opts)
+	if err != nil {
+		z.err = err
+		return err
+	}
+	z.out = out
+	if z.opts.LinkedBlocks {
+		z.hist.add(block)
+	}
+
+	z.err = z.writeAll(out)
+	return z.err
+}
+
+// queueBlock hands a copy of block in the next free slot to the workers,
+// writing the oldest queued block first when every slot is busy.
+func (z *Writer) queueBlock(block []byte) error {
+	if z.queued == len(z.blocks) {
+		if err := z.writeOldest(); err != nil {
+			return err
+		}
+	}
+	if z.jobs == nil {
+		z.startWorkers()
+	}
+
+	b := z.blocks[(z.next+z.queued)%len(z.blocks)]
+	z.queued++
+	b.in = append(b.in[:0], block...)
+	if z.opts.LinkedBlocks {
+		// The window is known up front, so linked blocks compress in parallel too.
+		b.dict = append(b.dict[:0], z.hist.window()...)
+		z.hist.add(block)
+	}
+	z.jobs <- b
+
+	return nil
+}
+
+// startWorkers starts one worker goroutine per slot. The jobs channel holds
+// every slot, so queueBlock never blocks on it.
+func (z *Writer) startWorkers() {
+	z.jobs = make(chan *writerBlock, len(z.blocks))
+	for range z.blocks {
+		go z.compressBlocks(z.jobs)
+	}
+}
+
+// stopWorkers waits for the queued blocks, dropping them, and ends the workers.
+func (z *Writer) stopWorkers() {
+	z.discardQueued()
+	if z.jobs != nil {
+		close(z.jobs)
+		z.jobs = nil
+	}
+}
+
+// discardQueued waits for the queued blocks, which own their slots until they
+// are compressed, and drops them.
+func (z *Writer) discardQueued() {
+	for ; z.queued > 0; z.queued-- {
+		<-z.blocks[z.next].done
+		z.next = (z.next + 1) % len(z.blocks)
+	}
+}
+
+// compressBlocks is a worker goroutine: it compresses the blocks it receives
+// with its own Encoder until jobs is closed.
+func (z *Writer) compressBlocks(jobs <-chan *writerBlock) {
+	var enc Encoder
+	for b := range jobs {
+		opts := z.opts
+		opts.Dict = b.dict
+		b.out, b.err = z.encode(&enc, b.out, b.in, &opts)
+		b.done <- struct{}{}
+	}
+}
+
+// writeOldest waits for the oldest queued block and writes it.
+func (z *Writer) writeOldest() error {
+	b := z.blocks[z.next]
+	<-b.done
+	z.next = (z.next + 1) % len(z.blocks)
+	z.queued--
+
+	if b.err != nil {
+		z.err = b.err
+	} else {
+		z.err = z.writeAll(b.out)
+	}
+	return z.err
+}
+
+// encode compresses block with enc into out and appends the block checksum
+// of a framed stream.
+func (z *Writer) encode(enc *Encoder, out, block []byte, opts *CompressOptions) ([]byte, error) {
+	out, err := encodeBlock(enc, out, block, z.blockSize, opts)
+	if err != nil || !z.header.BlockChecksums {
+		return out, err
+	}
+
+	kind := z.header.Checksum
+	return appendChecksum(out, kind, checksumOf(kind, out[blockHeaderLen:])), nil
+}
+
+// encodeBlock compresses block with enc into out, reusing its capacity, and
+// returns the block with its header. Blocks that do not shrink are stored raw.
+func encodeBlock(enc *Encoder, out, block []byte, blockSize int, opts *CompressOptions) ([]byte, error) {
+	if out == nil {
+		out = make([]byte, blockHeaderLen, blockHeaderLen+MaxCompressedSize(blockSize))
+	}
+
+	out, err := enc.AppendCompress(out[:blockHeaderLen], block, opts)
+	if err != nil {
+		return nil, err
+	}
+
+	payloadLen := len(out) - blockHeaderLen
+	if payloadLen >= len(block) {
+		out = append(out[:blockHeaderLen], block...)
+		payloadLen = len(block)
+	}
+	putBlockHeader(out, len(block), payloadLen)
+
+	return out, nil
+}
+
+// writeAll writes p to the underlying writer and reports short writes as errors.
+func (z *Writer) writeAll(p []byte) error {
+	n, err := z.w.Write(p)
+	if err != nil {
+		return err
+	}
+	if n != len(p) {
+		return io.ErrShortWrite
+	}
+
+	return nil
+}
@@ -0,0 +1,116 @@
+// SPDX-License-Identifier: MIT
+// Copyright (c) 2026 WoozyMasta
+// Source: github.com/woozymasta/lzo
+
+package lzo
+
+import (
+	"encoding/binary"
+	"math/bits"
+)
+
+// XXH64 primes from the xxHash specification.
+const (
+	xxhPrime1 uint64 = 11400714785074694791
+	xxhPrime2 uint64 = 14029467366897019727
+	xxhPrime3 uint64 = 1609587929392839161
+	xxhPrime4 uint64 = 9650029242287828579
+	xxhPrime5 uint64 = 2870177450012600261
+)
+
+// xxh64 is a streaming XXH64 hash with seed 0, used for frame checksums.
+type xxh64 struct {
+	v     [4]uint64 // v holds the four lane accumulators.
+	total uint64    // total is the number of bytes hashed.
+	mem   [32]byte  // mem buffers a partial stripe.
+	n     int       // n is the number of bytes in mem.
+}
+
+// reset restarts the hash.
+func (h *xxh64) reset() {
+	// The lane seeds wrap around, so they are computed on variables.
+	prime1, prime2 := xxhPrime1, xxhPrime2
+	h.v = [4]uint64{prime1 + prime2, prime2, 0, -prime1}
+	h.total = 0
+	h.n = 0
+}
+
+// write adds p to the hash.
+func (h *xxh64) write(p []byte) {
+	h.total += uint64(len(p))
+
+	if h.n > 0 {
+		n := copy(h.mem[h.n:], p)
+		h.n += n
+		p = p[n:]
+		if h.n < len(h.mem) {
+			return
+		}
+		h.stripe(h.mem[:])
+		h.n = 0
+	}
+
+	for ; len(p) >= len(h.mem); p = p[len(h.mem):] {
+		h.stripe(p)
+	}
+	h.n = copy(h.mem[:], p)
+}
+
+// stripe mixes one 32-byte stripe into the lanes.
+func (h *xxh64) stripe(p []byte) {
+	h.v[0] = xxhRound(h.v[0], binary.LittleEndian.Uint64(p))
+	h.v[1] = xxhRound(h.v[1], binary.LittleEndian.Uint64(p[8:]))
+	h.v[2] = xxhRound(h.v[2], binary.LittleEndian.Uint64(p[16:]))
+	h.v[3] = xxhRound(h.v[3], binary.LittleEndian.Uint64(p[24:]))
+}
+
+// sum returns the hash of the bytes written so far.
+func (h *xxh64) sum() uint64 {
+	var acc uint64
+	if h.total >= uint64(len(h.mem)) {
+		acc = bits.RotateLeft64(h.v[0], 1) + bits.RotateLeft64(h.v[1], 7) +
+			bits.RotateLeft64(h.v[2], 12) + bits.RotateLeft64(h.v[3], 18)
+		for _, v := range h.v {
+			acc = (acc^xxhRound(0, v))*xxhPrime1 + xxhPrime4
+		}
+	} else {
+		acc = xxhPrime5
+	}
+	acc += h.total
+
+	p := h.mem[:h.n]
+	for ; len(p) >= 8; p = p[8:] {
+		acc ^= xxhRound(0, binary.LittleEndian.Uint64(p))
+		acc = bits.RotateLeft64(acc, 27)*xxhPrime1 + xxhPrime4
+	}
+	if len(p) >= 4 {
+		acc ^= uint64(binary.LittleEndian.Uint32(p)) * xxhPrime1
+		acc = bits.RotateLeft64(acc, 23)*xxhPrime2 + xxhPrime3
+		p = p[4:]
+	}
+	for _, b := range p {
+		acc ^= uint64(b) * xxhPrime5
+		acc = bits.RotateLeft64(acc, 11) * xxhPrime1
+	}
+
+	acc ^= acc >> 33
+	acc *= xxhPrime2
+	acc ^= acc >> 29
+	acc *= xxhPrime3
+	acc ^= acc >> 32
+	return acc
+}
+
+// xxhRound mixes one 8-byte lane input into acc.
+func xxhRound(acc, input uint64) uint64 {
+	acc += input * xxhPrime2
+	return bits.RotateLeft64(acc, 31) * xxhPrime1
+}
+
+// xxh64Sum returns the XXH64 hash of p with seed 0.
+func xxh64Sum(p []byte) uint64 {
+	var h xxh64
+	h.reset()
+	h.write(p)
+	return h.sum()
+}
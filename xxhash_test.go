@@ -0,0 +1,49 @@
+package lzo
+
+import (
+	"hash/crc32"
+	"testing"
+)
+
+func TestXXH64Vectors(t *testing.T) {
+	cases := []struct {
+		in   string
+		want uint64
+	}{
+		{in: "", want: 0xef46db3751d8e999},
+		{in: "a", want: 0xd24ec4f1a98c6e5b},
+		{in: "abc", want: 0x44bc2cf5ad770999},
+		{in: "Nobody inspects the spammish repetition", want: 0xfbcea83c8a378bf1},
+	}
+	for _, tc := range cases {
+		if got := xxh64Sum([]byte(tc.in)); got != tc.want {
+			t.Errorf("xxh64(%q) = %#x, want %#x", tc.in, got, tc.want)
+		}
+	}
+}
+
+func TestXXH64Streaming(t *testing.T) {
+	data := benchmarkMixedBytes(1000)
+	want := xxh64Sum(data)
+
+	for _, chunk := range []int{1, 3, 31, 32, 33, 100} {
+		var h xxh64
+		h.reset()
+		for rest := data; len(rest) > 0; {
+			n := min(chunk, len(rest))
+			h.write(rest[:n])
+			rest = rest[n:]
+		}
+		if got := h.sum(); got != want {
+			t.Errorf("chunk=%d: sum = %#x, want %#x", chunk, got, want)
+		}
+	}
+
+	var c frameChecksum
+	c.reset(ChecksumCRC32)
+	c.write(data[:500])
+	c.write(data[500:])
+	if got := c.sum(); got != uint64(crc32.ChecksumIEEE(data)) {
+		t.Errorf("CRC-32 = %#x, want %#x", got, crc32.ChecksumIEEE(data))
+	}
+}